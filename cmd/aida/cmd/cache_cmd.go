@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/spf13/cobra"
+)
+
+// bytesPerKiB converts cache sizes for display.
+const bytesPerKiB = 1024
+
+func newCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect or clear the cache of model answers",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stats",
+		Short: "Show how many answers are cached",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, err := loadCache(cmd)
+			if err != nil {
+				return err
+			}
+
+			stats, err := store.Stats()
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			_, _ = fmt.Fprintf(out, "Directory: %s\n", store.Dir())
+			_, _ = fmt.Fprintf(out, "Entries: %d (%d expired)\n", stats.Entries, stats.Expired)
+			_, _ = fmt.Fprintf(out, "Size: %.1f KiB\n", float64(stats.Size)/bytesPerKiB)
+
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove every cached answer",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, err := loadCache(cmd)
+			if err != nil {
+				return err
+			}
+
+			removed, err := store.Clear()
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Removed %d cached answers\n", removed)
+
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// openCache returns the answer cache with the TTL from config.
+func openCache(cfg *config.Config, options ...cache.OptOptionsSetter) (*cache.Store, error) {
+	ttl, err := cfg.Cache.Duration()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := config.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	options = append([]cache.OptOptionsSetter{cache.WithMaxAge(ttl)}, options...)
+
+	return cache.NewStore(filepath.Join(dir, "responses"), options...)
+}
+
+// loadCache loads the config, with the --profile of cmd, and opens the answer cache.
+func loadCache(cmd *cobra.Command) (*cache.Store, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return openCache(cfg)
+}
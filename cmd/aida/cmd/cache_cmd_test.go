@@ -0,0 +1,54 @@
+package cmd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metalagman/aida/cmd/aida/cmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheStatsAndClear(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+
+	dir := filepath.Join(tmpDir, ".cache", "aida", "responses")
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "abc.json"), []byte(`{"texts":["ls"]}`), 0o600))
+
+	var out bytes.Buffer
+
+	root := cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetArgs([]string{"cache", "stats"})
+
+	require.NoError(t, root.Execute())
+	require.Contains(t, out.String(), "Directory: "+dir)
+	require.Contains(t, out.String(), "Entries: 1 (0 expired)")
+
+	out.Reset()
+
+	root = cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetArgs([]string{"cache", "clear"})
+
+	require.NoError(t, root.Execute())
+	require.Contains(t, out.String(), "Removed 1 cached answers")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	root = cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetArgs([]string{"cache", "stats", "--profile", "missing"})
+
+	require.ErrorContains(t, root.Execute(), `profile "missing" (from --profile) is not defined`)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+
+	"github.com/metalagman/aida/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+func newChatCmd() *cobra.Command {
+	opts := &cliOptions{}
+	cmd := &cobra.Command{
+		Use:           "chat",
+		Short:         "Start an interactive session where follow-up prompts refine previous commands",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			return runChat(ctx, cmd, opts)
+		},
+	}
+
+	setupFlags(cmd, opts)
+
+	return cmd
+}
+
+func runChat(ctx context.Context, cmd *cobra.Command, opts *cliOptions) error {
+	provider, r, _, err := prepareRun(ctx, cmd, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := r.Chat(ctx, provider); err != nil && !errors.Is(err, runner.ErrCancelled) {
+		return err
+	}
+
+	return nil
+}
+
+// isTerminal reports whether stream, a reader or writer, is an interactive terminal.
+func isTerminal(stream any) bool {
+	f, ok := stream.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
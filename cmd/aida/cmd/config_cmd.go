@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/redact"
+	"github.com/metalagman/aida/internal/policy"
+	"github.com/metalagman/aida/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+// defaultEditor is used by "aida config edit" when neither VISUAL nor EDITOR is set.
+const defaultEditor = "vi"
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and change the configuration",
+	}
+
+	cmd.AddCommand(newConfigGetCmd())
+	cmd.AddCommand(newConfigSetCmd())
+	cmd.AddCommand(newConfigUnsetCmd())
+	cmd.AddCommand(newConfigEditCmd())
+	cmd.AddCommand(newConfigPathCmd())
+	cmd.AddCommand(newConfigValidateCmd())
+	cmd.AddCommand(newConfigShowCmd())
+
+	return cmd
+}
+
+func newConfigGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the effective value of a key, e.g. mode or provider.openai.model",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings, err := traceConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			for _, setting := range settings {
+				if setting.Key == args[0] {
+					_, _ = fmt.Fprintln(cmd.OutOrStdout(), setting.Value)
+
+					return nil
+				}
+			}
+
+			return fmt.Errorf("%s is not set", args[0])
+		},
+	}
+}
+
+func newConfigSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a key in the config file; lists are comma separated",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+
+			cfg, err := config.LoadFile()
+			if err != nil {
+				return err
+			}
+
+			if err := cfg.Set(key, value); err != nil {
+				return err
+			}
+
+			checkKey := key
+			if strings.TrimSpace(value) == "" {
+				checkKey = ""
+			}
+
+			path, err := saveChecked(cfg, checkKey)
+			if err != nil {
+				return err
+			}
+
+			if config.IsSecretKey(key) {
+				value = config.MaskSecret(value)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Set %s to %s in %s\n", key, value, path)
+
+			return nil
+		},
+	}
+}
+
+func newConfigUnsetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a key, or a provider or profile entry, from the config file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadFile()
+			if err != nil {
+				return err
+			}
+
+			if err := cfg.Unset(args[0]); err != nil {
+				return err
+			}
+
+			path, err := saveChecked(cfg, "")
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Unset %s in %s\n", args[0], path)
+
+			return nil
+		},
+	}
+}
+
+func newConfigEditCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Open the config file in $VISUAL or $EDITOR and validate it",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := config.ResolveConfigPath()
+			if err != nil {
+				return err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(path), config.DirPerm); err != nil {
+				return fmt.Errorf("create config dir: %w", err)
+			}
+
+			program := runner.EditorFromEnv()
+			if program == "" {
+				program = defaultEditor
+			}
+
+			editor := runner.ExternalEditor{
+				Program: program,
+				Stdin:   cmd.InOrStdin(),
+				Stdout:  cmd.OutOrStdout(),
+				Stderr:  cmd.ErrOrStderr(),
+			}
+
+			if err := editor.EditFile(cmd.Context(), path); err != nil {
+				return err
+			}
+
+			return runConfigValidate(cmd)
+		},
+	}
+}
+
+func newConfigPathCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Print the path of the config file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := config.ResolveConfigPath()
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), path)
+
+			return nil
+		},
+	}
+}
+
+func newConfigValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the effective configuration for errors",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runConfigValidate(cmd)
+		},
+	}
+}
+
+func runConfigValidate(cmd *cobra.Command) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	problems := validateConfig(cfg)
+	if len(problems) == 0 {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Config is valid.")
+
+		return nil
+	}
+
+	for _, problem := range problems {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", problem)
+	}
+
+	return fmt.Errorf("config has %d problem(s)", len(problems))
+}
+
+func newConfigShowCmd() *cobra.Command {
+	var origin bool
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print every effective setting, with secrets masked",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			settings, err := traceConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+			for _, setting := range settings {
+				if !origin {
+					_, _ = fmt.Fprintf(w, "%s\t%s\n", setting.Key, setting.Value)
+
+					continue
+				}
+
+				source := setting.Origin
+				if setting.Source != "" {
+					source += " " + setting.Source
+				}
+
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, setting.Value, source)
+			}
+
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&origin, "origin", false,
+		"Also print where each value came from: file, env, dotenv, profile, project, flag or default")
+
+	return cmd
+}
+
+// traceConfig returns the effective settings with the profile selected by
+// --profile.
+func traceConfig(cmd *cobra.Command) ([]config.Setting, error) {
+	profile, _ := cmd.Flags().GetString("profile")
+
+	return config.Trace(profile, func(name string) bool {
+		return dotenvKeys[name]
+	})
+}
+
+// saveChecked saves cfg and loads it again. The previous file is restored
+// when that fails, adds a problem or drops key.
+func saveChecked(cfg *config.Config, key string) (string, error) {
+	path, err := config.ResolveConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	previous, readErr := os.ReadFile(path)
+	if readErr != nil && !errors.Is(readErr, os.ErrNotExist) {
+		return "", fmt.Errorf("read config: %w", readErr)
+	}
+
+	known := make(map[string]bool)
+
+	if before, err := config.LoadFile(); err == nil {
+		for _, problem := range validateConfig(before) {
+			known[problem.Error()] = true
+		}
+	}
+
+	if _, err := config.Save(cfg); err != nil {
+		return "", err
+	}
+
+	if err := checkSaved(key, known); err != nil {
+		if readErr != nil {
+			_ = os.Remove(path)
+		} else {
+			_ = os.WriteFile(path, previous, config.FilePerm)
+		}
+
+		return "", err
+	}
+
+	return path, nil
+}
+
+func checkSaved(key string, known map[string]bool) error {
+	saved, err := config.LoadFile()
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range validateConfig(saved) {
+		if !known[problem.Error()] {
+			return problem
+		}
+	}
+
+	if key == "" {
+		return nil
+	}
+
+	values, err := saved.Values()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := values[key]; !ok {
+		return fmt.Errorf("%s was dropped when loading the config, see aida config validate", key)
+	}
+
+	return nil
+}
+
+// validateConfig checks the settings that are otherwise only checked when
+// they are used.
+func validateConfig(cfg *config.Config) []error {
+	var problems []error
+
+	check := func(err error) {
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	switch runner.RunMode(cfg.Mode) {
+	case "", runner.ModeConfirm, runner.ModeYOLO, runner.ModeQuiet, runner.ModeDryRun:
+	default:
+		check(fmt.Errorf("invalid mode %q: use confirm, yolo, quiet or dry-run", cfg.Mode))
+	}
+
+	if cfg.MaxFixAttempts < 0 {
+		check(fmt.Errorf("max_fix_attempts must not be negative"))
+	}
+
+	if cfg.DefaultProvider != "" {
+		_, err := cfg.ProviderChain()
+		check(err)
+	}
+
+	_, err := cfg.Cache.Duration()
+	check(err)
+
+	_, err = cfg.Git.Level()
+	check(err)
+
+	_, err = cfg.Environment.EnabledFacts()
+	check(err)
+
+	_, err = cfg.Secrets.Store()
+	check(err)
+
+	_, err = policy.New(cfg.PolicyLayers()...)
+	check(err)
+
+	_, err = redact.NewRedactor(redact.WithPatterns(cfg.Redaction.Patterns))
+	check(err)
+
+	return problems
+}
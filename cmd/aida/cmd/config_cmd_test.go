@@ -0,0 +1,121 @@
+package cmd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/metalagman/aida/cmd/aida/cmd"
+	"github.com/metalagman/aida/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+	t.Setenv("AIDA_PROFILE", "")
+	t.Setenv("AIDA_MODE", "")
+	t.Setenv("AIDA_GIT_DETAIL", "branch")
+	t.Chdir(tmpDir)
+
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	configPath := filepath.Join(configDir, "config.toml")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+mode = "confirm"
+
+[provider.openai]
+api_key = "sk-openai"
+model = "gpt-4o"
+
+[profile.fast]
+mode = "yolo"
+`), 0o600))
+
+	execute := func(args ...string) (string, error) {
+		t.Helper()
+
+		var out bytes.Buffer
+
+		root := cmd.NewRootCmd()
+		root.SetOut(&out)
+		root.SetErr(&out)
+		root.SetArgs(args)
+
+		err := root.Execute()
+
+		return out.String(), err
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+
+		out, err := execute(args...)
+		require.NoError(t, err, out)
+
+		return out
+	}
+
+	assert.Equal(t, configPath+"\n", run("config", "path"))
+	assert.Equal(t, "confirm\n", run("config", "get", "mode"))
+	assert.Equal(t, "yolo\n", run("--profile", "fast", "config", "get", "mode"))
+	assert.Equal(t, "********\n", run("config", "get", "provider.openai.api_key"))
+
+	assert.Equal(t, "Set mode to dry-run in "+configPath+"\n", run("config", "set", "mode", "dry-run"))
+	assert.Equal(t, "dry-run\n", run("config", "get", "mode"))
+	out := run("config", "set", "provider.openai.api_key", "sk-new")
+	assert.Contains(t, out, "Set provider.openai.api_key to ********")
+	run("config", "set", "policy.deny_programs", "curl, wget")
+
+	loaded, err := config.LoadBase()
+	require.NoError(t, err)
+	assert.Equal(t, "dry-run", loaded.Mode)
+	assert.Equal(t, "sk-new", loaded.Providers["openai"].APIKey)
+	assert.Equal(t, []string{"curl", "wget"}, loaded.Policy.DenyPrograms)
+	assert.Equal(t, "branch", loaded.Git.Detail)
+
+	saved, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(saved), "branch", "environment values must not be saved")
+
+	out, err = execute("config", "set", "mode", "bogus")
+	require.ErrorContains(t, err, `invalid mode "bogus"`, out)
+	assert.Equal(t, "dry-run\n", run("config", "get", "mode"))
+
+	out, err = execute("config", "set", "nope", "x")
+	require.ErrorContains(t, err, `unknown config key "nope"`, out)
+
+	out = run("config", "show", "--origin")
+	assert.Regexp(t, `(?m)^mode\s+dry-run\s+file `+regexp.QuoteMeta(configPath)+`$`, out)
+	assert.Regexp(t, `(?m)^git\.detail\s+branch\s+env AIDA_GIT_DETAIL$`, out)
+	assert.Regexp(t, `(?m)^provider\.openai\.api_key\s+\*{8}\s+file `, out)
+	assert.NotContains(t, out, "sk-new")
+
+	out = run("--profile", "fast", "config", "show", "--origin")
+	assert.Regexp(t, `(?m)^mode\s+yolo\s+profile fast$`, out)
+	assert.Regexp(t, `(?m)^profile\s+fast\s+flag --profile$`, out)
+
+	assert.Equal(t, "Unset policy.deny_programs in "+configPath+"\n", run("config", "unset", "policy.deny_programs"))
+	out, err = execute("config", "get", "policy.deny_programs")
+	require.ErrorContains(t, err, "policy.deny_programs is not set", out)
+
+	assert.Equal(t, "Config is valid.\n", run("config", "validate"))
+
+	// The editor setting may carry arguments and gets the path appended.
+	t.Setenv("VISUAL", `sed -i.bak 's/dry-run/yolo/'`)
+	assert.Equal(t, "Config is valid.\n", run("config", "edit"))
+	assert.Equal(t, "yolo\n", run("config", "get", "mode"))
+
+	require.NoError(t, os.WriteFile(configPath, []byte("mode = \"bogus\"\n"), 0o600))
+	out, err = execute("config", "validate")
+	require.ErrorContains(t, err, "config has 1 problem(s)")
+	assert.Contains(t, out, `Error: invalid mode "bogus"`)
+}
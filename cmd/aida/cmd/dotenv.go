@@ -1,7 +1,29 @@
 package cmd
 
-import "github.com/joho/godotenv"
+import (
+	"os"
 
+	"github.com/joho/godotenv"
+)
+
+// dotenvKeys are the environment variables set from .env, so that
+// "aida config show --origin" can tell them from the real environment.
+var dotenvKeys = map[string]bool{}
+
+// initDotEnv sets the variables of .env in the current directory that are not
+// already set, like godotenv.Load.
 func initDotEnv() {
-	_ = godotenv.Load()
+	values, err := godotenv.Read()
+	if err != nil {
+		return
+	}
+
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+
+		_ = os.Setenv(key, value)
+		dotenvKeys[key] = true
+	}
 }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/envinfo"
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/spf13/cobra"
+)
+
+// environmentFacts collects the facts about the local machine allowed by the
+// config, reusing those cached for this host. --refresh collects them again.
+func environmentFacts(ctx context.Context, cmd *cobra.Command, opts *cliOptions, cfg *config.Config) ([]string, error) {
+	facts, err := cfg.Environment.EnabledFacts()
+	if err != nil || len(facts) == 0 {
+		return nil, err
+	}
+
+	dir, err := config.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := cache.NewStore(filepath.Join(dir, "environment"),
+		cache.WithMaxAge(envinfo.CacheTTL), cache.WithRefresh(opts.refresh))
+	if err != nil {
+		return nil, err
+	}
+
+	collector := envinfo.Collector{
+		Shell: cfg.Shell,
+		Facts: facts,
+		Tools: cfg.Environment.ToolList(),
+	}
+
+	lines, err := collector.CachedCollect(ctx, store)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: cannot cache environment facts: %v\n", err)
+	}
+
+	return lines, nil
+}
+
+// gitWorkspace returns a function describing the git repository of the
+// current directory in the configured detail, or nil when it is turned off.
+func gitWorkspace(cfg *config.Config) (func(context.Context) []string, error) {
+	detail, err := cfg.Git.Level()
+	if err != nil || detail == config.GitDetailOff {
+		return nil, err
+	}
+
+	return func(ctx context.Context) []string {
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil
+		}
+
+		collector := envinfo.GitCollector{Dir: dir, Detail: detail, MaxFiles: cfg.Git.FileLimit()}
+
+		return collector.Collect(ctx)
+	}, nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+)
+
+// Exit codes for provider errors, so that scripts can tell them apart from
+// other failures (exit code 1). A provider error wins over the status of a
+// command that ran before it, e.g. when asking for a fix fails.
+const (
+	exitAuth           = 10
+	exitRateLimited    = 11
+	exitQuotaExceeded  = 12
+	exitModelNotFound  = 13
+	exitContextTooLong = 14
+	exitSafetyBlocked  = 15
+)
+
+// ExitCode reports err on stderr and returns the status aida exits with: 0
+// without an error, the provider exit code for a provider error, the status of
+// the generated command when it failed, and 1 otherwise.
+func ExitCode(err error, stderr io.Writer) int {
+	if err == nil {
+		return 0
+	}
+
+	hint, code := providerFailure(err)
+
+	var exitErr *exec.ExitError
+	if hint == "" && errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+
+	if hint != "" {
+		_, _ = fmt.Fprintf(stderr, "Hint: %s\n", hint)
+	}
+
+	return code
+}
+
+// providerFailure returns an actionable hint and the exit code for err, or
+// an empty hint and exit code 1 when err is not a known provider error.
+func providerFailure(err error) (string, int) {
+	name := "<provider>"
+
+	var apiErr *llmerr.APIError
+	if errors.As(err, &apiErr) && apiErr.Provider != "" {
+		name = apiErr.Provider
+	}
+
+	switch {
+	case errors.Is(err, llmerr.ErrAuth):
+		return fmt.Sprintf("Check the API key: run `aida providers configure %s`.", name), exitAuth
+	case errors.Is(err, llmerr.ErrQuotaExceeded):
+		return "The account is out of quota or credits: check billing with the provider, " +
+			"or switch with `aida providers default`.", exitQuotaExceeded
+	case errors.Is(err, llmerr.ErrRateLimited):
+		return "The provider is rate limiting requests: wait a moment, or add a fallback " +
+			"with `aida providers default <provider> <fallback>`.", exitRateLimited
+	case errors.Is(err, llmerr.ErrModelNotFound):
+		return fmt.Sprintf("List the available models with `aida providers models %s`, "+
+			"then pick one with `aida providers set-model %s <model>`.", name, name), exitModelNotFound
+	case errors.Is(err, llmerr.ErrContextTooLong):
+		return "The request is too long for the model: shorten the prompt " +
+			"or use a model with a larger context window.", exitContextTooLong
+	case errors.Is(err, llmerr.ErrSafetyBlocked):
+		return "The provider blocked the request or its answer: rephrase the prompt.", exitSafetyBlocked
+	default:
+		return "", 1
+	}
+}
@@ -0,0 +1,146 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/metalagman/aida/cmd/aida/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAnswer is one response of newFakeProvider: an error status, or the
+// message content of a successful answer.
+type fakeAnswer struct {
+	status  int
+	content string
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		answers []fakeAnswer
+		want    int
+		hint    bool
+	}{
+		{
+			name:    "provider error without a command",
+			args:    []string{"--dry-run", "list files"},
+			answers: []fakeAnswer{{status: http.StatusUnauthorized}},
+			want:    10,
+			hint:    true,
+		},
+		{
+			name:    "failed command is passed through",
+			args:    []string{"--yolo", "list files"},
+			answers: []fakeAnswer{{status: http.StatusOK, content: "exit 3"}},
+			want:    3,
+		},
+		{
+			name: "provider error while asking for a fix wins over the command",
+			args: []string{"--yolo", "--fix", "list files"},
+			answers: []fakeAnswer{
+				{status: http.StatusOK, content: "exit 3"},
+				{status: http.StatusNotFound},
+			},
+			want: 13,
+			hint: true,
+		},
+		{
+			name:    "success",
+			args:    []string{"--yolo", "list files"},
+			answers: []fakeAnswer{{status: http.StatusOK, content: "true"}},
+			want:    0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFakeProvider(t, tc.answers)
+			setupProviderHome(t, server.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			root := cmd.NewRootCmd()
+			root.SetOut(&stdout)
+			root.SetErr(&stderr)
+			root.SetIn(strings.NewReader(""))
+			root.SetArgs(append([]string{"--no-cache"}, tc.args...))
+
+			got := cmd.ExitCode(root.Execute(), &stderr)
+			assert.Equal(t, tc.want, got, "stderr: %s", stderr.String())
+
+			if tc.hint {
+				assert.Contains(t, stderr.String(), "Hint: ")
+			} else {
+				assert.NotContains(t, stderr.String(), "Hint: ")
+			}
+		})
+	}
+}
+
+// newFakeProvider serves an OpenAI-compatible API answering requests in turn.
+// Every successful answer reports 42 prompt and 8 completion tokens.
+func newFakeProvider(t *testing.T, answers []fakeAnswer) *httptest.Server {
+	t.Helper()
+
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		i := int(calls.Add(1)) - 1
+		if !assert.Less(t, i, len(answers), "unexpected request") {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		answer := answers[i]
+		if answer.status != http.StatusOK {
+			w.WriteHeader(answer.status)
+			_, _ = w.Write([]byte(`{"error":{"message":"failed"}}`))
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]any{"content": answer.content}}},
+			"usage":   map[string]any{"prompt_tokens": 42, "completion_tokens": 8, "total_tokens": 50},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func setupProviderHome(t *testing.T, baseURL string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	content := `default_provider = "openai"
+
+[provider.openai]
+api_key = "test-key"
+model = "gpt-4o-mini"
+base_url = "` + baseURL + `"
+max_retries = 0
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(content), 0o600))
+}
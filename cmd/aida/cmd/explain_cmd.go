@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/metalagman/aida/internal/llm"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/spf13/cobra"
+)
+
+func newExplainCmd() *cobra.Command {
+	opts := &cliOptions{}
+	cmd := &cobra.Command{
+		Use:   "explain [-- command]",
+		Short: "Explain what an existing shell command does",
+		Long: "Explain breaks a shell command down into pipeline stages, flags, redirections and side effects.\n" +
+			"The command is taken from the arguments, or from stdin when no arguments are given.\n" +
+			"Put the command after -- when it has flags, e.g. aida explain -- ls -la; otherwise they are\n" +
+			"read as flags of explain.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			shellCommand, err := explainInput(ctx, cmd, args)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := applyOverrides(cfg, opts); err != nil {
+				return err
+			}
+
+			if err := resolveAPIKeys(ctx, cmd, cfg); err != nil {
+				return err
+			}
+
+			options, err := providerOptions(cmd, opts, cfg)
+			if err != nil {
+				return err
+			}
+
+			p, err := llm.NewProvider(ctx, cfg, options...)
+			if err != nil {
+				return err
+			}
+
+			explanation, err := p.ExplainCommand(ctx, shellCommand)
+			if err != nil {
+				return err
+			}
+
+			usageReporter(cmd, opts, cfg)(explanation.Usage)
+
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+
+				return enc.Encode(explanation)
+			}
+
+			return printExplanation(cmd.OutOrStdout(), explanation)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.provider, "provider", "", "LLM provider (aistudio, openai, anthropic, ollama)")
+	cmd.Flags().StringVar(&opts.apiKey, "api-key", "", "LLM API key")
+	cmd.Flags().StringVar(&opts.model, "model", "", "LLM model name")
+	cmd.Flags().Bool("json", false, "Print the explanation as JSON")
+	cmd.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "Report which provider and model answered")
+	cmd.Flags().BoolVar(&opts.usage, "usage", false, "Print token usage and estimated cost of the request")
+	setupCacheFlags(cmd, opts)
+	setupRedactionFlags(cmd, opts)
+
+	return cmd
+}
+
+// explainInput returns the command to explain from args, or from piped stdin
+// when there are none. Stdin is read with the limits of piped prompt input,
+// and a terminal is not read at all.
+func explainInput(ctx context.Context, cmd *cobra.Command, args []string) (string, error) {
+	input := PromptFromArgs(args, cmd.ArgsLenAtDash())
+
+	if len(args) == 0 && pipedInput(cmd.InOrStdin()) {
+		data, err := readPipedInput(ctx, cmd)
+		if err != nil {
+			return "", fmt.Errorf("read command from stdin: %w", err)
+		}
+
+		input = data
+	}
+
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", fmt.Errorf("command is required (as arguments or via stdin)")
+	}
+
+	return input, nil
+}
+
+func printExplanation(out io.Writer, explanation provider.Explanation) error {
+	if explanation.Summary != "" {
+		_, _ = fmt.Fprintf(out, "%s\n\n", explanation.Summary)
+	}
+
+	if len(explanation.Segments) > 0 {
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+		for _, segment := range explanation.Segments {
+			_, _ = fmt.Fprintf(tw, "  %s\t[%s]\t%s\n", segment.Text, segment.Kind, segment.Explanation)
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(explanation.SideEffects) > 0 {
+		_, _ = fmt.Fprintln(out, "\nSide effects:")
+
+		for _, effect := range explanation.SideEffects {
+			_, _ = fmt.Fprintf(out, "  - %s\n", effect)
+		}
+	}
+
+	return nil
+}
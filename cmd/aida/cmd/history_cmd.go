@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/metalagman/aida/internal/history"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultHistoryLimit = 20
+	// promptPreviewSize bounds how much of a prompt history listings show.
+	promptPreviewSize = 60
+	historyTimeLayout = "2006-01-02 15:04"
+	historyLimitUsage = "Number of most recent entries to list (0 lists all)"
+)
+
+func newHistoryCmd() *cobra.Command {
+	var limit int
+
+	list := func(cmd *cobra.Command, _ []string) error {
+		entries, err := loadHistory()
+		if err != nil {
+			return err
+		}
+
+		if limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+
+		return printHistory(cmd.OutOrStdout(), entries)
+	}
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List, search and re-run previously generated commands",
+		Args:  cobra.NoArgs,
+		RunE:  list,
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", defaultHistoryLimit, historyLimitUsage)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the most recent commands",
+		Args:  cobra.NoArgs,
+		RunE:  list,
+	}
+	listCmd.Flags().IntVarP(&limit, "limit", "n", defaultHistoryLimit, historyLimitUsage)
+
+	cmd.AddCommand(listCmd)
+	cmd.AddCommand(&cobra.Command{
+		Use:   "search <query>",
+		Short: "Fuzzy search prompts and commands",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := loadHistory()
+			if err != nil {
+				return err
+			}
+
+			return printHistory(cmd.OutOrStdout(), history.Search(entries, PromptFromArgs(args, -1)))
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show <id>",
+		Short: "Show everything recorded about a command",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entry, err := historyEntry(args[0])
+			if err != nil {
+				return err
+			}
+
+			printHistoryEntry(cmd.OutOrStdout(), entry)
+
+			return nil
+		},
+	})
+	cmd.AddCommand(newHistoryRerunCmd())
+	cmd.AddCommand(newHistoryExportCmd())
+
+	return cmd
+}
+
+func newHistoryRerunCmd() *cobra.Command {
+	opts := &cliOptions{candidates: 1}
+	cmd := &cobra.Command{
+		Use:   "rerun <id>",
+		Short: "Run a recorded command again without asking the model",
+		Long: "Rerun goes through the usual mode, confirmation and policy checks, " +
+			"but takes the command from the history instead of generating it.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			entry, err := historyEntry(args[0])
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := applyOverrides(cfg, opts); err != nil {
+				return err
+			}
+
+			r, err := setupRunner(cmd, opts, cfg)
+			if err != nil {
+				return err
+			}
+
+			r.MaxFixAttempts = 0
+			r.Live = false
+			r.OnUsage = nil
+			r.OnRecord = historyRecorder(cmd, entry.Prompt)
+
+			if wd, err := os.Getwd(); err == nil && entry.Dir != "" && entry.Dir != wd && r.Mode != runner.ModeQuiet {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Note: the command was generated in %s\n", entry.Dir)
+			}
+
+			err = r.Run(ctx, entry.Prompt, replayGenerator{command: entry.Command})
+			if errors.Is(err, runner.ErrCancelled) {
+				return nil
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.shell, "shell", "", "Shell executable for running commands")
+	cmd.Flags().BoolVar(&opts.yolo, "yolo", false, "Run without confirmation")
+	cmd.Flags().BoolVar(&opts.quiet, "quiet", false, "Run silently")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Print command without running")
+
+	return cmd
+}
+
+func newHistoryExportCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the whole history as CSV, or as JSON with --json",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			entries, err := loadHistory()
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				if entries == nil {
+					entries = []history.Entry{}
+				}
+
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+
+				return enc.Encode(entries)
+			}
+
+			return exportHistoryCSV(cmd.OutOrStdout(), entries)
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Export as a JSON array")
+
+	return cmd
+}
+
+// replayGenerator hands a recorded command to the runner instead of asking a model.
+type replayGenerator struct {
+	command string
+}
+
+func (g replayGenerator) GenerateCommand(context.Context, provider.Request) (provider.Result, error) {
+	return provider.Result{Candidates: []provider.Candidate{{Command: g.command}}}, nil
+}
+
+// historyRecorder returns the runner callback adding every generated command
+// to the history. A non-empty prompt is recorded instead of the one sent to
+// the model, which may carry extra context.
+func historyRecorder(cmd *cobra.Command, prompt string) func(runner.Record) {
+	store, storeErr := history.DefaultStore()
+	dir, _ := os.Getwd()
+
+	return func(rec runner.Record) {
+		if prompt != "" {
+			rec.Prompt = prompt
+		}
+
+		if storeErr == nil {
+			storeErr = store.Add(history.Entry{
+				Time:      time.Now().UTC(),
+				Prompt:    rec.Prompt,
+				Command:   rec.Command,
+				Provider:  rec.Provider,
+				Model:     rec.Model,
+				Dir:       dir,
+				Mode:      string(rec.Mode),
+				Confirmed: rec.Confirmed,
+				Ran:       rec.Ran,
+				ExitCode:  rec.ExitCode,
+			})
+		}
+
+		if storeErr != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: record history: %v\n", storeErr)
+		}
+	}
+}
+
+func loadHistory() ([]history.Entry, error) {
+	store, err := history.DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return store.List()
+}
+
+func historyEntry(arg string) (history.Entry, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return history.Entry{}, fmt.Errorf("invalid history id %q", arg)
+	}
+
+	store, err := history.DefaultStore()
+	if err != nil {
+		return history.Entry{}, err
+	}
+
+	return store.Get(id)
+}
+
+func printHistory(out io.Writer, entries []history.Entry) error {
+	if len(entries) == 0 {
+		_, _ = fmt.Fprintln(out, "No history recorded.")
+
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(tw, "ID\tTIME\tSTATUS\tPROMPT\tCOMMAND")
+
+	for _, entry := range entries {
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", entry.ID, entry.Time.Local().Format(historyTimeLayout),
+			historyStatus(entry), truncate(entry.Prompt, promptPreviewSize), entry.Command)
+	}
+
+	return tw.Flush()
+}
+
+func printHistoryEntry(out io.Writer, entry history.Entry) {
+	_, _ = fmt.Fprintf(out, "ID:        %d\n", entry.ID)
+	_, _ = fmt.Fprintf(out, "Time:      %s\n", entry.Time.Local().Format(time.RFC3339))
+	_, _ = fmt.Fprintf(out, "Prompt:    %s\n", entry.Prompt)
+	_, _ = fmt.Fprintf(out, "Command:   %s\n", entry.Command)
+
+	if entry.Provider != "" {
+		_, _ = fmt.Fprintf(out, "Provider:  %s (%s)\n", entry.Provider, entry.Model)
+	}
+
+	_, _ = fmt.Fprintf(out, "Directory: %s\n", entry.Dir)
+	_, _ = fmt.Fprintf(out, "Mode:      %s\n", entry.Mode)
+	_, _ = fmt.Fprintf(out, "Confirmed: %t\n", entry.Confirmed)
+	_, _ = fmt.Fprintf(out, "Status:    %s\n", historyStatus(entry))
+}
+
+func exportHistoryCSV(out io.Writer, entries []history.Entry) error {
+	w := csv.NewWriter(out)
+
+	_ = w.Write([]string{
+		"id", "time", "prompt", "command", "provider", "model", "dir", "mode", "confirmed", "ran", "exit_code",
+	})
+
+	for _, entry := range entries {
+		_ = w.Write([]string{
+			strconv.Itoa(entry.ID),
+			entry.Time.Format(time.RFC3339),
+			entry.Prompt,
+			entry.Command,
+			entry.Provider,
+			entry.Model,
+			entry.Dir,
+			entry.Mode,
+			strconv.FormatBool(entry.Confirmed),
+			strconv.FormatBool(entry.Ran),
+			strconv.Itoa(entry.ExitCode),
+		})
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+func historyStatus(entry history.Entry) string {
+	switch {
+	case entry.Ran && entry.ExitCode >= 0:
+		return fmt.Sprintf("exit %d", entry.ExitCode)
+	case entry.Ran:
+		return "failed"
+	case entry.Mode == string(runner.ModeDryRun):
+		return "dry run"
+	default:
+		return "not run"
+	}
+}
+
+// truncate shortens s to at most size runes, marking the cut with "...".
+func truncate(s string, size int) string {
+	runes := []rune(s)
+	if len(runes) <= size {
+		return s
+	}
+
+	return string(runes[:size-3]) + "..."
+}
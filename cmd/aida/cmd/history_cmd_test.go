@@ -0,0 +1,76 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/metalagman/aida/cmd/aida/cmd"
+	"github.com/metalagman/aida/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+
+	store, err := history.DefaultStore()
+	require.NoError(t, err)
+	require.NoError(t, store.Add(history.Entry{
+		Time: time.Now(), Prompt: "show disk usage sorted", Command: "du -sh * | sort -h",
+		Provider: "openai", Model: "gpt-4o", Mode: "confirm", Confirmed: true, Ran: true,
+	}))
+	require.NoError(t, store.Add(history.Entry{
+		Time: time.Now(), Prompt: "list files", Command: "ls -la", Mode: "dry-run", ExitCode: 0,
+	}))
+
+	run := func(args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+
+		root := cmd.NewRootCmd()
+		root.SetOut(&out)
+		root.SetErr(&out)
+		root.SetArgs(args)
+
+		require.NoError(t, root.Execute())
+
+		return out.String()
+	}
+
+	out := run("history")
+	assert.Regexp(t, `1\s+\S+ \S+\s+exit 0\s+show disk usage sorted\s+du -sh \* \| sort -h`, out)
+	assert.Regexp(t, `2\s+\S+ \S+\s+dry run\s+list files\s+ls -la`, out)
+
+	out = run("history", "search", "dsk", "srt")
+	assert.Contains(t, out, "du -sh")
+	assert.NotContains(t, out, "ls -la")
+
+	out = run("history", "show", "1")
+	assert.Contains(t, out, "Provider:  openai (gpt-4o)")
+	assert.Contains(t, out, "Confirmed: true")
+
+	out = run("history", "rerun", "2", "--dry-run")
+	assert.Equal(t, "ls -la\n", out)
+
+	var exported []history.Entry
+	require.NoError(t, json.Unmarshal([]byte(run("history", "export", "--json")), &exported))
+	require.Len(t, exported, 3)
+	assert.Equal(t, 3, exported[2].ID)
+	assert.Equal(t, "list files", exported[2].Prompt)
+	assert.Equal(t, "ls -la", exported[2].Command)
+	assert.Equal(t, "dry-run", exported[2].Mode)
+
+	out = run("history", "export")
+	assert.Contains(t, out, "id,time,prompt,command,provider,model,dir,mode,confirmed,ran,exit_code\n")
+	assert.Contains(t, out, ",show disk usage sorted,du -sh * | sort -h,openai,gpt-4o,,confirm,true,true,0\n")
+}
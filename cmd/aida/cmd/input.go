@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/metalagman/aida/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ttyPath is the controlling terminal, read for answers once stdin has been
+	// taken by piped input.
+	ttyPath = "/dev/tty"
+	// inputTimeout bounds how long piped input is read, so that input that
+	// stays open, such as tail -f or the stdin of a CI job, cannot hang aida.
+	inputTimeout = 10 * time.Second
+)
+
+// pipedInput reports whether stdin carries data for the prompt: a pipe or a
+// redirected file rather than a terminal or /dev/null. Readers other than
+// files are data handed in by the caller.
+func pipedInput(stdin io.Reader) bool {
+	file, ok := stdin.(*os.File)
+	if !ok {
+		return stdin != nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeNamedPipe != 0 || info.Mode().IsRegular()
+}
+
+// readPipedInput reads the input piped to aida for at most inputTimeout.
+func readPipedInput(ctx context.Context, cmd *cobra.Command) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, inputTimeout)
+	defer cancel()
+
+	return runner.ReadInput(ctx, cmd.InOrStdin())
+}
+
+// useTerminalInput switches the command's input to the controlling terminal,
+// so confirmations and the command itself no longer read from the consumed
+// stdin. Without a terminal, answers read as empty input. The returned
+// function closes the terminal.
+func useTerminalInput(cmd *cobra.Command) func() {
+	tty, err := os.Open(ttyPath)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: cannot open %s for answers: %v\n", ttyPath, err)
+		cmd.SetIn(strings.NewReader(""))
+
+		return func() {}
+	}
+
+	cmd.SetIn(tty)
+
+	return func() { _ = tty.Close() }
+}
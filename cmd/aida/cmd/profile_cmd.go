@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage configuration profiles",
+	}
+
+	cmd.AddCommand(newProfileListCmd())
+	cmd.AddCommand(newProfileUseCmd())
+	cmd.AddCommand(newProfileShowCmd())
+
+	return cmd
+}
+
+func newProfileListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List defined profiles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			names := cfg.ProfileNames()
+			if len(names) == 0 {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No profiles defined.")
+
+				return nil
+			}
+
+			for _, name := range names {
+				display := name
+				if name == cfg.ActiveProfile {
+					display = fmt.Sprintf("%s (active, from %s)", name, cfg.ActiveProfileSource)
+				}
+
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), display)
+			}
+
+			return nil
+		},
+	}
+}
+
+func newProfileUseCmd() *cobra.Command {
+	var unset bool
+
+	cmd := &cobra.Command{
+		Use:   "use <profile>",
+		Short: "Set the profile used by default",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if unset {
+				return cobra.NoArgs(cmd, args)
+			}
+
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Only default_profile changes, so the file is saved without the
+			// environment overrides LoadBase would merge in.
+			cfg, err := config.LoadFile()
+			if err != nil {
+				return err
+			}
+
+			name := ""
+			if !unset {
+				name = strings.ToLower(strings.TrimSpace(args[0]))
+				if _, ok := cfg.Profiles[name]; !ok {
+					return fmt.Errorf("profile %q is not defined", args[0])
+				}
+			}
+
+			cfg.DefaultProfile = name
+
+			path, err := config.Save(cfg)
+			if err != nil {
+				return err
+			}
+
+			if name == "" {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Cleared the default profile in %s\n", path)
+			} else {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Set default profile to %s in %s\n", name, path)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&unset, "clear", false, "Use no profile by default")
+
+	return cmd
+}
+
+func newProfileShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [profile]",
+		Short: "Show the effective settings of a profile, the active one by default",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				cfg *config.Config
+				err error
+			)
+
+			if len(args) == 1 {
+				cfg, err = config.LoadProfile(args[0])
+			} else {
+				cfg, err = loadConfig(cmd)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			printProfile(cmd.OutOrStdout(), cfg)
+
+			return nil
+		},
+	}
+}
+
+func printProfile(out io.Writer, cfg *config.Config) {
+	switch {
+	case cfg.ActiveProfile == "":
+		_, _ = fmt.Fprintln(out, "Profile:  none")
+	case cfg.ActiveProfileSource == config.ProfileSourceFlag:
+		_, _ = fmt.Fprintf(out, "Profile:  %s\n", cfg.ActiveProfile)
+	default:
+		_, _ = fmt.Fprintf(out, "Profile:  %s (from %s)\n", cfg.ActiveProfile, cfg.ActiveProfileSource)
+	}
+
+	if name, provider, err := cfg.ActiveProvider(); err != nil {
+		_, _ = fmt.Fprintf(out, "Provider: %v\n", err)
+	} else {
+		_, _ = fmt.Fprintf(out, "Provider: %s (%s)\n", name, provider.Model)
+	}
+
+	if len(cfg.FallbackProviders) > 0 {
+		_, _ = fmt.Fprintf(out, "Fallback: %s\n", strings.Join(cfg.FallbackProviders, ", "))
+	}
+
+	_, _ = fmt.Fprintf(out, "Mode:     %s\n", cfg.Mode)
+	_, _ = fmt.Fprintf(out, "Shell:    %s\n", cfg.Shell)
+
+	rules := policyRules(cfg.Policy)
+	for _, rule := range policyRules(cfg.ProfilePolicy) {
+		rules = append(rules, rule+" (from profile "+cfg.ActiveProfile+")")
+	}
+
+	if cfg.ProjectPolicyPath != "" {
+		rules = append(rules, "plus the project policy in "+cfg.ProjectPolicyPath)
+	}
+
+	if !cfg.Project.Policy.Empty() {
+		rules = append(rules, "plus the project policy in "+cfg.ProjectPath)
+	}
+
+	if len(rules) == 0 {
+		rules = []string{"none"}
+	}
+
+	for i, rule := range rules {
+		label := "Policy:  "
+		if i > 0 {
+			label = "         "
+		}
+
+		_, _ = fmt.Fprintf(out, "%s %s\n", label, rule)
+	}
+}
+
+// policyRules describes the non-empty rule lists of a policy, one per line.
+func policyRules(policy config.PolicyConfig) []string {
+	lists := []struct {
+		name  string
+		items []string
+	}{
+		{"allow_programs", policy.AllowPrograms},
+		{"deny_programs", policy.DenyPrograms},
+		{"confirm_programs", policy.ConfirmPrograms},
+		{"deny_patterns", policy.DenyPatterns},
+		{"confirm_patterns", policy.ConfirmPatterns},
+		{"forbidden_paths", policy.ForbiddenPaths},
+	}
+
+	var rules []string
+
+	for _, list := range lists {
+		if len(list.items) > 0 {
+			rules = append(rules, list.name+": "+strings.Join(list.items, ", "))
+		}
+	}
+
+	return rules
+}
@@ -0,0 +1,130 @@
+package cmd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metalagman/aida/cmd/aida/cmd"
+	"github.com/metalagman/aida/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+	t.Setenv("AIDA_PROFILE", "")
+	t.Chdir(tmpDir)
+
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(`
+mode = "confirm"
+
+[provider.openai]
+api_key = "sk-openai"
+model = "gpt-4o"
+
+[profile.offline]
+provider = "ollama"
+model = "qwen2.5-coder"
+mode = "dry-run"
+
+[profile.offline.policy]
+deny_programs = ["curl", "wget"]
+
+[profile.personal]
+model = "gpt-4.1"
+`), 0o600))
+
+	run := func(args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+
+		root := cmd.NewRootCmd()
+		root.SetOut(&out)
+		root.SetErr(&out)
+		root.SetArgs(args)
+
+		require.NoError(t, root.Execute())
+
+		return out.String()
+	}
+
+	assert.Equal(t, "offline\npersonal\n", run("profile", "list"))
+	assert.Contains(t, run("profile", "show"), "Profile:  none\nProvider: openai (gpt-4o)\nMode:     confirm\n")
+
+	out := run("profile", "show", "offline")
+	assert.Contains(t, out, "Provider: ollama (qwen2.5-coder)\n")
+	assert.Contains(t, out, "Mode:     dry-run\n")
+	assert.Contains(t, out, "Policy:   deny_programs: curl, wget (from profile offline)\n")
+
+	assert.Contains(t, run("profile", "use", "personal"), "Set default profile to personal")
+	assert.Equal(t, "offline\npersonal (active, from default_profile)\n", run("profile", "list"))
+	assert.Equal(t, "offline (active, from --profile)\npersonal\n", run("--profile", "offline", "profile", "list"))
+	assert.Contains(t, run("profile", "show"), "Provider: openai (gpt-4.1)\n")
+
+	loaded, err := config.LoadBase()
+	require.NoError(t, err)
+	assert.Equal(t, "personal", loaded.DefaultProfile)
+	assert.Equal(t, "gpt-4o", loaded.Providers["openai"].Model)
+	assert.Len(t, loaded.Profiles, 2)
+
+	assert.Contains(t, run("profile", "use", "--clear"), "Cleared the default profile")
+	assert.Equal(t, "offline\npersonal\n", run("profile", "list"))
+
+	root := cmd.NewRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"profile", "use", "staging"})
+	require.ErrorContains(t, root.Execute(), `profile "staging" is not defined`)
+}
+
+func TestProfileUseKeepsEnvOutOfFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+	t.Setenv("AIDA_PROFILE", "")
+	t.Setenv("AIDA_MODE", "yolo")
+	t.Setenv("AIDA_PROVIDER_OPENAI_API_KEY", "sk-from-env")
+	t.Setenv("AIDA_PROVIDER_ANTHROPIC_API_KEY", "sk-ant-from-env")
+	t.Chdir(tmpDir)
+
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(`
+mode = "confirm"
+
+[provider.openai]
+api_key = "sk-openai"
+model = "gpt-4o"
+
+[profile.personal]
+model = "gpt-4.1"
+`), 0o600))
+
+	root := cmd.NewRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"profile", "use", "personal"})
+	require.NoError(t, root.Execute())
+
+	saved, err := config.LoadFile()
+	require.NoError(t, err)
+	assert.Equal(t, "personal", saved.DefaultProfile)
+	assert.Equal(t, "confirm", saved.Mode)
+	assert.Equal(t, "sk-openai", saved.Providers["openai"].APIKey)
+	assert.NotContains(t, saved.Providers, "anthropic")
+}
@@ -2,6 +2,8 @@ package cmd
 
 import "github.com/metalagman/aida/internal/config"
 
-func normalizeProvider(input string) string {
-	return config.NormalizeProviderName(input)
+// normalizeProvider resolves input to a built-in provider or, when cfg is not
+// nil, to one of its custom entries.
+func normalizeProvider(cfg *config.Config, input string) string {
+	return cfg.ResolveProviderName(input)
 }
@@ -8,12 +8,14 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/metalagman/aida/internal/config"
 	"github.com/metalagman/aida/internal/llm"
+	"github.com/metalagman/aida/internal/secrets"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
@@ -32,17 +34,18 @@ func newProvidersCmd() *cobra.Command {
 	cmd.AddCommand(newProvidersSetModelCmd())
 	cmd.AddCommand(newProvidersConfigureCmd())
 	cmd.AddCommand(newProvidersDefaultCmd())
+	cmd.AddCommand(newProvidersMigrateSecretsCmd())
 
 	return cmd
 }
 
 func newProvidersDefaultCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "default [provider]",
-		Short: "Get or set the default provider",
-		Args:  cobra.MaximumNArgs(1),
+		Use:   "default [provider...]",
+		Short: "Get or set the default provider and the fallbacks tried after it",
+		Args:  cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.Load()
+			cfg, err := config.LoadBase()
 			if err != nil {
 				return err
 			}
@@ -51,29 +54,42 @@ func newProvidersDefaultCmd() *cobra.Command {
 				if cfg.DefaultProvider == "" {
 					_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No default provider set.")
 				} else {
-					_, _ = fmt.Fprintln(cmd.OutOrStdout(), cfg.DefaultProvider)
+					chain := append([]string{cfg.DefaultProvider}, cfg.FallbackProviders...)
+					_, _ = fmt.Fprintln(cmd.OutOrStdout(), strings.Join(chain, ", "))
 				}
 
 				return nil
 			}
 
-			name := normalizeProvider(args[0])
-			if name == "" {
-				return fmt.Errorf("unsupported provider %q", args[0])
-			}
+			names := make([]string, 0, len(args))
 
-			if _, ok := cfg.FindProvider(name); !ok {
-				return fmt.Errorf("provider %q not configured", name)
+			for _, arg := range args {
+				name := normalizeProvider(cfg, arg)
+				if name == "" {
+					return fmt.Errorf("unsupported provider %q", arg)
+				}
+
+				if _, ok := cfg.FindProvider(name); !ok {
+					return fmt.Errorf("provider %q not configured", name)
+				}
+
+				names = append(names, name)
 			}
 
-			cfg.DefaultProvider = name
+			cfg.DefaultProvider = names[0]
+			cfg.FallbackProviders = names[1:]
 
 			path, err := config.Save(cfg)
 			if err != nil {
 				return err
 			}
 
-			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Set default provider to %s in %s\n", name, path)
+			if len(cfg.FallbackProviders) == 0 {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Set default provider to %s in %s\n", names[0], path)
+			} else {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Set default provider to %s with fallbacks %s in %s\n",
+					names[0], strings.Join(cfg.FallbackProviders, ", "), path)
+			}
 
 			return nil
 		},
@@ -85,7 +101,7 @@ func newProvidersListCmd() *cobra.Command {
 		Use:   "list",
 		Short: "List configured providers",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.Load()
+			cfg, err := loadConfig(cmd)
 			if err != nil {
 				return err
 			}
@@ -108,6 +124,8 @@ func newProvidersListCmd() *cobra.Command {
 				display := name
 				if name == cfg.DefaultProvider && name != "" {
 					display = name + " (default)"
+				} else if slices.Contains(cfg.FallbackProviders, name) {
+					display = name + " (fallback)"
 				}
 
 				_, _ = fmt.Fprintln(cmd.OutOrStdout(), display)
@@ -124,14 +142,18 @@ func newProvidersLogoutCmd() *cobra.Command {
 		Short: "Remove a configured provider",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			name := normalizeProvider(args[0])
+			cfg, err := config.LoadBase()
+			if err != nil {
+				return err
+			}
+
+			name := normalizeProvider(cfg, args[0])
 			if name == "" {
 				return fmt.Errorf("unsupported provider %q", args[0])
 			}
 
-			cfg, err := config.Load()
-			if err != nil {
-				return err
+			if err := deleteStoredAPIKey(cmd, cfg, name, cfg.Providers[name]); err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to delete the stored API key: %v\n", err)
 			}
 
 			if !config.RemoveProvider(cfg, name) {
@@ -152,6 +174,75 @@ func newProvidersLogoutCmd() *cobra.Command {
 	}
 }
 
+func newProvidersMigrateSecretsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate-secrets",
+		Short: "Move plaintext API keys from the config file to the secret store",
+		Args:  cobra.NoArgs,
+		RunE:  runProvidersMigrateSecrets,
+	}
+}
+
+// runProvidersMigrateSecrets moves the API keys written in the config file to
+// the secret store. The file is loaded alone, so keys and settings taken from
+// the environment, a profile or the project config are neither moved nor saved.
+func runProvidersMigrateSecrets(cmd *cobra.Command, _ []string) error {
+	cfg, err := config.LoadFile()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(cfg.Providers))
+	for name, provider := range cfg.Providers {
+		if provider.APIKey != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No plaintext API keys found.")
+
+		return nil
+	}
+
+	sort.Strings(names)
+
+	if backend, err := cfg.Secrets.Store(); err != nil {
+		return err
+	} else if backend == "" {
+		cfg.Secrets.Backend = config.SecretsBackendKeyring
+	}
+
+	store, err := openSecretStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		provider := cfg.Providers[name]
+		if err := store.Set(name, provider.APIKey); err != nil {
+			return fmt.Errorf("store API key for %s: %w", name, err)
+		}
+
+		provider.APIKey = ""
+		provider.APIKeyKeyring = true
+		cfg.Providers[name] = provider
+	}
+
+	path, err := config.Save(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Moved the API key of %s to the %s\n", name, store.Name())
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Updated %s\n", path)
+
+	return nil
+}
+
 func newProvidersModelsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "models [provider]",
@@ -173,7 +264,7 @@ func runProvidersModels(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(ctx, modelListTimeout)
 	defer cancel()
 
-	cfg, err := config.Load()
+	cfg, err := config.LoadBase()
 	if err != nil {
 		return err
 	}
@@ -185,10 +276,15 @@ func runProvidersModels(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if apiKey != "" {
-		provider.APIKey = apiKey
+	if apiKey == "" {
+		apiKey, err = secrets.ResolveAPIKey(ctx, providerName, provider, secretStoreOpener(cmd, cfg))
+		if err != nil {
+			return err
+		}
 	}
 
+	provider.APIKey = apiKey
+
 	all, _ := cmd.Flags().GetBool("all")
 
 	models, err := llm.ListModels(ctx, providerName, provider)
@@ -218,7 +314,7 @@ func resolveProviderForModels(cfg *config.Config, args []string) (string, config
 		return cfg.ActiveProvider()
 	}
 
-	providerName := normalizeProvider(args[0])
+	providerName := normalizeProvider(cfg, args[0])
 	if providerName == "" {
 		return "", config.ProviderConfig{}, fmt.Errorf("unsupported provider %q", args[0])
 	}
@@ -238,7 +334,12 @@ func newProvidersSetModelCmd() *cobra.Command {
 		Short: "Set the default model for a provider",
 		Args:  cobra.RangeArgs(minArgs, maxArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			name := normalizeProvider(args[0])
+			cfg, err := config.LoadBase()
+			if err != nil {
+				return err
+			}
+
+			name := normalizeProvider(cfg, args[0])
 			if name == "" {
 				return fmt.Errorf("unsupported provider %q", args[0])
 			}
@@ -253,11 +354,6 @@ func newProvidersSetModelCmd() *cobra.Command {
 				return fmt.Errorf("model is required (either as an argument or via --model flag)")
 			}
 
-			cfg, err := config.Load()
-			if err != nil {
-				return err
-			}
-
 			if _, ok := cfg.FindProvider(name); !ok {
 				return fmt.Errorf("provider %q not configured", name)
 			}
@@ -291,30 +387,75 @@ func newProvidersConfigureCmd() *cobra.Command {
 	}
 
 	cmd.Flags().String("api-key", "", "API key to store (skips prompt)")
+	cmd.Flags().String("api-key-env", "", "Read the API key from this environment variable instead of storing it")
+	cmd.Flags().String("api-key-cmd", "", "Shell command printing the API key, e.g. \"pass show openai\"")
 	cmd.Flags().String("model", "", "Default model to use (skips prompt)")
+	cmd.Flags().String("host", "", "Server address for self-hosted providers such as ollama")
+	cmd.Flags().String("base-url", "", "API base URL to use instead of the provider default")
+	cmd.Flags().String("type", "", "Provider type for custom entries (openai-compatible, openai, anthropic, aistudio, ollama)")
 
 	return cmd
 }
 
 func runProvidersConfigure(cmd *cobra.Command, args []string) error {
-	name := normalizeProvider(args[0])
+	cfg, err := config.LoadBase()
+	if err != nil {
+		return err
+	}
+
+	providerType, _ := cmd.Flags().GetString("type")
+	providerType = strings.ToLower(strings.TrimSpace(providerType))
+
+	name := normalizeProvider(cfg, args[0])
+	if name == "" && config.ProviderType(args[0], config.ProviderConfig{Type: providerType}) != "" {
+		name = strings.ToLower(strings.TrimSpace(args[0]))
+	}
+
 	if name == "" {
 		return fmt.Errorf("unsupported provider %q", args[0])
 	}
 
+	existing := cfg.Providers[name]
+	if providerType != "" {
+		existing.Type = providerType
+	}
+
+	requiresAPIKey := config.ProviderRequiresAPIKey(config.ProviderType(name, existing))
+
 	apiKey, _ := cmd.Flags().GetString("api-key")
 	model, _ := cmd.Flags().GetString("model")
+	host, _ := cmd.Flags().GetString("host")
+	baseURL, _ := cmd.Flags().GetString("base-url")
+	apiKeyEnv, _ := cmd.Flags().GetString("api-key-env")
+	apiKeyCmd, _ := cmd.Flags().GetString("api-key-cmd")
+
+	source := config.ProviderConfig{
+		APIKey:    apiKey,
+		APIKeyEnv: strings.TrimSpace(apiKeyEnv),
+		APIKeyCmd: strings.TrimSpace(apiKeyCmd),
+	}
+	if source.APIKeyEnv != "" || source.APIKeyCmd != "" {
+		requiresAPIKey = false
+	}
 
-	if apiKey == "" {
+	// Local OpenAI-compatible servers behind a base URL often need no key.
+	if config.NormalizeProviderName(config.ProviderType(name, existing)) == config.ProviderOpenAI &&
+		(baseURL != "" || existing.BaseURL != "") {
+		requiresAPIKey = false
+	}
+
+	if apiKey == "" && requiresAPIKey {
 		var err error
 
 		apiKey, err = promptForAPIKey(cmd, name)
 		if err != nil {
 			return err
 		}
+
+		source.APIKey = apiKey
 	}
 
-	if strings.TrimSpace(apiKey) == "" {
+	if strings.TrimSpace(apiKey) == "" && requiresAPIKey {
 		return fmt.Errorf("api key is required")
 	}
 
@@ -327,22 +468,27 @@ func runProvidersConfigure(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	cfg, err := config.Load()
+	cfg.UpsertProvider(name, config.ProviderConfig{
+		Model:   model,
+		Host:    strings.TrimSpace(host),
+		BaseURL: strings.TrimSpace(baseURL),
+		Type:    providerType,
+	})
+
+	where, err := storeAPIKey(cmd, cfg, name, source)
 	if err != nil {
 		return err
 	}
 
-	cfg.UpsertProvider(name, config.ProviderConfig{
-		APIKey: apiKey,
-		Model:  model,
-	})
-
 	path, err := config.Save(cfg)
 	if err != nil {
 		return err
 	}
 
 	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Configured %s in %s\n", name, path)
+	if where != "" {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "API key: %s\n", where)
+	}
 
 	return nil
 }
@@ -377,11 +523,13 @@ func promptForAPIKey(cmd *cobra.Command, provider string) (string, error) {
 }
 
 func apiKeyHint(provider string) string {
-	switch normalizeProvider(provider) {
+	switch normalizeProvider(nil, provider) {
 	case "aistudio":
 		return "https://aistudio.google.com/api-keys"
 	case "openai":
 		return "https://platform.openai.com/api-keys"
+	case "anthropic":
+		return "https://console.anthropic.com/settings/keys"
 	default:
 		return ""
 	}
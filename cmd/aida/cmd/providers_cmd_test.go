@@ -3,11 +3,13 @@ package cmd_test
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/metalagman/aida/cmd/aida/cmd"
 	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/secrets"
 	"github.com/stretchr/testify/require"
 )
 
@@ -162,3 +164,332 @@ func TestProvidersConfigureEOFInputUsesDefaultModel(t *testing.T) {
 	require.Equal(t, "test-key", provider.APIKey)
 	require.Equal(t, "gemini-2.5-flash", provider.Model)
 }
+
+func TestProvidersConfigureOllamaWithoutAPIKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+
+	var out bytes.Buffer
+
+	root := cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetIn(strings.NewReader(""))
+	root.SetArgs([]string{"providers", "configure", "ollama", "--host", "http://gpu-box:11434"})
+
+	err := root.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "Configured ollama")
+	require.NotContains(t, out.String(), "Enter API key")
+
+	loaded, err := config.Load()
+	require.NoError(t, err)
+
+	provider, ok := loaded.FindProvider("ollama")
+	require.True(t, ok)
+	require.Empty(t, provider.APIKey)
+	require.Equal(t, "http://gpu-box:11434", provider.Host)
+	require.Equal(t, "llama3.2", provider.Model)
+}
+
+func TestProvidersConfigureLocalServerWithoutAPIKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+
+	var out bytes.Buffer
+
+	root := cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetIn(strings.NewReader(""))
+	root.SetArgs([]string{
+		"providers", "configure", "lmstudio",
+		"--type", "openai-compatible",
+		"--base-url", "http://localhost:1234/v1",
+		"--model", "qwen2.5-coder",
+	})
+
+	err := root.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "Configured lmstudio")
+	require.NotContains(t, out.String(), "Enter API key")
+
+	loaded, err := config.Load()
+	require.NoError(t, err)
+
+	provider, ok := loaded.FindProvider("lmstudio")
+	require.True(t, ok)
+	require.Empty(t, provider.APIKey)
+	require.Equal(t, "http://localhost:1234/v1", provider.BaseURL)
+}
+
+func TestProvidersConfigureOpenAICompatible(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+
+	var out bytes.Buffer
+
+	root := cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{
+		"providers", "configure", "Groq",
+		"--type", "openai-compatible",
+		"--base-url", "https://api.groq.com/openai/v1",
+		"--api-key", "groq-key",
+		"--model", "llama-3.3-70b-versatile",
+	})
+
+	err := root.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "Configured groq")
+
+	loaded, err := config.Load()
+	require.NoError(t, err)
+
+	provider, ok := loaded.FindProvider("groq")
+	require.True(t, ok)
+	require.Equal(t, "groq-key", provider.APIKey)
+	require.Equal(t, "llama-3.3-70b-versatile", provider.Model)
+	require.Equal(t, "https://api.groq.com/openai/v1", provider.BaseURL)
+	require.Equal(t, "openai-compatible", provider.Type)
+
+	root = cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"providers", "configure", "unknown", "--api-key", "k", "--model", "m"})
+	require.Error(t, root.Execute())
+}
+
+func TestProvidersDefaultChain(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+
+	cfg := &config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"aistudio": {APIKey: "gemini-key", Model: "gemini-2.5-flash"},
+			"openai":   {APIKey: "openai-key", Model: "gpt-4o-mini"},
+		},
+		DefaultProvider: "aistudio",
+	}
+	_, err := config.Save(cfg)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+
+	root := cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"providers", "default", "openai", "google"})
+
+	err = root.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "Set default provider to openai with fallbacks aistudio")
+
+	loaded, err := config.Load()
+	require.NoError(t, err)
+	require.Equal(t, "openai", loaded.DefaultProvider)
+	require.Equal(t, []string{"aistudio"}, loaded.FallbackProviders)
+
+	out.Reset()
+
+	root = cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"providers", "list"})
+
+	err = root.Execute()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "aistudio (fallback)")
+	require.Contains(t, out.String(), "openai (default)")
+
+	out.Reset()
+
+	root = cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"providers", "default"})
+
+	err = root.Execute()
+	require.NoError(t, err)
+	require.Equal(t, "openai, aistudio\n", out.String())
+}
+
+func TestProvidersSecretStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+	t.Setenv("AIDA_SECRETS_PASSPHRASE", "correct horse")
+
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configPath := filepath.Join(configDir, "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+[secrets]
+backend = "file"
+
+[provider.openai]
+api_key = "sk-plaintext"
+model = "gpt-4o"
+`), 0o600))
+
+	run := func(args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+
+		root := cmd.NewRootCmd()
+		root.SetOut(&out)
+		root.SetErr(&out)
+		root.SetArgs(args)
+
+		require.NoError(t, root.Execute())
+
+		return out.String()
+	}
+
+	dataDir, err := config.DataDir()
+	require.NoError(t, err)
+
+	store := secrets.NewFileStore(filepath.Join(dataDir, "secrets.json"), func() (string, error) {
+		return "correct horse", nil
+	})
+
+	// Keys and settings from the environment are not moved or saved.
+	t.Setenv("AIDA_PROVIDER_ANTHROPIC_API_KEY", "sk-env")
+	t.Setenv("AIDA_MODE", "yolo")
+
+	out := run("providers", "migrate-secrets")
+	require.Contains(t, out, "Moved the API key of openai to the encrypted file")
+	require.NotContains(t, out, "anthropic")
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "sk-plaintext")
+	require.NotContains(t, string(data), "anthropic")
+	require.NotContains(t, string(data), "yolo")
+
+	_, err = store.Get("anthropic")
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+
+	t.Setenv("AIDA_PROVIDER_ANTHROPIC_API_KEY", "")
+	t.Setenv("AIDA_MODE", "")
+
+	loaded, err := config.Load()
+	require.NoError(t, err)
+	require.True(t, loaded.Providers["openai"].APIKeyKeyring)
+	require.Empty(t, loaded.Providers["openai"].APIKey)
+
+	key, err := store.Get("openai")
+	require.NoError(t, err)
+	require.Equal(t, "sk-plaintext", key)
+
+	require.Contains(t, run("providers", "migrate-secrets"), "No plaintext API keys found.")
+
+	out = run("providers", "configure", "aistudio", "--api-key", "gemini-key", "--model", "gemini-2.5-flash")
+	require.Contains(t, out, "API key: stored in the encrypted file")
+
+	key, err = store.Get("aistudio")
+	require.NoError(t, err)
+	require.Equal(t, "gemini-key", key)
+
+	out = run("providers", "configure", "openai", "--api-key-cmd", "pass show openai", "--model", "gpt-4o")
+	require.Contains(t, out, "API key: read from the output of pass show openai")
+
+	loaded, err = config.Load()
+	require.NoError(t, err)
+	require.Equal(t, "pass show openai", loaded.Providers["openai"].APIKeyCmd)
+	require.False(t, loaded.Providers["openai"].APIKeyKeyring)
+
+	_, err = store.Get("openai")
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+
+	run("providers", "logout", "aistudio")
+
+	_, err = store.Get("aistudio")
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+func TestProvidersMigrateSecretsKeepsProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+	t.Setenv("AIDA_PROFILE", "")
+	t.Setenv("AIDA_SECRETS_PASSPHRASE", "correct horse")
+
+	projectDir := filepath.Join(tmpDir, "project")
+	require.NoError(t, os.MkdirAll(projectDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".aida.toml"), []byte("shell = \"bash\"\n"), 0o644))
+	t.Chdir(projectDir)
+
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configPath := filepath.Join(configDir, "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+mode = "confirm"
+default_profile = "offline"
+
+[secrets]
+backend = "file"
+
+[provider.openai]
+api_key = "sk-plaintext"
+model = "gpt-4o"
+
+[profile.offline]
+provider = "ollama"
+model = "qwen2.5-coder"
+mode = "dry-run"
+
+[profile.offline.policy]
+deny_programs = ["curl"]
+`), 0o600))
+
+	var out bytes.Buffer
+
+	root := cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"--profile", "offline", "providers", "migrate-secrets"})
+	require.NoError(t, root.Execute())
+	require.Contains(t, out.String(), "Moved the API key of openai to the encrypted file")
+
+	saved, err := config.LoadFile()
+	require.NoError(t, err)
+	require.Equal(t, "confirm", saved.Mode)
+	require.Equal(t, "openai", saved.DefaultProvider)
+	require.NotContains(t, saved.Providers, "ollama")
+	require.NotEqual(t, "bash", saved.Shell)
+	require.Empty(t, saved.Policy.DenyPrograms)
+	require.True(t, saved.Providers["openai"].APIKeyKeyring)
+}
@@ -5,13 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"os/signal"
 	"runtime"
 	"strings"
 
 	"github.com/metalagman/aida/internal/config"
 	"github.com/metalagman/aida/internal/llm"
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/metalagman/aida/internal/llm/redact"
+	"github.com/metalagman/aida/internal/policy"
 	"github.com/metalagman/aida/internal/runner"
 	"github.com/spf13/cobra"
 )
@@ -23,21 +25,30 @@ type cliOptions struct {
 	yolo     bool
 	quiet    bool
 	dryRun   bool
+	fix      bool
 	shell    string
+	verbose  bool
+	usage    bool
+	noCache  bool
+	refresh  bool
+
+	showRedactions bool
+
+	candidates int
 }
 
+const (
+	// defaultFixAttempts is used when --fix is set without max_fix_attempts in config.
+	defaultFixAttempts = 2
+	// maxCandidates is the largest candidate count Gemini accepts.
+	maxCandidates = 8
+)
+
 var rootCmd = NewRootCmd()
 
+// Execute runs the root command and exits with the status ExitCode returns.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			os.Exit(exitErr.ExitCode())
-		}
-
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+	os.Exit(ExitCode(rootCmd.Execute(), os.Stderr))
 }
 
 func NewRootCmd() *cobra.Command {
@@ -49,35 +60,42 @@ func NewRootCmd() *cobra.Command {
 		Short:         "Generate and run a single shell command from a prompt",
 		SilenceUsage:  true,
 		SilenceErrors: true,
-		Args:          cobra.MinimumNArgs(1),
+		Args:          cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 			defer stop()
 
-			cfg, loadErr := config.Load()
-			if loadErr != nil {
-				return loadErr
+			prompt := PromptFromArgs(args, cmd.ArgsLenAtDash())
+			if strings.TrimSpace(prompt) == "" {
+				if !isTerminal(cmd.InOrStdin()) {
+					return errors.New("prompt is required")
+				}
+
+				return runChat(ctx, cmd, opts)
 			}
 
-			if err := applyOverrides(cfg, opts); err != nil {
-				return err
+			var input string
+
+			if pipedInput(cmd.InOrStdin()) {
+				var err error
+
+				input, err = readPipedInput(ctx, cmd)
+				if err != nil {
+					return err
+				}
+
+				defer useTerminalInput(cmd)()
 			}
 
-			provider, err := llm.NewProvider(ctx, cfg)
+			provider, r, cfg, err := prepareRun(ctx, cmd, opts)
 			if err != nil {
 				return err
 			}
 
-			r := setupRunner(cmd, opts, cfg)
+			r.Input = input
+			r.OnRecord = historyRecorder(cmd, prompt)
 
-			prompt := PromptFromArgs(args, cmd.ArgsLenAtDash())
-			if strings.TrimSpace(prompt) == "" {
-				return errors.New("prompt is required")
-			}
-
-			prompt = formatPromptWithShell(prompt, cfg.Shell)
-
-			if err := r.Run(ctx, prompt, provider); err != nil {
+			if err := r.Run(ctx, formatPromptWithShell(prompt, cfg.Shell), provider); err != nil {
 				if errors.Is(err, runner.ErrCancelled) {
 					return nil
 				}
@@ -90,12 +108,111 @@ func NewRootCmd() *cobra.Command {
 	}
 
 	setupFlags(cmd, opts)
+	cmd.PersistentFlags().String("profile", "", "Configuration profile to use (overrides AIDA_PROFILE)")
 	cmd.AddCommand(newProvidersCmd())
+	cmd.AddCommand(newExplainCmd())
+	cmd.AddCommand(newChatCmd())
+	cmd.AddCommand(newUsageCmd())
+	cmd.AddCommand(newCacheCmd())
+	cmd.AddCommand(newHistoryCmd())
+	cmd.AddCommand(newProfileCmd())
+	cmd.AddCommand(newConfigCmd())
 
 	return cmd
 }
 
-func setupRunner(cmd *cobra.Command, opts *cliOptions, cfg *config.Config) runner.Runner {
+// loadConfig loads the config with the profile selected by --profile, if the
+// command has the flag.
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	profile, _ := cmd.Flags().GetString("profile")
+
+	return config.LoadProfile(profile)
+}
+
+// prepareRun loads the config, applies the command line overrides and builds
+// the provider and runner for it.
+func prepareRun(
+	ctx context.Context,
+	cmd *cobra.Command,
+	opts *cliOptions,
+) (llm.Provider, runner.Runner, *config.Config, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, runner.Runner{}, nil, err
+	}
+
+	if err := applyOverrides(cfg, opts); err != nil {
+		return nil, runner.Runner{}, nil, err
+	}
+
+	if err := resolveAPIKeys(ctx, cmd, cfg); err != nil {
+		return nil, runner.Runner{}, nil, err
+	}
+
+	options, err := providerOptions(cmd, opts, cfg)
+	if err != nil {
+		return nil, runner.Runner{}, nil, err
+	}
+
+	provider, err := llm.NewProvider(ctx, cfg, options...)
+	if err != nil {
+		return nil, runner.Runner{}, nil, err
+	}
+
+	r, err := setupRunner(cmd, opts, cfg)
+	if err != nil {
+		return nil, runner.Runner{}, nil, err
+	}
+
+	r.Environment, err = environmentFacts(ctx, cmd, opts, cfg)
+	if err != nil {
+		return nil, runner.Runner{}, nil, err
+	}
+
+	r.Workspace, err = gitWorkspace(cfg)
+	if err != nil {
+		return nil, runner.Runner{}, nil, err
+	}
+
+	r.Instructions = cfg.Project.Instructions
+
+	return provider, r, cfg, nil
+}
+
+// providerOptions returns the llm options selected by the config and the
+// command line flags.
+func providerOptions(cmd *cobra.Command, opts *cliOptions, cfg *config.Config) ([]llm.OptOptionsSetter, error) {
+	var options []llm.OptOptionsSetter
+
+	if opts.verbose {
+		options = append(options, llm.WithVerbose(cmd.ErrOrStderr()))
+	}
+
+	redactor, err := newRedactor(cmd, opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	options = append(options, llm.WithRedactor(redactor))
+
+	ttl, err := cfg.Cache.Duration()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.noCache || ttl == 0 {
+		return options, nil
+	}
+
+	store, err := openCache(cfg, cache.WithRefresh(opts.refresh))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(options, llm.WithCache(store)), nil
+}
+
+func setupRunner(cmd *cobra.Command, opts *cliOptions, cfg *config.Config) (runner.Runner, error) {
 	mode := runner.RunMode(cfg.Mode)
 
 	switch {
@@ -109,25 +226,85 @@ func setupRunner(cmd *cobra.Command, opts *cliOptions, cfg *config.Config) runne
 		mode = runner.ModeConfirm
 	}
 
+	if opts.candidates < 1 || opts.candidates > maxCandidates {
+		return runner.Runner{}, fmt.Errorf("--candidates must be between 1 and %d", maxCandidates)
+	}
+
 	executor := runner.ShellExecutor{Shell: cfg.Shell}
 
-	return runner.Runner{
-		Mode:     mode,
-		Stdout:   cmd.OutOrStdout(),
-		Stderr:   cmd.ErrOrStderr(),
-		Stdin:    cmd.InOrStdin(),
-		Executor: executor,
+	fixAttempts := cfg.MaxFixAttempts
+	if opts.fix && fixAttempts <= 0 {
+		fixAttempts = defaultFixAttempts
 	}
+
+	commandPolicy, err := policy.New(cfg.PolicyLayers()...)
+	if err != nil {
+		return runner.Runner{}, err
+	}
+
+	var editor runner.CommandEditor
+	if program := runner.EditorFromEnv(); program != "" {
+		editor = runner.ExternalEditor{
+			Program: program,
+			Stdin:   cmd.InOrStdin(),
+			Stdout:  cmd.OutOrStdout(),
+			Stderr:  cmd.ErrOrStderr(),
+		}
+	}
+
+	return runner.Runner{
+		Mode:           mode,
+		Stdout:         cmd.OutOrStdout(),
+		Stderr:         cmd.ErrOrStderr(),
+		Stdin:          cmd.InOrStdin(),
+		Executor:       executor,
+		Policy:         commandPolicy,
+		MaxFixAttempts: fixAttempts,
+		Editor:         editor,
+		Candidates:     opts.candidates,
+		Live:           mode != runner.ModeQuiet && isTerminal(cmd.OutOrStdout()),
+		OnUsage:        usageReporter(cmd, opts, cfg),
+		OnRecord:       historyRecorder(cmd, ""),
+	}, nil
 }
 
 func setupFlags(cmd *cobra.Command, opts *cliOptions) {
-	cmd.Flags().StringVar(&opts.provider, "provider", "", "LLM provider (aistudio, openai)")
+	cmd.Flags().StringVar(&opts.provider, "provider", "", "LLM provider (aistudio, openai, anthropic, ollama)")
 	cmd.Flags().StringVar(&opts.apiKey, "api-key", "", "LLM API key")
 	cmd.Flags().StringVar(&opts.model, "model", "", "LLM model name")
 	cmd.Flags().StringVar(&opts.shell, "shell", "", "Shell executable for running commands")
 	cmd.Flags().BoolVar(&opts.yolo, "yolo", false, "Run without confirmation")
 	cmd.Flags().BoolVar(&opts.quiet, "quiet", false, "Run silently")
 	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Print command without running")
+	cmd.Flags().BoolVar(&opts.fix, "fix", false, "Ask the model to fix commands that exit with a non-zero status")
+	cmd.Flags().IntVar(&opts.candidates, "candidates", 1, "Number of alternative commands to choose from")
+	cmd.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "Report which provider and model answered")
+	cmd.Flags().BoolVar(&opts.usage, "usage", false, "Print token usage and estimated cost of every request")
+	setupCacheFlags(cmd, opts)
+	setupRedactionFlags(cmd, opts)
+}
+
+func setupCacheFlags(cmd *cobra.Command, opts *cliOptions) {
+	cmd.Flags().BoolVar(&opts.noCache, "no-cache", false, "Neither reuse nor store cached answers")
+	cmd.Flags().BoolVar(&opts.refresh, "refresh", false, "Ask the model again and replace the cached answer")
+}
+
+func setupRedactionFlags(cmd *cobra.Command, opts *cliOptions) {
+	cmd.Flags().BoolVar(&opts.showRedactions, "show-redactions", false, "Print on stderr which secrets were masked")
+}
+
+// newRedactor returns the redactor masking secrets in every request. With
+// --show-redactions, each masked secret is reported on stderr.
+func newRedactor(cmd *cobra.Command, opts *cliOptions, cfg *config.Config) (*redact.Redactor, error) {
+	options := []redact.OptOptionsSetter{redact.WithPatterns(cfg.Redaction.Patterns)}
+
+	if opts.showRedactions {
+		options = append(options, redact.WithOnRedact(func(r redact.Redaction) {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Redacted: %s %s as %s\n", r.Kind, r.Preview, r.Placeholder)
+		}))
+	}
+
+	return redact.NewRedactor(options...)
 }
 
 func PromptFromArgs(args []string, dashIndex int) string {
@@ -165,12 +342,13 @@ func applyOverrides(cfg *config.Config, opts *cliOptions) error {
 	}
 
 	if opts.provider != "" {
-		normalized := normalizeProvider(opts.provider)
+		normalized := normalizeProvider(cfg, opts.provider)
 		if normalized == "" {
 			return fmt.Errorf("unsupported provider %q", opts.provider)
 		}
 
 		cfg.DefaultProvider = normalized
+		cfg.FallbackProviders = nil
 	}
 
 	if opts.shell != "" {
@@ -197,7 +375,7 @@ func applyOverrides(cfg *config.Config, opts *cliOptions) error {
 
 func resolveProviderName(cfg *config.Config, opts *cliOptions) string {
 	if opts.provider != "" {
-		if normalized := normalizeProvider(opts.provider); normalized != "" {
+		if normalized := normalizeProvider(cfg, opts.provider); normalized != "" {
 			return normalized
 		}
 
@@ -2,6 +2,7 @@ package cmd_test
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/metalagman/aida/cmd/aida/cmd"
@@ -60,3 +61,43 @@ func TestRootCmdRejectsUnknownProvider(t *testing.T) {
 		t.Fatal("expected error for unsupported provider")
 	}
 }
+
+func TestExplainCmdRequiresCommand(t *testing.T) {
+	root := cmd.NewRootCmd()
+	root.SetIn(strings.NewReader("  \n"))
+	root.SetArgs([]string{"explain"})
+
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "command is required") {
+		t.Fatalf("expected missing command error, got %v", err)
+	}
+}
+
+func TestRootCmdRequiresPromptWithoutTerminal(t *testing.T) {
+	root := cmd.NewRootCmd()
+	root.SetIn(strings.NewReader(""))
+	root.SetArgs([]string{})
+
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "prompt is required") {
+		t.Fatalf("expected missing prompt error, got %v", err)
+	}
+}
+
+func TestExplainCmdReadsOnlyPipedInput(t *testing.T) {
+	// Like a terminal, a character device is not read for the command.
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	root := cmd.NewRootCmd()
+	root.SetIn(devNull)
+	root.SetArgs([]string{"explain"})
+
+	err = root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "command is required") {
+		t.Fatalf("expected missing command error, got %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/secrets"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// secretsPassphraseEnv unlocks the encrypted secrets file without a prompt.
+const secretsPassphraseEnv = "AIDA_SECRETS_PASSPHRASE"
+
+// secretStoreOpener returns a function opening the configured secret store,
+// the keyring when no backend is configured. It is opened at most once.
+func secretStoreOpener(cmd *cobra.Command, cfg *config.Config) func() (secrets.Store, error) {
+	var (
+		store secrets.Store
+		err   error
+	)
+
+	return func() (secrets.Store, error) {
+		if store == nil && err == nil {
+			store, err = openSecretStore(cmd, cfg)
+		}
+
+		return store, err
+	}
+}
+
+func openSecretStore(cmd *cobra.Command, cfg *config.Config) (secrets.Store, error) {
+	backend, err := cfg.Secrets.Store()
+	if err != nil {
+		return nil, err
+	}
+
+	if backend == "" {
+		backend = config.SecretsBackendKeyring
+	}
+
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	file := secrets.NewFileStore(filepath.Join(dir, "secrets.json"), func() (string, error) {
+		return secretsPassphrase(cmd)
+	})
+
+	return secrets.Open(backend, file)
+}
+
+// secretsPassphrase returns the passphrase of the secrets file from the
+// environment, or asks for it on the terminal.
+func secretsPassphrase(cmd *cobra.Command) (string, error) {
+	if passphrase := os.Getenv(secretsPassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	file, ok := cmd.InOrStdin().(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		return "", fmt.Errorf("set %s to unlock the secrets file", secretsPassphraseEnv)
+	}
+
+	_, _ = fmt.Fprint(cmd.ErrOrStderr(), "Passphrase for the aida secrets file: ")
+
+	passphrase, err := term.ReadPassword(int(file.Fd()))
+	_, _ = fmt.Fprintln(cmd.ErrOrStderr())
+
+	if err != nil {
+		return "", fmt.Errorf("read passphrase: %w", err)
+	}
+
+	return strings.TrimSpace(string(passphrase)), nil
+}
+
+// resolveAPIKeys fills in the API keys of the providers about to be used from
+// their environment variable, command or the secret store. A fallback whose
+// key cannot be resolved is dropped from the chain with a warning.
+func resolveAPIKeys(ctx context.Context, cmd *cobra.Command, cfg *config.Config) error {
+	chain, err := cfg.ProviderChain()
+	if err != nil {
+		return err
+	}
+
+	store := secretStoreOpener(cmd, cfg)
+
+	for i, named := range chain {
+		err := resolveAPIKey(ctx, cfg, named.Name, store)
+
+		switch {
+		case err != nil && i == 0:
+			return err
+		case err != nil:
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: skipping fallback %v\n", err)
+
+			cfg.FallbackProviders = slices.DeleteFunc(cfg.FallbackProviders, func(fallback string) bool {
+				return cfg.ResolveProviderName(fallback) == named.Name
+			})
+		}
+	}
+
+	return nil
+}
+
+func resolveAPIKey(ctx context.Context, cfg *config.Config, name string, store func() (secrets.Store, error)) error {
+	provider, ok := cfg.Providers[name]
+	if !ok {
+		return nil
+	}
+
+	key, err := secrets.ResolveAPIKey(ctx, name, provider, store)
+	if err != nil {
+		return fmt.Errorf("provider %s: %w", name, err)
+	}
+
+	provider.APIKey = key
+	cfg.Providers[name] = provider
+
+	return nil
+}
+
+// deleteStoredAPIKey removes the API key of a provider entry from the secret
+// store, if it was kept there.
+func deleteStoredAPIKey(cmd *cobra.Command, cfg *config.Config, name string, provider config.ProviderConfig) error {
+	if !provider.APIKeyKeyring {
+		return nil
+	}
+
+	store, err := openSecretStore(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Delete(name); err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// storeAPIKey records where the API key of a provider entry comes from,
+// clearing any other source. A key entered directly is kept in the secret
+// store when a backend is configured and in the config file otherwise. It
+// returns a description of the source for the user, empty for the config file.
+func storeAPIKey(cmd *cobra.Command, cfg *config.Config, name string, source config.ProviderConfig) (string, error) {
+	if source.APIKey == "" && source.APIKeyEnv == "" && source.APIKeyCmd == "" {
+		return "", nil
+	}
+
+	provider := cfg.Providers[name]
+	previous := provider
+
+	provider.APIKey = ""
+	provider.APIKeyEnv = source.APIKeyEnv
+	provider.APIKeyCmd = source.APIKeyCmd
+	provider.APIKeyKeyring = false
+
+	var where string
+
+	switch {
+	case source.APIKeyEnv != "":
+		where = "read from $" + source.APIKeyEnv
+	case source.APIKeyCmd != "":
+		where = "read from the output of " + source.APIKeyCmd
+	default:
+		backend, err := cfg.Secrets.Store()
+		if err != nil {
+			return "", err
+		}
+
+		if backend == "" {
+			provider.APIKey = source.APIKey
+
+			break
+		}
+
+		store, err := openSecretStore(cmd, cfg)
+		if err != nil {
+			return "", err
+		}
+
+		if err := store.Set(name, source.APIKey); err != nil {
+			return "", fmt.Errorf("store API key: %w", err)
+		}
+
+		provider.APIKeyKeyring = true
+		where = "stored in the " + store.Name()
+	}
+
+	if !provider.APIKeyKeyring {
+		if err := deleteStoredAPIKey(cmd, cfg, name, previous); err != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to delete the stored API key: %v\n", err)
+		}
+	}
+
+	cfg.Providers[name] = provider
+
+	return where, nil
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+const hoursPerDay = 24
+
+func newUsageCmd() *cobra.Command {
+	var since string
+
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Summarize token usage and estimated cost per provider and model",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			from, err := parseSince(since, time.Now())
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			ledger, err := usage.DefaultLedger()
+			if err != nil {
+				return err
+			}
+
+			entries, err := ledger.Entries(from)
+			if err != nil {
+				return err
+			}
+
+			if len(entries) == 0 {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No usage recorded.")
+
+				return nil
+			}
+
+			return printUsage(cmd.OutOrStdout(), usage.Summarize(entries, cfg.Prices))
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Only count usage since a duration ago (7d, 12h) or a date (2006-01-02)")
+
+	return cmd
+}
+
+// parseSince turns a --since value into the earliest time to count. Durations
+// accept a "d" suffix for days on top of Go duration units. An empty value
+// counts everything.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n >= 0 {
+			return now.Add(-time.Duration(n) * hoursPerDay * time.Hour), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return date, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration such as 7d or 12h, or a date", value)
+}
+
+func printUsage(out io.Writer, summaries []usage.Summary) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(tw, "PROVIDER\tMODEL\tREQUESTS\tPROMPT\tCOMPLETION\tCOST")
+
+	var (
+		total    usage.Summary
+		unpriced int
+	)
+
+	for _, summary := range summaries {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\n", summary.Provider, summary.Model,
+			summary.Requests, summary.PromptTokens, summary.CompletionTokens, formatCost(summary.Cost, summary.Priced))
+
+		total.Requests += summary.Requests
+		total.PromptTokens += summary.PromptTokens
+		total.CompletionTokens += summary.CompletionTokens
+		total.Cost += summary.Cost
+
+		if !summary.Priced {
+			unpriced++
+		}
+	}
+
+	_, _ = fmt.Fprintf(tw, "Total\t\t%d\t%d\t%d\t%s\n",
+		total.Requests, total.PromptTokens, total.CompletionTokens, formatCost(total.Cost, true))
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if unpriced > 0 {
+		_, _ = fmt.Fprintln(out, "\nModels without a price are left out of the cost; add them under [[prices]] in config.")
+	}
+
+	return nil
+}
+
+func formatCost(cost float64, priced bool) string {
+	if !priced {
+		return "-"
+	}
+
+	return fmt.Sprintf("$%.4f", cost)
+}
+
+// usageReporter returns the runner callback recording every answer in the
+// usage ledger and, with --usage, printing it to stderr. Cached answers cost
+// nothing and are not recorded.
+func usageReporter(cmd *cobra.Command, opts *cliOptions, cfg *config.Config) func(provider.Usage) {
+	ledger, ledgerErr := usage.DefaultLedger()
+
+	return func(u provider.Usage) {
+		if u.Cached {
+			if opts.usage {
+				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "Usage: cached answer, no tokens spent")
+			}
+
+			return
+		}
+
+		if ledgerErr == nil {
+			ledgerErr = ledger.Record(usage.NewEntry(u, time.Now()))
+		}
+
+		if ledgerErr != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: record usage: %v\n", ledgerErr)
+		}
+
+		if !opts.usage {
+			return
+		}
+
+		cost := "cost unknown"
+		if price, ok := config.FindPrice(cfg.Prices, u.Provider, u.Model); ok {
+			cost = "about " + formatCost(price.Cost(u.PromptTokens, u.CompletionTokens), true)
+		}
+
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Usage: %d prompt + %d completion tokens (%s, %s), %s\n",
+			u.PromptTokens, u.CompletionTokens, u.Provider, u.Model, cost)
+	}
+}
@@ -0,0 +1,91 @@
+package cmd_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metalagman/aida/cmd/aida/cmd"
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/usage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageSummary(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+
+	t.Cleanup(func() {
+		os.Setenv("HOME", origHome)
+	})
+	os.Setenv("HOME", tmpDir)
+
+	_, err := config.Save(&config.Config{
+		Prices: []config.Price{{Model: "gpt-4o", Input: 2.5, Output: 10}},
+	})
+	require.NoError(t, err)
+
+	ledger, err := usage.DefaultLedger()
+	require.NoError(t, err)
+
+	now := time.Now()
+	for _, entry := range []usage.Entry{
+		{Time: now.Add(-30 * 24 * time.Hour), Provider: "openai", Model: "gpt-4o", PromptTokens: 1000, CompletionTokens: 100},
+		{Time: now.Add(-time.Hour), Provider: "openai", Model: "gpt-4o", PromptTokens: 1000, CompletionTokens: 100},
+		{Time: now.Add(-time.Hour), Provider: "ollama", Model: "llama3.2", PromptTokens: 500, CompletionTokens: 50},
+	} {
+		require.NoError(t, ledger.Record(entry))
+	}
+
+	var out bytes.Buffer
+
+	root := cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"usage", "--since", "7d"})
+
+	require.NoError(t, root.Execute())
+	require.Regexp(t, `openai\s+gpt-4o\s+1\s+1000\s+100\s+\$0\.0035`, out.String())
+	require.Regexp(t, `ollama\s+llama3\.2\s+1\s+500\s+50\s+-`, out.String())
+	require.Regexp(t, `Total\s+2\s+1500\s+150\s+\$0\.0035`, out.String())
+	require.Contains(t, out.String(), "[[prices]]")
+
+	root = cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"usage", "--since", "last week"})
+
+	require.ErrorContains(t, root.Execute(), `invalid --since "last week"`)
+}
+
+func TestExplainRecordsUsage(t *testing.T) {
+	server := newFakeProvider(t, []fakeAnswer{{
+		status:  http.StatusOK,
+		content: `{"summary": "Lists files", "segments": [], "side_effects": []}`,
+	}})
+	setupProviderHome(t, server.URL)
+
+	var out, errOut bytes.Buffer
+
+	root := cmd.NewRootCmd()
+	root.SetOut(&out)
+	root.SetErr(&errOut)
+	root.SetIn(strings.NewReader(""))
+	root.SetArgs([]string{"explain", "--usage", "--no-cache", "--", "ls", "-la"})
+
+	require.NoError(t, root.Execute())
+	require.Contains(t, out.String(), "Lists files")
+	require.Contains(t, errOut.String(), "Usage: 42 prompt + 8 completion tokens (openai, gpt-4o-mini)")
+
+	ledger, err := usage.DefaultLedger()
+	require.NoError(t, err)
+
+	entries, err := ledger.Entries(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, 42, entries[0].PromptTokens)
+	require.Equal(t, 8, entries[0].CompletionTokens)
+}
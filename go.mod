@@ -13,6 +13,7 @@ require (
 	google.golang.org/adk v0.3.0
 	google.golang.org/genai v1.40.0
 	gopkg.in/yaml.v3 v3.0.1
+	mvdan.cc/sh/v3 v3.12.0
 )
 
 require (
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCacheTTL is how long answers are reused when cache.ttl is unset.
+const DefaultCacheTTL = 24 * time.Hour
+
+// CacheConfig controls the local cache of model answers.
+type CacheConfig struct {
+	// TTL is how long an answer is reused, as a duration such as "24h" or
+	// "30m". "0" disables the cache.
+	TTL string `mapstructure:"ttl" toml:"ttl,omitempty" yaml:"ttl,omitempty"`
+}
+
+// Duration parses TTL, DefaultCacheTTL when unset.
+func (c CacheConfig) Duration() (time.Duration, error) {
+	if c.TTL == "" {
+		return DefaultCacheTTL, nil
+	}
+
+	ttl, err := time.ParseDuration(c.TTL)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid cache ttl %q: use a duration such as 24h", c.TTL)
+	}
+
+	return ttl, nil
+}
+
+// CacheDir returns the directory aida keeps cached data in.
+func CacheDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".cache", "aida"), nil
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/pelletier/go-toml/v2"
@@ -13,10 +14,16 @@ import (
 )
 
 const (
-	ProviderAIStudio = "aistudio"
-	ProviderOpenAI   = "openai"
+	ProviderAIStudio  = "aistudio"
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderOllama    = "ollama"
 )
 
+// ProviderTypeOpenAICompatible is the type of named provider entries that talk
+// to any OpenAI-compatible chat completions API.
+const ProviderTypeOpenAICompatible = "openai-compatible"
+
 const (
 	DirPerm       = 0o700
 	FilePerm      = 0o600
@@ -29,19 +36,156 @@ type Config struct {
 	DefaultProvider string `mapstructure:"default_provider" toml:"default_provider" yaml:"default_provider"`
 	Mode            string `mapstructure:"mode"             toml:"mode"             yaml:"mode"`
 	Shell           string `mapstructure:"shell"            toml:"shell"            yaml:"shell"`
+	//nolint:lll
+	MaxFixAttempts int          `mapstructure:"max_fix_attempts" toml:"max_fix_attempts" yaml:"max_fix_attempts"`
+	Policy         PolicyConfig `mapstructure:"policy"           toml:"policy,omitempty" yaml:"policy,omitempty"`
+
+	// FallbackProviders are tried in order when the default provider fails. A
+	// default_provider list fills them with everything after its first entry.
+	//nolint:lll
+	FallbackProviders []string `mapstructure:"fallback_providers" toml:"fallback_providers,omitempty" yaml:"fallback_providers,omitempty"`
+
+	// Prices estimate the cost of the tokens reported by providers.
+	Prices []Price `mapstructure:"prices" toml:"prices,omitempty" yaml:"prices,omitempty"`
+	// Cache controls the local cache of model answers.
+	Cache CacheConfig `mapstructure:"cache" toml:"cache,omitempty" yaml:"cache,omitempty"`
+	// Environment selects the facts about the local machine sent to the model.
+	//nolint:lll
+	Environment EnvironmentConfig `mapstructure:"environment" toml:"environment,omitempty" yaml:"environment,omitempty"`
+	// Git controls what is sent to the model about the current git repository.
+	Git GitConfig `mapstructure:"git" toml:"git,omitempty" yaml:"git,omitempty"`
+	// Redaction adds patterns for secrets masked in requests.
+	Redaction RedactionConfig `mapstructure:"redaction" toml:"redaction,omitempty" yaml:"redaction,omitempty"`
+	// Secrets selects where API keys are stored.
+	Secrets SecretsConfig `mapstructure:"secrets" toml:"secrets,omitempty" yaml:"secrets,omitempty"`
+
+	// DefaultProfile is the profile applied when no other one is selected.
+	//nolint:lll
+	DefaultProfile string `mapstructure:"default_profile" toml:"default_profile,omitempty" yaml:"default_profile,omitempty"`
+	// Profiles are named sets of settings applied over the top-level ones.
+	// They are read by readProfiles.
+	Profiles map[string]Profile `mapstructure:"-" toml:"profile,omitempty" yaml:"profile,omitempty"`
+	// ActiveProfile is the profile applied by Load, if any.
+	ActiveProfile string `mapstructure:"-" toml:"-" yaml:"-"`
+	// ActiveProfileSource tells where ActiveProfile was selected, see SelectProfile.
+	ActiveProfileSource string `mapstructure:"-" toml:"-" yaml:"-"`
+	// ProfilePolicy is the policy of ActiveProfile, checked as a layer on top of Policy.
+	ProfilePolicy PolicyConfig `mapstructure:"-" toml:"-" yaml:"-"`
+
+	// ProjectPolicy is read from a project-local policy file and never saved.
+	ProjectPolicy PolicyConfig `mapstructure:"-" toml:"-" yaml:"-"`
+	// ProjectPolicyPath is the file ProjectPolicy was read from, if any.
+	ProjectPolicyPath string `mapstructure:"-" toml:"-" yaml:"-"`
+	// Project is read from a project config file and never saved.
+	Project ProjectConfig `mapstructure:"-" toml:"-" yaml:"-"`
+	// ProjectPath is the file Project was read from, if any.
+	ProjectPath string `mapstructure:"-" toml:"-" yaml:"-"`
 }
 
 type ProviderConfig struct {
-	APIKey string `mapstructure:"api_key" toml:"api_key" yaml:"api_key"`
-	Model  string `mapstructure:"model"   toml:"model"   yaml:"model"`
+	APIKey string `mapstructure:"api_key" toml:"api_key"        yaml:"api_key"`
+	Model  string `mapstructure:"model"   toml:"model"          yaml:"model"`
+	// APIKeyEnv names an environment variable holding the API key.
+	APIKeyEnv string `mapstructure:"api_key_env" toml:"api_key_env,omitempty" yaml:"api_key_env,omitempty"`
+	// APIKeyCmd is a shell command printing the API key, e.g. "pass show openai".
+	APIKeyCmd string `mapstructure:"api_key_cmd" toml:"api_key_cmd,omitempty" yaml:"api_key_cmd,omitempty"`
+	// APIKeyKeyring reads the API key from the secret store, see SecretsConfig.
+	//nolint:lll
+	APIKeyKeyring bool `mapstructure:"api_key_keyring" toml:"api_key_keyring,omitempty" yaml:"api_key_keyring,omitempty"`
+	// Host is the server address of a self-hosted provider such as ollama.
+	Host string `mapstructure:"host" toml:"host,omitempty" yaml:"host,omitempty"`
+	// BaseURL overrides the API endpoint of the provider.
+	BaseURL string `mapstructure:"base_url" toml:"base_url,omitempty" yaml:"base_url,omitempty"`
+	// Type selects the implementation of an entry whose name is not a built-in
+	// provider: a built-in provider name or "openai-compatible".
+	Type string `mapstructure:"type" toml:"type,omitempty" yaml:"type,omitempty"`
+	// MaxRetries is how many times a request failing with 429, 5xx or a
+	// dropped connection is retried. DefaultMaxRetries is used when unset.
+	MaxRetries *int `mapstructure:"max_retries" toml:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+}
+
+// DefaultMaxRetries is the retry count of providers without max_retries.
+const DefaultMaxRetries = 2
+
+// Retries returns the configured retry count, DefaultMaxRetries when unset.
+func (p ProviderConfig) Retries() int {
+	if p.MaxRetries == nil {
+		return DefaultMaxRetries
+	}
+
+	return max(*p.MaxRetries, 0)
 }
 
+// Load returns the effective config. Each layer overrides the previous one:
+//  1. built-in defaults
+//  2. the config file
+//  3. the selected profile, see SelectProfile
+//  4. the project config, see LoadProjectConfig
+//  5. environment variables
+//  6. command line flags, applied by the caller
+//
+// The policies of the project are checked in addition to the policy of the
+// config file or profile.
 func Load() (*Config, error) {
+	return LoadProfile("")
+}
+
+// LoadProfile is Load with the profile given on the command line, if any.
+func LoadProfile(name string) (*Config, error) {
+	cfg, err := LoadBase()
+	if err != nil {
+		return nil, err
+	}
+
+	wd, _ := os.Getwd()
+
+	name, source, err := cfg.SelectProfile(name, wd)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		if err := cfg.ApplyProfile(name, source); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.applyProject()
+
+	return cfg, nil
+}
+
+// LoadBase loads the config without applying a profile. Commands that change
+// and save the config use it, so profile settings are not written back.
+func LoadBase() (*Config, error) {
+	cfg, _, err := load(true)
+
+	return cfg, err
+}
+
+// LoadFile loads the config file alone, without environment variables or a
+// profile, for commands that set single keys in it.
+func LoadFile() (*Config, error) {
+	cfg, _, err := load(false)
+
+	return cfg, err
+}
+
+// applyProject merges the project config over the settings it can pin.
+func (c *Config) applyProject() {
+	if c.Project.Shell != "" && !envSet("shell") {
+		c.Shell = c.Project.Shell
+	}
+}
+
+// load reads the config file, and environment variables when withEnv is set.
+// It returns the viper instance the file was read with.
+func load(withEnv bool) (*Config, *viper.Viper, error) {
 	v := viper.New()
 
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 
 	configDir := filepath.Join(homeDir, ".config", "aida")
@@ -49,38 +193,119 @@ func Load() (*Config, error) {
 	v.AddConfigPath(configDir)
 	v.SetConfigName("config")
 
-	v.SetEnvPrefix("AIDA")
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
-	v.AutomaticEnv()
+	if withEnv {
+		v.SetEnvPrefix("AIDA")
+		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+		v.AutomaticEnv()
 
-	// Bind keys so Viper knows to look for them in environment variables
-	_ = v.BindEnv("mode")
-	_ = v.BindEnv("shell")
-	_ = v.BindEnv("default_provider")
+		// Bind keys so Viper knows to look for them in environment variables
+		_ = v.BindEnv("mode")
+		_ = v.BindEnv("shell")
+		_ = v.BindEnv("default_provider")
+		_ = v.BindEnv("max_fix_attempts")
+		_ = v.BindEnv("cache.ttl")
+		_ = v.BindEnv("git.detail")
+	}
 
 	v.SetDefault("mode", "confirm")
 	v.SetDefault("shell", "/bin/sh")
 
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("failed to read config file: %w", err)
+			return nil, nil, fmt.Errorf("failed to read config file: %w", err)
 		}
 	}
 
+	splitProviderChain(v)
+
 	var cfg Config
 
 	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	applyEnvOverrides(&cfg)
-	normalizeProviders(&cfg)
+	if cfg.Profiles, err = readProfiles(v.ConfigFileUsed()); err != nil {
+		return nil, nil, err
+	}
+
+	if withEnv {
+		applyEnvOverrides(&cfg)
+	} else {
+		cfg.DefaultProvider = cfg.ResolveProviderName(cfg.DefaultProvider)
+	}
+
+	if err := normalizeProviders(&cfg); err != nil {
+		return nil, nil, err
+	}
+
+	if wd, err := os.Getwd(); err == nil {
+		cfg.ProjectPolicy, cfg.ProjectPolicyPath, err = LoadProjectPolicy(wd)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cfg.Project, cfg.ProjectPath, err = LoadProjectConfig(wd)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
 	if cfg.DefaultProvider == "" && len(cfg.Providers) > 0 {
 		cfg.DefaultProvider = FirstProviderName(cfg.Providers)
 	}
 
-	return &cfg, nil
+	return &cfg, v, nil
+}
+
+// splitProviderChain lets default_provider hold an ordered list of providers,
+// either as a list in the config file or comma separated in
+// AIDA_DEFAULT_PROVIDER. The first entry becomes the default provider and the
+// rest its fallbacks.
+func splitProviderChain(v *viper.Viper) {
+	var chain []string
+
+	switch value := v.Get("default_provider").(type) {
+	case string:
+		chain = strings.Split(value, ",")
+	case []string:
+		chain = value
+	case []any:
+		for _, item := range value {
+			chain = append(chain, fmt.Sprint(item))
+		}
+	default:
+		return
+	}
+
+	names := make([]string, 0, len(chain))
+
+	for _, name := range chain {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		v.Set("default_provider", "")
+
+		return
+	}
+
+	v.Set("default_provider", names[0])
+
+	if len(names) > 1 {
+		v.Set("fallback_providers", names[1:])
+	}
+}
+
+// DataDir returns the directory aida keeps its local records in.
+func DataDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".local", "share", "aida"), nil
 }
 
 func ResolveConfigPath() (string, error) {
@@ -148,6 +373,10 @@ func NormalizeProviderName(input string) string {
 		return ProviderAIStudio
 	case ProviderOpenAI, "open-ai":
 		return ProviderOpenAI
+	case ProviderAnthropic, "claude":
+		return ProviderAnthropic
+	case ProviderOllama:
+		return ProviderOllama
 	default:
 		return ""
 	}
@@ -159,17 +388,62 @@ func DefaultModelForProvider(input string) string {
 		return "gemini-2.5-flash"
 	case ProviderOpenAI:
 		return "gpt-4o-mini"
+	case ProviderAnthropic:
+		return "claude-haiku-4-5"
+	case ProviderOllama:
+		return "llama3.2"
 	default:
 		return ""
 	}
 }
 
+// ProviderType returns the built-in provider implementing the named entry:
+// the name itself for built-in providers, otherwise the one selected by its
+// type. It returns "" when neither is known.
+func ProviderType(name string, provider ProviderConfig) string {
+	if builtin := NormalizeProviderName(name); builtin != "" {
+		return builtin
+	}
+
+	providerType := strings.ToLower(strings.TrimSpace(provider.Type))
+	if providerType == ProviderTypeOpenAICompatible {
+		return ProviderOpenAI
+	}
+
+	return NormalizeProviderName(providerType)
+}
+
+// ResolveProviderName maps input to a built-in provider name or to the name of
+// a configured custom entry. It returns "" when neither matches.
+func (c *Config) ResolveProviderName(input string) string {
+	if name := NormalizeProviderName(input); name != "" {
+		return name
+	}
+
+	name := strings.ToLower(strings.TrimSpace(input))
+	if c == nil || name == "" {
+		return ""
+	}
+
+	if _, ok := c.Providers[name]; ok {
+		return name
+	}
+
+	return ""
+}
+
+// ProviderRequiresAPIKey reports whether the provider needs an API key. Local
+// providers such as ollama work without one.
+func ProviderRequiresAPIKey(input string) bool {
+	return NormalizeProviderName(input) != ProviderOllama
+}
+
 func (c *Config) ActiveProvider() (string, ProviderConfig, error) {
 	if c == nil {
 		return "", ProviderConfig{}, fmt.Errorf("config is nil")
 	}
 
-	name := NormalizeProviderName(c.DefaultProvider)
+	name := c.ResolveProviderName(c.DefaultProvider)
 
 	if name == "" && len(c.Providers) > 0 {
 		name = FirstProviderName(c.Providers)
@@ -183,15 +457,71 @@ func (c *Config) ActiveProvider() (string, ProviderConfig, error) {
 		return name, provider, nil
 	}
 
+	if !ProviderRequiresAPIKey(name) {
+		return name, ProviderConfig{Model: DefaultModelForProvider(name)}, nil
+	}
+
 	return "", ProviderConfig{}, fmt.Errorf("default provider %q not configured", name)
 }
 
+// NamedProvider is a provider config together with the name of its entry.
+type NamedProvider struct {
+	Name   string
+	Config ProviderConfig
+}
+
+// ProviderChain returns the active provider followed by its fallbacks, in the
+// order they should be tried.
+func (c *Config) ProviderChain() ([]NamedProvider, error) {
+	name, active, err := c.ActiveProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []NamedProvider{{Name: name, Config: active}}
+
+	for _, fallback := range c.FallbackProviders {
+		fallbackName := c.ResolveProviderName(fallback)
+		if fallbackName == "" {
+			return nil, fmt.Errorf("unsupported fallback provider %q", fallback)
+		}
+
+		if containsProvider(chain, fallbackName) {
+			continue
+		}
+
+		provider, ok := c.Providers[fallbackName]
+
+		switch {
+		case ok:
+		case !ProviderRequiresAPIKey(fallbackName):
+			provider = ProviderConfig{Model: DefaultModelForProvider(fallbackName)}
+		default:
+			return nil, fmt.Errorf("fallback provider %q not configured", fallbackName)
+		}
+
+		chain = append(chain, NamedProvider{Name: fallbackName, Config: provider})
+	}
+
+	return chain, nil
+}
+
+func containsProvider(chain []NamedProvider, name string) bool {
+	for _, entry := range chain {
+		if entry.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c *Config) FindProvider(name string) (ProviderConfig, bool) {
 	if c == nil {
 		return ProviderConfig{}, false
 	}
 
-	name = NormalizeProviderName(name)
+	name = c.ResolveProviderName(name)
 
 	if name == "" {
 		return ProviderConfig{}, false
@@ -209,7 +539,12 @@ func (c *Config) UpsertProvider(name string, provider ProviderConfig) string {
 		return ""
 	}
 
-	name = NormalizeProviderName(name)
+	resolved := c.ResolveProviderName(name)
+	if resolved == "" && ProviderType(name, provider) != "" {
+		resolved = strings.ToLower(strings.TrimSpace(name))
+	}
+
+	name = resolved
 	if name == "" {
 		return ""
 	}
@@ -231,6 +566,22 @@ func (c *Config) UpsertProvider(name string, provider ProviderConfig) string {
 			existing.Model = provider.Model
 		}
 
+		if provider.Host != "" {
+			existing.Host = provider.Host
+		}
+
+		if provider.BaseURL != "" {
+			existing.BaseURL = provider.BaseURL
+		}
+
+		if provider.Type != "" {
+			existing.Type = provider.Type
+		}
+
+		if provider.MaxRetries != nil {
+			existing.MaxRetries = provider.MaxRetries
+		}
+
 		c.Providers[name] = existing
 
 		return name
@@ -250,7 +601,7 @@ func RemoveProvider(cfg *Config, name string) bool {
 		return false
 	}
 
-	name = NormalizeProviderName(name)
+	name = cfg.ResolveProviderName(name)
 	if name == "" {
 		return false
 	}
@@ -261,10 +612,23 @@ func RemoveProvider(cfg *Config, name string) bool {
 
 	delete(cfg.Providers, name)
 
+	fallbacks := make([]string, 0, len(cfg.FallbackProviders))
+
+	for _, fallback := range cfg.FallbackProviders {
+		if cfg.ResolveProviderName(fallback) != name {
+			fallbacks = append(fallbacks, fallback)
+		}
+	}
+
+	cfg.FallbackProviders = fallbacks
+
 	if cfg.DefaultProvider == name {
 		cfg.DefaultProvider = ""
 
-		if len(cfg.Providers) > 0 {
+		if len(cfg.FallbackProviders) > 0 {
+			cfg.DefaultProvider = cfg.FallbackProviders[0]
+			cfg.FallbackProviders = cfg.FallbackProviders[1:]
+		} else if len(cfg.Providers) > 0 {
 			cfg.DefaultProvider = FirstProviderName(cfg.Providers)
 		}
 	}
@@ -272,23 +636,51 @@ func RemoveProvider(cfg *Config, name string) bool {
 	return true
 }
 
-func normalizeProviders(cfg *Config) {
+// normalizeProviders maps provider aliases to their canonical names and keeps
+// custom entries whose type is known. Custom entries without a type or with an
+// unknown type are an error.
+func normalizeProviders(cfg *Config) error {
 	if cfg == nil {
-		return
+		return nil
 	}
 
 	if cfg.Providers == nil {
 		cfg.Providers = make(map[string]ProviderConfig)
 
-		return
+		return nil
 	}
 
 	normalized := make(map[string]ProviderConfig)
 
-	for name, provider := range cfg.Providers {
+	// Aliases of a built-in provider are merged into one entry. The entry under
+	// the canonical name goes first so its values win, then aliases by name.
+	names := make([]string, 0, len(cfg.Providers))
+	for name := range cfg.Providers {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if ci, cj := isCanonicalProviderName(names[i]), isCanonicalProviderName(names[j]); ci != cj {
+			return ci
+		}
+
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		provider := cfg.Providers[name]
 		normalizedName := NormalizeProviderName(name)
+
 		if normalizedName == "" {
-			continue
+			if strings.TrimSpace(provider.Type) == "" {
+				return fmt.Errorf("provider %q is not built in and has no type", name)
+			}
+
+			if ProviderType(name, provider) == "" {
+				return fmt.Errorf("provider %q has unsupported type %q", name, provider.Type)
+			}
+
+			normalizedName = strings.ToLower(strings.TrimSpace(name))
 		}
 
 		normalizeSingleProvider(normalized, normalizedName, provider)
@@ -297,26 +689,71 @@ func normalizeProviders(cfg *Config) {
 	cfg.Providers = normalized
 
 	if cfg.DefaultProvider != "" {
-		cfg.DefaultProvider = NormalizeProviderName(cfg.DefaultProvider)
+		cfg.DefaultProvider = cfg.ResolveProviderName(cfg.DefaultProvider)
+	}
+
+	for i, fallback := range cfg.FallbackProviders {
+		if name := cfg.ResolveProviderName(fallback); name != "" {
+			cfg.FallbackProviders[i] = name
+		}
 	}
+
+	return nil
+}
+
+func isCanonicalProviderName(name string) bool {
+	return strings.ToLower(strings.TrimSpace(name)) == NormalizeProviderName(name)
 }
 
 func normalizeSingleProvider(normalized map[string]ProviderConfig, name string, provider ProviderConfig) {
+	if existing, ok := normalized[name]; ok {
+		mergeProvider(&existing, provider)
+		provider = existing
+	}
+
 	if provider.Model == "" {
 		provider.Model = DefaultModelForProvider(name)
 	}
 
-	if existing, ok := normalized[name]; ok {
-		switch {
-		case existing.APIKey == "" && provider.APIKey != "":
-			existing.APIKey = provider.APIKey
-		case existing.Model == "" && provider.Model != "":
-			existing.Model = provider.Model
-		}
+	normalized[name] = provider
+}
 
-		normalized[name] = existing
-	} else {
-		normalized[name] = provider
+// mergeProvider fills the unset fields of dst from src.
+func mergeProvider(dst *ProviderConfig, src ProviderConfig) {
+	if dst.Type == "" {
+		dst.Type = src.Type
+	}
+
+	if dst.APIKey == "" {
+		dst.APIKey = src.APIKey
+	}
+
+	if dst.APIKeyEnv == "" {
+		dst.APIKeyEnv = src.APIKeyEnv
+	}
+
+	if dst.APIKeyCmd == "" {
+		dst.APIKeyCmd = src.APIKeyCmd
+	}
+
+	if !dst.APIKeyKeyring {
+		dst.APIKeyKeyring = src.APIKeyKeyring
+	}
+
+	if dst.Model == "" {
+		dst.Model = src.Model
+	}
+
+	if dst.Host == "" {
+		dst.Host = src.Host
+	}
+
+	if dst.BaseURL == "" {
+		dst.BaseURL = src.BaseURL
+	}
+
+	if dst.MaxRetries == nil {
+		dst.MaxRetries = src.MaxRetries
 	}
 }
 
@@ -325,7 +762,7 @@ func applyEnvOverrides(cfg *Config) {
 		return
 	}
 
-	// 1. Specific provider overrides (AIDA_PROVIDER_<NAME>_API_KEY / AIDA_PROVIDER_<NAME>_MODEL)
+	// 1. Specific provider overrides (AIDA_PROVIDER_<NAME>_API_KEY / _MODEL / _HOST / _BASE_URL / _MAX_RETRIES)
 	applySpecificProviderEnvOverrides(cfg)
 
 	// 2. Default provider override
@@ -334,7 +771,23 @@ func applyEnvOverrides(cfg *Config) {
 		// But if it's still empty, we fallback to our logic.
 	}
 
-	cfg.DefaultProvider = NormalizeProviderName(cfg.DefaultProvider)
+	cfg.DefaultProvider = cfg.ResolveProviderName(cfg.DefaultProvider)
+}
+
+// providerEnvFields maps AIDA_PROVIDER_<NAME>_<FIELD> suffixes to the fields they set.
+var providerEnvFields = []struct {
+	suffix string
+	set    func(provider *ProviderConfig, value string)
+}{
+	{"_API_KEY", func(p *ProviderConfig, v string) { p.APIKey = v }},
+	{"_BASE_URL", func(p *ProviderConfig, v string) { p.BaseURL = v }},
+	{"_MODEL", func(p *ProviderConfig, v string) { p.Model = v }},
+	{"_HOST", func(p *ProviderConfig, v string) { p.Host = v }},
+	{"_MAX_RETRIES", func(p *ProviderConfig, v string) {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			p.MaxRetries = &n
+		}
+	}},
 }
 
 func applySpecificProviderEnvOverrides(cfg *Config) {
@@ -354,33 +807,51 @@ func applySpecificProviderEnvOverrides(cfg *Config) {
 		value := parts[1]
 		remaining := strings.TrimPrefix(key, prefix)
 
-		switch {
-		case strings.HasSuffix(remaining, "_API_KEY"):
-			name := NormalizeProviderName(strings.TrimSuffix(remaining, "_API_KEY"))
-			if name == "" {
+		for _, field := range providerEnvFields {
+			if !strings.HasSuffix(remaining, field.suffix) {
 				continue
 			}
 
-			if provider, ok := cfg.Providers[name]; ok {
-				provider.APIKey = value
-				cfg.Providers[name] = provider
-			} else {
-				cfg.UpsertProvider(name, ProviderConfig{APIKey: value})
-			}
-		case strings.HasSuffix(remaining, "_MODEL"):
-			name := NormalizeProviderName(strings.TrimSuffix(remaining, "_MODEL"))
+			name := cfg.envProviderName(strings.TrimSuffix(remaining, field.suffix))
 			if name == "" {
-				continue
+				break
 			}
 
 			if provider, ok := cfg.Providers[name]; ok {
-				provider.Model = value
+				field.set(&provider, value)
 				cfg.Providers[name] = provider
 			} else {
-				cfg.UpsertProvider(name, ProviderConfig{Model: value})
+				var provider ProviderConfig
+
+				field.set(&provider, value)
+				cfg.UpsertProvider(name, provider)
 			}
+
+			break
+		}
+	}
+}
+
+// envProviderName resolves the <NAME> part of an AIDA_PROVIDER_<NAME>_* variable.
+// Dashes can't appear in environment variable names, so the entry "my-proxy"
+// is matched by MY_PROXY.
+func (c *Config) envProviderName(input string) string {
+	if name := c.ResolveProviderName(input); name != "" {
+		return name
+	}
+
+	for name := range c.Providers {
+		if strings.EqualFold(providerEnvName(name), input) {
+			return name
 		}
 	}
+
+	return ""
+}
+
+// providerEnvName returns the <NAME> part of AIDA_PROVIDER_<NAME>_* for a provider entry.
+func providerEnvName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
 }
 
 func FirstProviderName(providers map[string]ProviderConfig) string {
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/metalagman/aida/internal/config"
 	"github.com/stretchr/testify/assert"
@@ -238,3 +239,553 @@ func TestSave_OverwriteYAML(t *testing.T) {
 	assert.Contains(t, string(data), "api_key")
 	assert.Contains(t, string(data), "yaml-key")
 }
+
+func TestLoad_OllamaWithoutConfig(t *testing.T) {
+	_ = setupTestHome(t)
+	t.Setenv("AIDA_DEFAULT_PROVIDER", "ollama")
+	t.Setenv("AIDA_PROVIDER_OLLAMA_HOST", "gpu-box:11434")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	name, provider, err := cfg.ActiveProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "ollama", name)
+	assert.Equal(t, "gpu-box:11434", provider.Host)
+	assert.Equal(t, "llama3.2", provider.Model)
+	assert.Empty(t, provider.APIKey)
+
+	delete(cfg.Providers, "ollama")
+
+	_, provider, err = cfg.ActiveProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "llama3.2", provider.Model)
+}
+
+func TestLoad_CustomProvider(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+default_provider = "groq"
+
+[provider.groq]
+type = "openai-compatible"
+api_key = "groq-key"
+model = "llama-3.3-70b-versatile"
+base_url = "https://api.groq.com/openai/v1"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+	t.Setenv("AIDA_PROVIDER_GROQ_BASE_URL", "http://localhost:8080/v1")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	name, provider, err := cfg.ActiveProvider()
+	require.NoError(t, err)
+	assert.Equal(t, "groq", name)
+	assert.Equal(t, "openai", config.ProviderType(name, provider))
+	assert.Equal(t, "http://localhost:8080/v1", provider.BaseURL)
+	assert.Equal(t, "groq-key", provider.APIKey)
+}
+
+func TestLoad_CustomProviderEnvWithDash(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+default_provider = "my-proxy"
+
+[provider.my-proxy]
+type = "openai-compatible"
+model = "gpt-4o"
+base_url = "https://proxy.example.com/v1"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+	t.Setenv("AIDA_PROVIDER_MY_PROXY_API_KEY", "proxy-key")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	provider, ok := cfg.FindProvider("my-proxy")
+	require.True(t, ok)
+	assert.Equal(t, "proxy-key", provider.APIKey)
+	assert.NotContains(t, cfg.Providers, "my_proxy")
+
+	trace, err := config.Trace("", nil)
+	require.NoError(t, err)
+
+	var source string
+
+	for _, setting := range trace {
+		if setting.Key == "provider.my-proxy.api_key" {
+			source = setting.Source
+		}
+	}
+
+	assert.Equal(t, "AIDA_PROVIDER_MY_PROXY_API_KEY", source)
+}
+
+func TestLoad_MergesProviderAliases(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+[provider.aistudio]
+model = "gemini-2.5-pro"
+
+[provider.google]
+api_key_env = "GEMINI_API_KEY"
+model = "gemini-2.0-flash"
+base_url = "https://gateway.example.com"
+max_retries = 5
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	require.Len(t, cfg.Providers, 1)
+
+	provider := cfg.Providers["aistudio"]
+	assert.Equal(t, "gemini-2.5-pro", provider.Model)
+	assert.Equal(t, "GEMINI_API_KEY", provider.APIKeyEnv)
+	assert.Equal(t, "https://gateway.example.com", provider.BaseURL)
+	assert.Equal(t, 5, provider.Retries())
+}
+
+func TestLoad_UnsupportedProviderType(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+[provider.custom]
+type = "mystery"
+base_url = "http://localhost:1234"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+
+	_, err := config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mystery")
+}
+
+func TestLoad_CustomProviderWithoutType(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+default_provider = "local"
+
+[provider.openai]
+api_key = "sk-openai"
+
+[provider.local]
+base_url = "http://localhost:1234"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+
+	_, err := config.Load()
+	require.ErrorContains(t, err, `provider "local" is not built in and has no type`)
+}
+
+func TestLoad_ProviderChain(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+default_provider = ["openai", "google", "ollama"]
+
+[provider.openai]
+api_key = "openai-key"
+
+[provider.aistudio]
+api_key = "gemini-key"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "openai", cfg.DefaultProvider)
+	assert.Equal(t, []string{"aistudio", "ollama"}, cfg.FallbackProviders)
+
+	chain, err := cfg.ProviderChain()
+	require.NoError(t, err)
+	require.Len(t, chain, 3)
+	assert.Equal(t, "openai", chain[0].Name)
+	assert.Equal(t, "gemini-key", chain[1].Config.APIKey)
+	assert.Equal(t, "llama3.2", chain[2].Config.Model)
+
+	_, err = config.Save(cfg)
+	require.NoError(t, err)
+
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "openai", cfg.DefaultProvider)
+	assert.Equal(t, []string{"aistudio", "ollama"}, cfg.FallbackProviders)
+
+	require.True(t, config.RemoveProvider(cfg, "openai"))
+	assert.Equal(t, "aistudio", cfg.DefaultProvider)
+	assert.Equal(t, []string{"ollama"}, cfg.FallbackProviders)
+
+	t.Setenv("AIDA_DEFAULT_PROVIDER", "aistudio, anthropic")
+
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "aistudio", cfg.DefaultProvider)
+	assert.Equal(t, []string{"anthropic"}, cfg.FallbackProviders)
+
+	_, err = cfg.ProviderChain()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "anthropic")
+}
+
+func TestLoad_MaxRetries(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+[provider.openai]
+api_key = "openai-key"
+max_retries = 5
+
+[provider.aistudio]
+api_key = "gemini-key"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+	t.Setenv("AIDA_PROVIDER_AISTUDIO_MAX_RETRIES", "0")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 5, cfg.Providers["openai"].Retries())
+	assert.Equal(t, 0, cfg.Providers["aistudio"].Retries())
+	assert.Equal(t, config.DefaultMaxRetries, config.ProviderConfig{}.Retries())
+}
+
+func TestLoad_Prices(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+[[prices]]
+model = "gemini-2.5-flash"
+input = 0.3
+output = 2.5
+
+[[prices]]
+provider = "groq"
+model = "llama-3.3-70b"
+input = 0.59
+output = 0.79
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	require.Len(t, cfg.Prices, 2)
+
+	price, ok := config.FindPrice(cfg.Prices, "aistudio", "gemini-2.5-flash")
+	require.True(t, ok)
+	assert.InDelta(t, 0.55, price.Cost(1_000_000, 100_000), 1e-9)
+
+	_, ok = config.FindPrice(cfg.Prices, "openai", "llama-3.3-70b")
+	assert.False(t, ok)
+}
+
+func TestLoad_CacheTTLFromEnv(t *testing.T) {
+	setupTestHome(t)
+	t.Setenv("AIDA_CACHE_TTL", "1h")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "1h", cfg.Cache.TTL)
+}
+
+func TestCacheConfigDuration(t *testing.T) {
+	tests := []struct {
+		ttl     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{ttl: "", want: config.DefaultCacheTTL},
+		{ttl: "30m", want: 30 * time.Minute},
+		{ttl: "0", want: 0},
+		{ttl: "-1h", wantErr: true},
+		{ttl: "a day", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ttl, func(t *testing.T) {
+			got, err := config.CacheConfig{TTL: tt.ttl}.Duration()
+			if tt.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoad_Environment(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantFacts []string
+		wantTools []string
+	}{
+		{name: "defaults", content: "", wantFacts: config.AllFacts, wantTools: config.DefaultTools},
+		{
+			name:      "allowlist",
+			content:   "[environment]\nfacts = [\"distro\", \"tools\"]\ntools = [\"rg\", \"fd\"]\n",
+			wantFacts: []string{config.FactDistro, config.FactTools},
+			wantTools: []string{"rg", "fd"},
+		},
+		{
+			name:      "nothing allowed",
+			content:   "[environment]\nfacts = []\n",
+			wantFacts: []string{},
+			wantTools: config.DefaultTools,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := setupTestHome(t)
+			configDir := filepath.Join(tmpDir, ".config", "aida")
+			require.NoError(t, os.MkdirAll(configDir, 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(tt.content), 0o644))
+
+			cfg, err := config.Load()
+			require.NoError(t, err)
+
+			facts, err := cfg.Environment.EnabledFacts()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFacts, facts)
+			assert.Equal(t, tt.wantTools, cfg.Environment.ToolList())
+		})
+	}
+}
+
+func TestEnvironmentConfigUnknownFact(t *testing.T) {
+	_, err := config.EnvironmentConfig{Facts: []string{"kernel"}}.EnabledFacts()
+	require.ErrorContains(t, err, `unknown environment fact "kernel"`)
+}
+
+func TestLoad_GitDetailFromEnv(t *testing.T) {
+	setupTestHome(t)
+	t.Setenv("AIDA_GIT_DETAIL", "off")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	level, err := cfg.Git.Level()
+	require.NoError(t, err)
+	assert.Equal(t, config.GitDetailOff, level)
+}
+
+func TestGitConfig(t *testing.T) {
+	level, err := config.GitConfig{}.Level()
+	require.NoError(t, err)
+	assert.Equal(t, config.GitDetailFiles, level)
+	assert.Equal(t, config.DefaultGitMaxFiles, config.GitConfig{}.FileLimit())
+	assert.Equal(t, 3, config.GitConfig{MaxFiles: 3}.FileLimit())
+
+	_, err = config.GitConfig{Detail: "everything"}.Level()
+	require.ErrorContains(t, err, `invalid git detail "everything"`)
+}
+
+func TestLoad_RedactionPatterns(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+[redaction]
+patterns = ['ACME-[0-9]{6}', '(?i)ticket=(\w+)']
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ACME-[0-9]{6}", `(?i)ticket=(\w+)`}, cfg.Redaction.Patterns)
+}
+
+func TestLoad_APIKeySources(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+[secrets]
+backend = "file"
+
+[provider.openai]
+api_key_cmd = "pass show openai"
+
+[provider.anthropic]
+api_key_env = "WORK_ANTHROPIC_KEY"
+
+[provider.aistudio]
+api_key_keyring = true
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "pass show openai", cfg.Providers["openai"].APIKeyCmd)
+	assert.Equal(t, "WORK_ANTHROPIC_KEY", cfg.Providers["anthropic"].APIKeyEnv)
+	assert.True(t, cfg.Providers["aistudio"].APIKeyKeyring)
+
+	backend, err := cfg.Secrets.Store()
+	require.NoError(t, err)
+	assert.Equal(t, config.SecretsBackendFile, backend)
+
+	cfg.Secrets.Backend = "vault"
+	_, err = cfg.Secrets.Store()
+	require.Error(t, err)
+}
+
+func TestLoad_Policy(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+[policy]
+deny_programs = ["shutdown", "mkfs*"]
+forbidden_paths = ["/etc"]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+
+	projectDir := filepath.Join(tmpDir, "project")
+	workDir := filepath.Join(projectDir, "sub", "dir")
+	require.NoError(t, os.MkdirAll(workDir, 0o755))
+
+	projectPolicy := `
+confirm_programs = ["git"]
+deny_patterns = ["re:rm -rf"]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".aida-policy.toml"), []byte(projectPolicy), 0o644))
+	t.Chdir(workDir)
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shutdown", "mkfs*"}, cfg.Policy.DenyPrograms)
+	assert.Equal(t, []string{"/etc"}, cfg.Policy.ForbiddenPaths)
+	assert.Equal(t, []string{"git"}, cfg.ProjectPolicy.ConfirmPrograms)
+	assert.Equal(t, filepath.Join(projectDir, ".aida-policy.toml"), cfg.ProjectPolicyPath)
+	assert.Len(t, cfg.PolicyLayers(), 2)
+
+	path, err := config.Save(cfg)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "deny_programs")
+	assert.NotContains(t, string(data), "confirm_programs")
+}
+
+func TestLoad_ProjectConfig(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configContent := `
+shell = "/bin/bash"
+
+[policy]
+deny_programs = ["shutdown"]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0o644))
+
+	projectDir := filepath.Join(tmpDir, "project")
+	workDir := filepath.Join(projectDir, "services", "api")
+	require.NoError(t, os.MkdirAll(workDir, 0o755))
+
+	projectConfig := `
+shell = "zsh"
+instructions = """
+This repo uses task, not make.
+"""
+
+[policy]
+confirm_programs = ["terraform"]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".aida.toml"), []byte(projectConfig), 0o644))
+	t.Chdir(workDir)
+	t.Setenv("AIDA_SHELL", "")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(projectDir, ".aida.toml"), cfg.ProjectPath)
+	assert.Equal(t, "zsh", cfg.Shell)
+	assert.Equal(t, "This repo uses task, not make.", cfg.Project.Instructions)
+	assert.Equal(t, []config.PolicyConfig{
+		{DenyPrograms: []string{"shutdown"}},
+		{ConfirmPrograms: []string{"terraform"}},
+	}, cfg.PolicyLayers())
+
+	base, err := config.LoadBase()
+	require.NoError(t, err)
+	assert.Equal(t, "/bin/bash", base.Shell)
+
+	t.Setenv("AIDA_SHELL", "/bin/dash")
+
+	cfg, err = config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/bin/dash", cfg.Shell)
+}
+
+func TestLoadProjectConfigRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "provider",
+			file:    ".aida.toml",
+			content: "[provider.openai]\napi_key = \"sk-project\"\n",
+			wantErr: `"provider" is not allowed`,
+		},
+		{
+			name:    "secrets in yaml",
+			file:    ".aida.yaml",
+			content: "secrets:\n  backend: file\n",
+			wantErr: `"secrets" is not allowed`,
+		},
+		{
+			name:    "relative shell",
+			file:    ".aida.toml",
+			content: "shell = \"./tools/sh\"\n",
+			wantErr: "must be a name on $PATH or an absolute path",
+		},
+		{
+			name:    "unknown absolute shell",
+			file:    ".aida.yml",
+			content: "shell: /tmp/not-a-shell\n",
+			wantErr: "/tmp/not-a-shell",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0o644))
+
+			_, _, err := config.LoadProjectConfig(dir)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
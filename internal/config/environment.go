@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Facts about the local machine that can be sent to the model.
+const (
+	FactDistro         = "distro"
+	FactShellVersion   = "shell_version"
+	FactCoreutils      = "coreutils"
+	FactPackageManager = "package_manager"
+	FactTools          = "tools"
+)
+
+// AllFacts lists every fact, in the order they are sent.
+var AllFacts = []string{FactDistro, FactShellVersion, FactCoreutils, FactPackageManager, FactTools}
+
+// DefaultTools are looked up on $PATH when environment.tools is unset.
+var DefaultTools = []string{
+	"git", "rg", "fd", "fdfind", "jq", "yq", "curl", "wget", "rsync", "gawk",
+	"python3", "node", "docker", "podman", "kubectl", "systemctl",
+}
+
+// EnvironmentConfig selects the facts about the local machine that are added
+// to the system instruction.
+type EnvironmentConfig struct {
+	// Facts is the allowlist of facts to collect and send. All of them are
+	// sent when unset; an empty list sends none.
+	Facts []string `mapstructure:"facts" toml:"facts,omitempty" yaml:"facts,omitempty"`
+	// Tools are the programs looked up on $PATH, DefaultTools when unset.
+	Tools []string `mapstructure:"tools" toml:"tools,omitempty" yaml:"tools,omitempty"`
+}
+
+// EnabledFacts returns the allowed facts, AllFacts when unset.
+func (c EnvironmentConfig) EnabledFacts() ([]string, error) {
+	if c.Facts == nil {
+		return AllFacts, nil
+	}
+
+	for _, fact := range c.Facts {
+		if !slices.Contains(AllFacts, fact) {
+			return nil, fmt.Errorf("unknown environment fact %q: use one of %v", fact, AllFacts)
+		}
+	}
+
+	return c.Facts, nil
+}
+
+// ToolList returns the programs to look up, DefaultTools when unset.
+func (c EnvironmentConfig) ToolList() []string {
+	if c.Tools == nil {
+		return DefaultTools
+	}
+
+	return c.Tools
+}
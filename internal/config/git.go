@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Levels of detail about the git repository sent to the model, each
+// including the previous one.
+const (
+	GitDetailOff    = "off"
+	GitDetailBranch = "branch"
+	GitDetailStatus = "status"
+	GitDetailFiles  = "files"
+)
+
+// DefaultGitMaxFiles is how many changed files are listed when git.max_files is unset.
+const DefaultGitMaxFiles = 10
+
+var gitDetails = []string{GitDetailOff, GitDetailBranch, GitDetailStatus, GitDetailFiles}
+
+// GitConfig controls what is told to the model about the git repository of
+// the current directory.
+type GitConfig struct {
+	// Detail is "off", "branch" for the branch and its upstream, "status" to
+	// add whether the work tree is dirty, or "files" to also list changed
+	// files. Defaults to "files".
+	Detail string `mapstructure:"detail" toml:"detail,omitempty" yaml:"detail,omitempty"`
+	// MaxFiles bounds the changed files listed, DefaultGitMaxFiles when unset.
+	MaxFiles int `mapstructure:"max_files" toml:"max_files,omitempty" yaml:"max_files,omitempty"`
+}
+
+// Level returns the configured detail, GitDetailFiles when unset.
+func (c GitConfig) Level() (string, error) {
+	if c.Detail == "" {
+		return GitDetailFiles, nil
+	}
+
+	if !slices.Contains(gitDetails, c.Detail) {
+		return "", fmt.Errorf("invalid git detail %q: use one of %v", c.Detail, gitDetails)
+	}
+
+	return c.Detail, nil
+}
+
+// FileLimit returns how many changed files to list.
+func (c GitConfig) FileLimit() int {
+	if c.MaxFiles <= 0 {
+		return DefaultGitMaxFiles
+	}
+
+	return c.MaxFiles
+}
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// secretKey is the last segment of config keys holding secrets.
+const secretKey = "api_key"
+
+// IsSecretKey reports whether the dotted config key holds a secret.
+func IsSecretKey(key string) bool {
+	return key == secretKey || strings.HasSuffix(key, "."+secretKey)
+}
+
+// MaskSecret hides a secret value for display.
+func MaskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return "********"
+}
+
+// Values returns the settings saved to the config file as dotted keys, such
+// as "mode" or "provider.openai.model". Empty values are left out and lists
+// are joined with ", ".
+func (c *Config) Values() (map[string]string, error) {
+	return valuesOf(c)
+}
+
+// valuesOf flattens anything saved with toml tags into dotted keys.
+func valuesOf(v any) (map[string]string, error) {
+	data, err := toml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+
+	var tree map[string]any
+
+	if err := toml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	values := make(map[string]string)
+	flatten(values, "", tree)
+
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, value any) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			flatten(values, joinKey(prefix, key), item)
+		}
+	case []any:
+		items := make([]string, 0, len(value))
+
+		for i, item := range value {
+			if _, ok := item.(map[string]any); ok {
+				flatten(values, joinKey(prefix, strconv.Itoa(i)), item)
+
+				continue
+			}
+
+			items = append(items, fmt.Sprint(item))
+		}
+
+		if len(items) > 0 {
+			values[prefix] = strings.Join(items, ", ")
+		}
+	case string:
+		if value != "" {
+			values[prefix] = value
+		}
+	default:
+		values[prefix] = fmt.Sprint(value)
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+// SortedKeys returns the keys of values in order.
+func SortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Set sets a dotted config key, parsing value for the type of the key. Lists
+// are given comma separated. Entries of provider and profile tables are
+// created as needed.
+func (c *Config) Set(key, value string) error {
+	return c.walk(key, func(field reflect.Value) error {
+		return setField(key, field, value)
+	}, false)
+}
+
+// Unset clears a dotted config key. A key naming a provider or profile entry
+// removes the whole entry.
+func (c *Config) Unset(key string) error {
+	return c.walk(key, func(field reflect.Value) error {
+		field.SetZero()
+
+		return nil
+	}, true)
+}
+
+// walk finds the field of key and calls fn with it. Map entries are copied
+// out, changed and stored back; with deleteEntry, a key ending at a map entry
+// deletes it instead.
+func (c *Config) walk(key string, fn func(reflect.Value) error, deleteEntry bool) error {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(key)), ".")
+
+	return walkValue(key, reflect.ValueOf(c).Elem(), parts, fn, deleteEntry)
+}
+
+func walkValue(key string, value reflect.Value, parts []string, fn func(reflect.Value) error, deleteEntry bool) error {
+	if len(parts) == 0 {
+		if value.Kind() == reflect.Struct || value.Kind() == reflect.Map {
+			return fmt.Errorf("%q is a table: set one of its keys", key)
+		}
+
+		return fn(value)
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		field, ok := fieldByTag(value, parts[0])
+		if !ok {
+			return fmt.Errorf("unknown config key %q", key)
+		}
+
+		return walkValue(key, field, parts[1:], fn, deleteEntry)
+	case reflect.Map:
+		name := reflect.ValueOf(parts[0])
+		existing := value.MapIndex(name)
+
+		switch {
+		case deleteEntry && !existing.IsValid():
+			return nil
+		case deleteEntry && len(parts) == 1:
+			value.SetMapIndex(name, reflect.Value{})
+
+			return nil
+		case value.IsNil():
+			value.Set(reflect.MakeMap(value.Type()))
+		}
+
+		entry := reflect.New(value.Type().Elem()).Elem()
+		if existing.IsValid() {
+			entry.Set(existing)
+		}
+
+		if err := walkValue(key, entry, parts[1:], fn, deleteEntry); err != nil {
+			return err
+		}
+
+		value.SetMapIndex(name, entry)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown config key %q", key)
+	}
+}
+
+// fieldByTag returns the struct field saved under the toml name.
+func fieldByTag(value reflect.Value, name string) (reflect.Value, bool) {
+	for i := range value.NumField() {
+		tag, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("toml"), ",")
+		if tag == name && tag != "-" {
+			return value.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func setField(key string, field reflect.Value, value string) error {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a boolean", key, value)
+		}
+
+		field.SetBool(b)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number", key, value)
+		}
+
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number", key, value)
+		}
+
+		field.Set(reflect.ValueOf(&n))
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		field.Set(reflect.ValueOf(splitList(value)))
+	default:
+		return fmt.Errorf("%s cannot be set from the command line: edit the config file", key)
+	}
+
+	return nil
+}
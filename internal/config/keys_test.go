@@ -0,0 +1,48 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSetAndUnset(t *testing.T) {
+	cfg := &config.Config{Mode: "confirm"}
+
+	require.NoError(t, cfg.Set("mode", "yolo"))
+	require.NoError(t, cfg.Set("max_fix_attempts", "3"))
+	require.NoError(t, cfg.Set("provider.openai.model", "gpt-4o"))
+	require.NoError(t, cfg.Set("provider.openai.max_retries", "4"))
+	require.NoError(t, cfg.Set("provider.openai.api_key_keyring", "true"))
+	require.NoError(t, cfg.Set("policy.deny_programs", "shutdown, mkfs*"))
+	require.NoError(t, cfg.Set("profile.offline.provider", "ollama"))
+
+	assert.Equal(t, "yolo", cfg.Mode)
+	assert.Equal(t, 3, cfg.MaxFixAttempts)
+	assert.Equal(t, "gpt-4o", cfg.Providers["openai"].Model)
+	assert.Equal(t, 4, cfg.Providers["openai"].Retries())
+	assert.True(t, cfg.Providers["openai"].APIKeyKeyring)
+	assert.Equal(t, []string{"shutdown", "mkfs*"}, cfg.Policy.DenyPrograms)
+	assert.Equal(t, "ollama", cfg.Profiles["offline"].Provider)
+
+	values, err := cfg.Values()
+	require.NoError(t, err)
+	assert.Equal(t, "shutdown, mkfs*", values["policy.deny_programs"])
+	assert.Equal(t, "4", values["provider.openai.max_retries"])
+
+	require.NoError(t, cfg.Unset("provider.openai.max_retries"))
+	assert.Equal(t, config.DefaultMaxRetries, cfg.Providers["openai"].Retries())
+
+	require.NoError(t, cfg.Unset("provider.anthropic.model"))
+	assert.NotContains(t, cfg.Providers, "anthropic")
+
+	require.NoError(t, cfg.Unset("profile.offline"))
+	assert.Empty(t, cfg.Profiles)
+
+	require.ErrorContains(t, cfg.Set("max_fix_attempts", "many"), "not a number")
+	require.ErrorContains(t, cfg.Set("unknown", "x"), `unknown config key "unknown"`)
+	require.ErrorContains(t, cfg.Set("policy", "x"), "is a table")
+	require.ErrorContains(t, cfg.Set("prices", "x"), "edit the config file")
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Origins of effective settings, see Trace.
+const (
+	OriginDefault = "default"
+	OriginFile    = "file"
+	OriginEnv     = "env"
+	OriginDotenv  = "dotenv"
+	OriginProfile = "profile"
+	OriginProject = "project"
+	OriginFlag    = "flag"
+)
+
+// Setting is one effective config key and where its value came from.
+type Setting struct {
+	Key   string
+	Value string
+	// Origin is one of the Origin constants.
+	Origin string
+	// Source names the file, environment variable, profile or flag the value
+	// came from, if any.
+	Source string
+}
+
+// Trace loads the config like LoadProfile and returns every effective
+// setting with its origin, sorted by key. fromDotenv reports whether an
+// environment variable was set from a .env file. Secrets are masked.
+func Trace(profile string, fromDotenv func(name string) bool) ([]Setting, error) {
+	file, v, err := load(false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := LoadBase()
+	if err != nil {
+		return nil, err
+	}
+
+	wd, _ := os.Getwd()
+
+	name, source, err := cfg.SelectProfile(profile, wd)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults, err := (&Config{Mode: "confirm", Shell: "/bin/sh"}).Values()
+	if err != nil {
+		return nil, err
+	}
+
+	fromFile, err := file.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := cfg.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		if err := cfg.ApplyProfile(name, source); err != nil {
+			return nil, err
+		}
+	}
+
+	profiled, err := cfg.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.applyProject()
+
+	effective, err := cfg.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	path := v.ConfigFileUsed()
+	settings := make([]Setting, 0, len(effective))
+
+	for _, key := range SortedKeys(effective) {
+		setting := Setting{Key: key, Value: effective[key], Origin: OriginDefault}
+
+		switch {
+		case effective[key] != profiled[key]:
+			setting.Origin, setting.Source = OriginProject, cfg.ProjectPath
+		case profiled[key] != base[key]:
+			setting.Origin, setting.Source = OriginProfile, name
+		case envName(key) != "":
+			setting.Origin, setting.Source = envOrigin(envName(key), fromDotenv), envName(key)
+		case base[key] != fromFile[key]:
+			setting.Origin = OriginEnv
+		case v.InConfig(key) || fromFile[key] != defaults[key]:
+			setting.Origin, setting.Source = OriginFile, path
+		}
+
+		settings = append(settings, setting)
+	}
+
+	if name != "" {
+		settings = append(settings, profileSetting(name, source, path, fromDotenv))
+	}
+
+	settings = append(settings, layerSettings("profile_policy", OriginProfile, name, cfg.ProfilePolicy)...)
+	settings = append(settings, layerSettings("project", OriginProject, cfg.ProjectPath, cfg.Project)...)
+	settings = append(settings, layerSettings("project_policy", OriginProject, cfg.ProjectPolicyPath, cfg.ProjectPolicy)...)
+
+	for i := range settings {
+		if IsSecretKey(settings[i].Key) {
+			settings[i].Value = MaskSecret(settings[i].Value)
+		}
+	}
+
+	return settings, nil
+}
+
+// profileSetting describes where the active profile was selected.
+func profileSetting(name, source, path string, fromDotenv func(string) bool) Setting {
+	setting := Setting{Key: "profile", Value: name}
+
+	switch source {
+	case ProfileSourceFlag:
+		setting.Origin, setting.Source = OriginFlag, source
+	case ProfileSourceEnv:
+		setting.Origin, setting.Source = envOrigin(source, fromDotenv), source
+	case ProfileSourceDefault:
+		setting.Origin, setting.Source = OriginFile, path
+	default:
+		setting.Origin, setting.Source = OriginProject, source
+	}
+
+	return setting
+}
+
+// layerSettings lists the settings of a layer kept apart from the top-level
+// config, such as a project file, under prefix.
+func layerSettings(prefix, origin, source string, layer any) []Setting {
+	if source == "" {
+		return nil
+	}
+
+	values, err := valuesOf(layer)
+	if err != nil {
+		return nil
+	}
+
+	settings := make([]Setting, 0, len(values))
+
+	for _, key := range SortedKeys(values) {
+		settings = append(settings, Setting{
+			Key: prefix + "." + key, Value: values[key], Origin: origin, Source: source,
+		})
+	}
+
+	return settings
+}
+
+// envName returns the environment variable overriding key, if it is set.
+func envName(key string) string {
+	parts := strings.Split(key, ".")
+
+	var name string
+
+	switch {
+	case key == "fallback_providers":
+		name = "AIDA_DEFAULT_PROVIDER"
+	case len(parts) == 3 && parts[0] == "provider":
+		name = "AIDA_PROVIDER_" + providerEnvName(parts[1]) + "_" + strings.ToUpper(parts[2])
+	default:
+		name = "AIDA_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	}
+
+	if os.Getenv(name) == "" {
+		return ""
+	}
+
+	return name
+}
+
+func envOrigin(name string, fromDotenv func(string) bool) string {
+	if fromDotenv != nil && fromDotenv(name) {
+		return OriginDotenv
+	}
+
+	return OriginEnv
+}
@@ -0,0 +1,74 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+
+	configPath := filepath.Join(configDir, "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+mode = "confirm"
+
+[provider.openai]
+api_key = "sk-secret"
+model = "gpt-4o"
+
+[profile.fast]
+mode = "yolo"
+
+[profile.fast.policy]
+deny_programs = ["reboot"]
+`), 0o644))
+
+	projectDir := filepath.Join(tmpDir, "project")
+	require.NoError(t, os.MkdirAll(projectDir, 0o755))
+
+	projectPath := filepath.Join(projectDir, ".aida.toml")
+	require.NoError(t, os.WriteFile(projectPath, []byte(`shell = "bash"
+instructions = "Use task, not make."
+`), 0o644))
+	t.Chdir(projectDir)
+
+	t.Setenv("AIDA_PROFILE", "")
+	t.Setenv("AIDA_SHELL", "")
+	t.Setenv("AIDA_GIT_DETAIL", "branch")
+	t.Setenv("AIDA_MAX_FIX_ATTEMPTS", "3")
+
+	settings, err := config.Trace("fast", func(name string) bool {
+		return name == "AIDA_MAX_FIX_ATTEMPTS"
+	})
+	require.NoError(t, err)
+
+	traced := make(map[string]config.Setting, len(settings))
+	for _, setting := range settings {
+		traced[setting.Key] = setting
+	}
+
+	for _, want := range []config.Setting{
+		{Key: "mode", Value: "yolo", Origin: config.OriginProfile, Source: "fast"},
+		{Key: "shell", Value: "bash", Origin: config.OriginProject, Source: projectPath},
+		{Key: "git.detail", Value: "branch", Origin: config.OriginEnv, Source: "AIDA_GIT_DETAIL"},
+		{Key: "max_fix_attempts", Value: "3", Origin: config.OriginDotenv, Source: "AIDA_MAX_FIX_ATTEMPTS"},
+		{Key: "provider.openai.model", Value: "gpt-4o", Origin: config.OriginFile, Source: configPath},
+		{Key: "provider.openai.api_key", Value: "********", Origin: config.OriginFile, Source: configPath},
+		{Key: "default_provider", Value: "openai", Origin: config.OriginFile, Source: configPath},
+		{Key: "profile", Value: "fast", Origin: config.OriginFlag, Source: config.ProfileSourceFlag},
+		{Key: "profile_policy.deny_programs", Value: "reboot", Origin: config.OriginProfile, Source: "fast"},
+		{
+			Key: "project.instructions", Value: "Use task, not make.",
+			Origin: config.OriginProject, Source: projectPath,
+		},
+	} {
+		assert.Equal(t, want, traced[want.Key], want.Key)
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectPolicyFiles are the project-local policy file names, in lookup order.
+var ProjectPolicyFiles = []string{".aida-policy.toml", ".aida-policy.yaml", ".aida-policy.yml"}
+
+// PolicyConfig lists rules that generated commands are checked against before they run.
+//
+// Programs are matched by base name and may use glob wildcards ("mkfs*").
+// Patterns are matched against the whole command: globs by default, or
+// regular expressions when prefixed with "re:". Forbidden paths are globs;
+// commands writing to or deleting anything under them are refused.
+type PolicyConfig struct {
+	//nolint:lll
+	AllowPrograms []string `mapstructure:"allow_programs" toml:"allow_programs,omitempty" yaml:"allow_programs,omitempty"`
+	//nolint:lll
+	DenyPrograms []string `mapstructure:"deny_programs" toml:"deny_programs,omitempty" yaml:"deny_programs,omitempty"`
+	//nolint:lll
+	ConfirmPrograms []string `mapstructure:"confirm_programs" toml:"confirm_programs,omitempty" yaml:"confirm_programs,omitempty"`
+	//nolint:lll
+	DenyPatterns []string `mapstructure:"deny_patterns" toml:"deny_patterns,omitempty" yaml:"deny_patterns,omitempty"`
+	//nolint:lll
+	ConfirmPatterns []string `mapstructure:"confirm_patterns" toml:"confirm_patterns,omitempty" yaml:"confirm_patterns,omitempty"`
+	//nolint:lll
+	ForbiddenPaths []string `mapstructure:"forbidden_paths" toml:"forbidden_paths,omitempty" yaml:"forbidden_paths,omitempty"`
+}
+
+// Empty reports whether the policy has no rules.
+func (p PolicyConfig) Empty() bool {
+	return len(p.AllowPrograms) == 0 &&
+		len(p.DenyPrograms) == 0 &&
+		len(p.ConfirmPrograms) == 0 &&
+		len(p.DenyPatterns) == 0 &&
+		len(p.ConfirmPatterns) == 0 &&
+		len(p.ForbiddenPaths) == 0
+}
+
+// PolicyLayers returns the user policy followed by the policy of the active
+// profile, the project-local policy and the policy of the project config,
+// skipping empty ones. A command must pass every layer.
+func (c *Config) PolicyLayers() []PolicyConfig {
+	if c == nil {
+		return nil
+	}
+
+	var layers []PolicyConfig
+
+	for _, layer := range []PolicyConfig{c.Policy, c.ProfilePolicy, c.ProjectPolicy, c.Project.Policy} {
+		if !layer.Empty() {
+			layers = append(layers, layer)
+		}
+	}
+
+	return layers
+}
+
+// LoadProjectPolicy finds the nearest project-local policy file, starting at
+// dir and walking up to the filesystem root. It returns the path of the file
+// that was read, or an empty path when there is none.
+func LoadProjectPolicy(dir string) (PolicyConfig, string, error) {
+	path := findUpward(dir, ProjectPolicyFiles...)
+	if path == "" {
+		return PolicyConfig{}, "", nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PolicyConfig{}, "", fmt.Errorf("read project policy: %w", err)
+	}
+
+	var policy PolicyConfig
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &policy)
+	default:
+		err = toml.Unmarshal(data, &policy)
+	}
+
+	if err != nil {
+		return PolicyConfig{}, "", fmt.Errorf("parse project policy %s: %w", path, err)
+	}
+
+	return policy, path, nil
+}
+
+// findUpward returns the first existing file with one of the names in dir or
+// any of its parents.
+func findUpward(dir string, names ...string) string {
+	if dir == "" {
+		return ""
+	}
+
+	dir = filepath.Clean(dir)
+
+	for {
+		for _, name := range names {
+			candidate := filepath.Join(dir, name)
+
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+
+		dir = parent
+	}
+}
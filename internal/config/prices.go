@@ -0,0 +1,47 @@
+package config
+
+import "strings"
+
+// Price is what a model costs per million tokens, in US dollars. Prices are
+// only used to estimate spending; aida ships none, so costs are reported for
+// the models listed in the config.
+type Price struct {
+	// Provider limits the price to one provider entry. Empty matches any.
+	Provider string `mapstructure:"provider" toml:"provider,omitempty" yaml:"provider,omitempty"`
+	Model    string `mapstructure:"model"    toml:"model"              yaml:"model"`
+	// Input is the price of a million prompt tokens.
+	Input float64 `mapstructure:"input" toml:"input" yaml:"input"`
+	// Output is the price of a million completion tokens.
+	Output float64 `mapstructure:"output" toml:"output" yaml:"output"`
+}
+
+const tokensPerPriceUnit = 1_000_000
+
+// Cost returns the price of the given token counts.
+func (p Price) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Input + float64(completionTokens)*p.Output) / tokensPerPriceUnit
+}
+
+// FindPrice returns the price of model as served by provider. A price naming
+// the provider wins over one that matches any provider.
+func FindPrice(prices []Price, provider, model string) (Price, bool) {
+	var (
+		found Price
+		ok    bool
+	)
+
+	for _, price := range prices {
+		if !strings.EqualFold(price.Model, model) {
+			continue
+		}
+
+		switch {
+		case strings.EqualFold(price.Provider, provider):
+			return price, true
+		case price.Provider == "" && !ok:
+			found, ok = price, true
+		}
+	}
+
+	return found, ok
+}
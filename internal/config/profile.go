@@ -0,0 +1,193 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ProjectProfileFile names the profile to use inside a project. It holds just
+// the profile name and is looked up from the current directory upwards.
+const ProjectProfileFile = ".aida-profile"
+
+// Sources a profile can be selected from, in order of precedence.
+const (
+	ProfileSourceFlag    = "--profile"
+	ProfileSourceEnv     = "AIDA_PROFILE"
+	ProfileSourceDefault = "default_profile"
+)
+
+// Profile is a named set of settings applied over the top-level ones, e.g. a
+// work gateway, a personal key or an offline model. Unset fields keep the
+// top-level value.
+type Profile struct {
+	// Provider is the provider to use, or a comma-separated list whose first
+	// entry is the default provider and the rest its fallbacks. It replaces
+	// the fallbacks of the top-level config.
+	Provider string `mapstructure:"provider" toml:"provider,omitempty" yaml:"provider,omitempty"`
+	// Model is the model of that provider.
+	Model string `mapstructure:"model" toml:"model,omitempty" yaml:"model,omitempty"`
+	Mode  string `mapstructure:"mode" toml:"mode,omitempty" yaml:"mode,omitempty"`
+	Shell string `mapstructure:"shell" toml:"shell,omitempty" yaml:"shell,omitempty"`
+	// Policy is checked as another layer on top of the top-level policy, so it
+	// can add rules but not lift them.
+	Policy PolicyConfig `mapstructure:"policy" toml:"policy,omitempty" yaml:"policy,omitempty"`
+}
+
+// readProfiles reads the profile tables of the config file. AIDA_PROFILE would
+// shadow them in a viper instance reading the environment, so they are read
+// without it.
+func readProfiles(path string) (map[string]Profile, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var profiles map[string]Profile
+
+	if err := v.UnmarshalKey("profile", &profiles); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// ProfileNames returns the names of the defined profiles, sorted.
+func (c *Config) ProfileNames() []string {
+	if c == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// SelectProfile returns the name of the profile to apply and where it was
+// selected. The first of these wins:
+//  1. name, from the --profile flag
+//  2. the AIDA_PROFILE environment variable
+//  3. the nearest ProjectProfileFile, starting at dir
+//  4. default_profile in the config file
+//
+// An empty name means no profile is applied.
+func (c *Config) SelectProfile(name, dir string) (string, string, error) {
+	if name = normalizeProfileName(name); name != "" {
+		return name, ProfileSourceFlag, nil
+	}
+
+	if name = normalizeProfileName(os.Getenv(ProfileSourceEnv)); name != "" {
+		return name, ProfileSourceEnv, nil
+	}
+
+	if path := findUpward(dir, ProjectProfileFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("read project profile: %w", err)
+		}
+
+		if name = normalizeProfileName(string(data)); name != "" {
+			return name, path, nil
+		}
+	}
+
+	if name = normalizeProfileName(c.DefaultProfile); name != "" {
+		return name, ProfileSourceDefault, nil
+	}
+
+	return "", "", nil
+}
+
+// ApplyProfile applies the named profile over the top-level settings.
+// Settings given by environment variables are left alone, as they take
+// precedence over profiles.
+func (c *Config) ApplyProfile(name, source string) error {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q (from %s) is not defined", name, source)
+	}
+
+	c.ActiveProfile = name
+	c.ActiveProfileSource = source
+
+	if profile.Provider != "" && !envSet("default_provider") {
+		chain := splitList(profile.Provider)
+		if len(chain) > 0 {
+			c.DefaultProvider = c.ResolveProviderName(chain[0])
+			if c.DefaultProvider == "" {
+				return fmt.Errorf("profile %q: unsupported provider %q", name, chain[0])
+			}
+
+			c.FallbackProviders = nil
+			if len(chain) > 1 {
+				c.FallbackProviders = chain[1:]
+			}
+		}
+	}
+
+	if profile.Model != "" {
+		provider := c.DefaultProvider
+		if provider == "" {
+			provider = FirstProviderName(c.Providers)
+		}
+
+		if c.UpsertProvider(provider, ProviderConfig{Model: profile.Model}) == "" {
+			return fmt.Errorf("profile %q sets a model but no provider is configured", name)
+		}
+	}
+
+	if profile.Mode != "" && !envSet("mode") {
+		c.Mode = profile.Mode
+	}
+
+	if profile.Shell != "" && !envSet("shell") {
+		c.Shell = profile.Shell
+	}
+
+	c.ProfilePolicy = profile.Policy
+
+	// AIDA_PROVIDER_<NAME>_MODEL and friends win over the profile model.
+	applySpecificProviderEnvOverrides(c)
+
+	return nil
+}
+
+// envSet reports whether the environment variable bound to key is set.
+func envSet(key string) bool {
+	return os.Getenv("AIDA_"+strings.ToUpper(key)) != ""
+}
+
+func normalizeProfileName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func splitList(value string) []string {
+	var items []string
+
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,235 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profilesConfig = `
+mode = "confirm"
+shell = "/bin/bash"
+default_provider = ["openai", "aistudio"]
+default_profile = "personal"
+
+[policy]
+deny_programs = ["shutdown"]
+
+[provider.openai]
+api_key = "sk-openai"
+model = "gpt-4o"
+
+[provider.aistudio]
+api_key = "gemini-key"
+
+[provider.gateway]
+type = "openai-compatible"
+base_url = "https://llm.corp.example/v1"
+api_key = "corp-key"
+
+[profile.work]
+provider = "gateway"
+model = "corp-large"
+mode = "dry-run"
+
+[profile.work.policy]
+deny_programs = ["kubectl"]
+
+[profile.personal]
+model = "gpt-4.1"
+
+[profile.offline]
+provider = "ollama"
+model = "qwen2.5-coder"
+shell = "/bin/zsh"
+`
+
+func TestLoadProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		flag        string
+		env         map[string]string
+		projectFile string
+		wantProfile string
+		wantSource  string
+		wantName    string
+		wantModel   string
+		wantMode    string
+		wantShell   string
+		wantDeny    []string
+		fallbacks   []string
+	}{
+		{
+			name:        "default profile",
+			wantProfile: "personal",
+			wantSource:  config.ProfileSourceDefault,
+			wantName:    "openai",
+			wantModel:   "gpt-4.1",
+			wantMode:    "confirm",
+			wantShell:   "/bin/bash",
+			wantDeny:    []string{"shutdown"},
+			fallbacks:   []string{"aistudio"},
+		},
+		{
+			name:        "flag",
+			flag:        "work",
+			env:         map[string]string{"AIDA_PROFILE": "offline"},
+			wantProfile: "work",
+			wantSource:  config.ProfileSourceFlag,
+			wantName:    "gateway",
+			wantModel:   "corp-large",
+			wantMode:    "dry-run",
+			wantShell:   "/bin/bash",
+			wantDeny:    []string{"shutdown", "kubectl"},
+		},
+		{
+			name:        "environment",
+			env:         map[string]string{"AIDA_PROFILE": "Offline"},
+			projectFile: "work",
+			wantProfile: "offline",
+			wantSource:  config.ProfileSourceEnv,
+			wantName:    "ollama",
+			wantModel:   "qwen2.5-coder",
+			wantMode:    "confirm",
+			wantShell:   "/bin/zsh",
+			wantDeny:    []string{"shutdown"},
+		},
+		{
+			name:        "project file",
+			projectFile: "work\n",
+			wantProfile: "work",
+			wantName:    "gateway",
+			wantModel:   "corp-large",
+			wantMode:    "dry-run",
+			wantShell:   "/bin/bash",
+			wantDeny:    []string{"shutdown", "kubectl"},
+		},
+		{
+			name: "environment variables win over the profile",
+			flag: "work",
+			env: map[string]string{
+				"AIDA_MODE":                   "yolo",
+				"AIDA_PROVIDER_GATEWAY_MODEL": "corp-small",
+			},
+			wantProfile: "work",
+			wantSource:  config.ProfileSourceFlag,
+			wantName:    "gateway",
+			wantModel:   "corp-small",
+			wantMode:    "yolo",
+			wantShell:   "/bin/bash",
+			wantDeny:    []string{"shutdown", "kubectl"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := setupTestHome(t)
+			configDir := filepath.Join(tmpDir, ".config", "aida")
+			require.NoError(t, os.MkdirAll(configDir, 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(profilesConfig), 0o644))
+
+			t.Setenv("AIDA_PROFILE", "")
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			projectDir := filepath.Join(tmpDir, "project")
+			workDir := filepath.Join(projectDir, "src")
+			require.NoError(t, os.MkdirAll(workDir, 0o755))
+
+			source := tt.wantSource
+			if tt.projectFile != "" {
+				path := filepath.Join(projectDir, config.ProjectProfileFile)
+				require.NoError(t, os.WriteFile(path, []byte(tt.projectFile), 0o644))
+
+				if source == "" {
+					source = path
+				}
+			}
+
+			t.Chdir(workDir)
+
+			cfg, err := config.LoadProfile(tt.flag)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantProfile, cfg.ActiveProfile)
+			assert.Equal(t, source, cfg.ActiveProfileSource)
+
+			name, provider, err := cfg.ActiveProvider()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantModel, provider.Model)
+			assert.Equal(t, tt.fallbacks, cfg.FallbackProviders)
+			assert.Equal(t, tt.wantMode, cfg.Mode)
+			assert.Equal(t, tt.wantShell, cfg.Shell)
+			var deny []string
+			for _, layer := range cfg.PolicyLayers() {
+				deny = append(deny, layer.DenyPrograms...)
+			}
+
+			assert.Equal(t, tt.wantDeny, deny)
+		})
+	}
+}
+
+func TestProfilePolicyKeepsTopLevelRules(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(profilesConfig+`
+[profile.loose.policy]
+allow_programs = ["shutdown", "ls"]
+`), 0o644))
+	t.Setenv("AIDA_PROFILE", "")
+	t.Chdir(tmpDir)
+
+	for _, name := range []string{"work", "loose"} {
+		cfg, err := config.LoadProfile(name)
+		require.NoError(t, err)
+
+		p, err := policy.New(cfg.PolicyLayers()...)
+		require.NoError(t, err)
+
+		assert.Equal(t, policy.ActionDeny, p.Check("shutdown -h now").Action, "profile %s", name)
+	}
+
+	cfg, err := config.LoadProfile("work")
+	require.NoError(t, err)
+
+	p, err := policy.New(cfg.PolicyLayers()...)
+	require.NoError(t, err)
+	assert.Equal(t, policy.ActionDeny, p.Check("kubectl delete pod x").Action)
+}
+
+func TestLoadBaseIgnoresProfiles(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(profilesConfig), 0o644))
+	t.Setenv("AIDA_PROFILE", "work")
+
+	cfg, err := config.LoadBase()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.ActiveProfile)
+	assert.Equal(t, "openai", cfg.DefaultProvider)
+	assert.Equal(t, "gpt-4o", cfg.Providers["openai"].Model)
+	assert.Equal(t, "confirm", cfg.Mode)
+	assert.Equal(t, []string{"offline", "personal", "work"}, cfg.ProfileNames())
+}
+
+func TestLoadProfileUndefined(t *testing.T) {
+	tmpDir := setupTestHome(t)
+	configDir := filepath.Join(tmpDir, ".config", "aida")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(profilesConfig), 0o644))
+	t.Setenv("AIDA_PROFILE", "")
+
+	_, err := config.LoadProfile("staging")
+	require.ErrorContains(t, err, `profile "staging" (from --profile) is not defined`)
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFiles are the project config file names, in lookup order.
+var ProjectFiles = []string{".aida.toml", ".aida.yaml", ".aida.yml"}
+
+// projectKeys are the settings a project config may hold. Providers, API keys
+// and the secret store stay in the user config, so a checked-out repository
+// cannot redirect requests or read keys.
+var projectKeys = []string{"shell", "instructions", "policy"}
+
+// shellsFile lists the login shells of the system.
+const shellsFile = "/etc/shells"
+
+// ProjectConfig holds the settings of a project-local config file, merged
+// over the user config.
+type ProjectConfig struct {
+	// Shell pins the shell commands run with, e.g. "bash". It is a name
+	// looked up on $PATH or an absolute path listed in /etc/shells.
+	Shell string `mapstructure:"shell" toml:"shell,omitempty" yaml:"shell,omitempty"`
+	// Instructions are added to the system instruction, e.g. "This repo uses
+	// task, not make."
+	Instructions string `mapstructure:"instructions" toml:"instructions,omitempty" yaml:"instructions,omitempty"`
+	// Policy adds rules checked in addition to the user policy.
+	Policy PolicyConfig `mapstructure:"policy" toml:"policy,omitempty" yaml:"policy,omitempty"`
+}
+
+// LoadProjectConfig finds the nearest project config file, starting at dir and
+// walking up to the filesystem root. It returns the path of the file that was
+// read, or an empty path when there is none.
+func LoadProjectConfig(dir string) (ProjectConfig, string, error) {
+	path := findUpward(dir, ProjectFiles...)
+	if path == "" {
+		return ProjectConfig{}, "", nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ProjectConfig{}, "", fmt.Errorf("read project config: %w", err)
+	}
+
+	unmarshal := toml.Unmarshal
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		unmarshal = yaml.Unmarshal
+	}
+
+	var keys map[string]any
+
+	if err := unmarshal(data, &keys); err != nil {
+		return ProjectConfig{}, "", fmt.Errorf("parse project config %s: %w", path, err)
+	}
+
+	for key := range keys {
+		if !slices.Contains(projectKeys, key) {
+			return ProjectConfig{}, "", fmt.Errorf("project config %s: %q is not allowed, only %s; "+
+				"providers and API keys belong in the user config", path, key, strings.Join(projectKeys, ", "))
+		}
+	}
+
+	var project ProjectConfig
+
+	if err := unmarshal(data, &project); err != nil {
+		return ProjectConfig{}, "", fmt.Errorf("parse project config %s: %w", path, err)
+	}
+
+	project.Instructions = strings.TrimSpace(project.Instructions)
+
+	if err := checkProjectShell(project.Shell); err != nil {
+		return ProjectConfig{}, "", fmt.Errorf("project config %s: %w", path, err)
+	}
+
+	return project, path, nil
+}
+
+// checkProjectShell refuses shells a checked-out repository could plant, such
+// as relative paths or absolute paths that are not a known login shell.
+func checkProjectShell(shell string) error {
+	switch {
+	case shell == "" || !strings.Contains(shell, "/"):
+		return nil
+	case !filepath.IsAbs(shell):
+		return fmt.Errorf("shell %q must be a name on $PATH or an absolute path", shell)
+	}
+
+	file, err := os.Open(shellsFile)
+	if err != nil {
+		return fmt.Errorf("shell %q must be a name on $PATH: %w", shell, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == shell {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("shell %q is not listed in %s", shell, shellsFile)
+}
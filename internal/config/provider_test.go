@@ -15,6 +15,9 @@ func TestNormalizeProviderName(t *testing.T) {
 		{input: "OpenAI", want: "openai"},
 		{input: "open-ai", want: "openai"},
 		{input: "google-ai-studio", want: "aistudio"},
+		{input: "anthropic", want: "anthropic"},
+		{input: "Claude", want: "anthropic"},
+		{input: "ollama", want: "ollama"},
 		{input: "unknown", want: ""},
 	}
 
@@ -32,6 +35,8 @@ func TestDefaultModelForProvider(t *testing.T) {
 	}{
 		{input: "openai", want: "gpt-4o-mini"},
 		{input: "aistudio", want: "gemini-2.5-flash"},
+		{input: "claude", want: "claude-haiku-4-5"},
+		{input: "ollama", want: "llama3.2"},
 		{input: "unknown", want: ""},
 	}
 
@@ -0,0 +1,9 @@
+package config
+
+// RedactionConfig extends the secrets masked before requests leave the machine.
+type RedactionConfig struct {
+	// Patterns are regular expressions for secrets besides the built-in ones.
+	// The first capture group is masked when there is one, the whole match
+	// otherwise.
+	Patterns []string `mapstructure:"patterns" toml:"patterns,omitempty" yaml:"patterns,omitempty"`
+}
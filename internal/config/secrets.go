@@ -0,0 +1,32 @@
+package config
+
+import "fmt"
+
+// Backends of the secret store holding API keys.
+const (
+	// SecretsBackendKeyring uses the OS keyring, falling back to an encrypted
+	// file where none is available.
+	SecretsBackendKeyring = "keyring"
+	// SecretsBackendFile always uses the encrypted file.
+	SecretsBackendFile = "file"
+)
+
+// SecretsConfig selects where API keys entered with "aida providers
+// configure" are stored.
+type SecretsConfig struct {
+	// Backend is "keyring" or "file". API keys are kept in the config file
+	// when it is unset.
+	Backend string `mapstructure:"backend" toml:"backend,omitempty" yaml:"backend,omitempty"`
+}
+
+// Store returns the configured backend, or an empty string when API keys are
+// kept in the config file.
+func (c SecretsConfig) Store() (string, error) {
+	switch c.Backend {
+	case "", SecretsBackendKeyring, SecretsBackendFile:
+		return c.Backend, nil
+	default:
+		return "", fmt.Errorf("invalid secrets backend %q: use %q or %q",
+			c.Backend, SecretsBackendKeyring, SecretsBackendFile)
+	}
+}
@@ -0,0 +1,291 @@
+package envinfo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/cache"
+)
+
+const (
+	// CacheTTL is how long collected facts are reused on a host.
+	CacheTTL = 24 * time.Hour
+	// probeTimeout bounds every program run to detect a version.
+	probeTimeout = 2 * time.Second
+	// maxVersionSize bounds the version line reported for the shell.
+	maxVersionSize = 80
+)
+
+// defaultOSRelease is where Linux distributions describe themselves.
+const defaultOSRelease = "/etc/os-release"
+
+// packageManagers are reported when found on $PATH, in this order.
+var packageManagers = []string{
+	"brew", "port", "apt", "dnf", "yum", "pacman", "zypper", "apk", "emerge", "xbps-install", "nix", "pkg", "snap",
+	"flatpak",
+}
+
+// coreutils are the programs whose GNU or BSD flavor changes which flags work.
+var coreutils = []string{"ls", "sed", "find"}
+
+// Collector gathers the allowed facts about the local machine.
+type Collector struct {
+	// Shell runs the generated commands; its version is reported.
+	Shell string
+	// Facts is the allowlist of facts to collect, see config.AllFacts.
+	Facts []string
+	// Tools are the programs looked up on $PATH.
+	Tools []string
+	// OSRelease is read for the distro, /etc/os-release when empty.
+	OSRelease string
+}
+
+// Collect returns one "Name: value" line per fact that could be detected.
+func (c Collector) Collect(ctx context.Context) []string {
+	var lines []string
+
+	add := func(name, value string) {
+		if value != "" {
+			lines = append(lines, name+": "+value)
+		}
+	}
+
+	for _, fact := range c.Facts {
+		switch fact {
+		case config.FactDistro:
+			add("Distro", c.distro(ctx))
+		case config.FactShellVersion:
+			add("Shell version", shellVersion(ctx, c.Shell))
+		case config.FactCoreutils:
+			add("Coreutils", coreutilsFlavor(ctx))
+		case config.FactPackageManager:
+			add("Package managers", strings.Join(onPath(packageManagers), ", "))
+		case config.FactTools:
+			found := onPath(c.Tools)
+			add("Installed tools", strings.Join(found, ", "))
+			add("Not installed", strings.Join(missing(c.Tools, found), ", "))
+		}
+	}
+
+	return lines
+}
+
+// CachedCollect returns the facts cached for this host and collector
+// settings, collecting and storing them when there are none or the store
+// refreshes. A nil store always collects.
+func (c Collector) CachedCollect(ctx context.Context, store *cache.Store) ([]string, error) {
+	if store == nil {
+		return c.Collect(ctx), nil
+	}
+
+	host, _ := os.Hostname()
+
+	key, err := cache.Key("environment", host, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if !store.Refresh() {
+		if lines, ok := store.Get(key); ok {
+			return lines, nil
+		}
+	}
+
+	lines := c.Collect(ctx)
+	if err := store.Put(key, lines); err != nil {
+		return lines, err
+	}
+
+	return lines, nil
+}
+
+func (c Collector) distro(ctx context.Context) string {
+	path := c.OSRelease
+	if path == "" {
+		path = defaultOSRelease
+	}
+
+	if name := osReleaseName(path); name != "" {
+		return name
+	}
+
+	if runtime.GOOS == "darwin" {
+		if version := probe(ctx, "sw_vers", "-productVersion"); version != "" {
+			return "macOS " + version
+		}
+	}
+
+	return ""
+}
+
+// osReleaseName returns PRETTY_NAME from an os-release file, falling back to
+// NAME and VERSION_ID.
+func osReleaseName(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if ok {
+			values[key] = strings.Trim(value, `"'`)
+		}
+	}
+
+	if name := values["PRETTY_NAME"]; name != "" {
+		return name
+	}
+
+	return strings.TrimSpace(values["NAME"] + " " + values["VERSION_ID"])
+}
+
+// shellVersion returns the first line of "shell --version", or the shell's
+// name when it has no such flag, as dash does not.
+func shellVersion(ctx context.Context, shell string) string {
+	if shell == "" {
+		return ""
+	}
+
+	version := probe(ctx, shell, "--version")
+	if version == "" {
+		return filepath.Base(shell)
+	}
+
+	if len(version) > maxVersionSize {
+		version = version[:maxVersionSize]
+	}
+
+	return version
+}
+
+// coreutilsFlavor reports whether ls, sed and find are the GNU, BusyBox or BSD
+// versions, e.g. "GNU" or "BSD ls, GNU sed, BSD find" when they differ.
+// Programs whose flavor cannot be told are left out.
+func coreutilsFlavor(ctx context.Context) string {
+	flavors := make([]string, 0, len(coreutils))
+	same := true
+
+	for _, program := range coreutils {
+		if _, err := exec.LookPath(program); err != nil {
+			continue
+		}
+
+		flavor := versionFlavor(ctx, program)
+		if flavor == "" {
+			continue
+		}
+
+		if len(flavors) > 0 && !strings.HasPrefix(flavors[0], flavor+" ") {
+			same = false
+		}
+
+		flavors = append(flavors, flavor+" "+program)
+	}
+
+	if len(flavors) == 0 {
+		return ""
+	}
+
+	if same {
+		flavor, _, _ := strings.Cut(flavors[0], " ")
+
+		return flavor
+	}
+
+	return strings.Join(flavors, ", ")
+}
+
+// versionFlavor tells the flavor of a program from what "program --version"
+// prints on stdout or stderr. BusyBox is checked first, since its sed says
+// "This is not GNU sed" and its other applets reject the flag. BSD programs
+// reject the flag with a usage message. It returns "" when the program did
+// not run or printed nothing it recognizes.
+func versionFlavor(ctx context.Context, program string) string {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, program, "--version").CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if (err != nil && !errors.As(err, &exitErr)) || ctx.Err() != nil {
+		return ""
+	}
+
+	version := strings.ToLower(string(out))
+
+	switch {
+	case strings.Contains(version, "busybox"), strings.Contains(version, "this is not gnu"):
+		return "BusyBox"
+	case strings.Contains(version, "gnu"):
+		return "GNU"
+	case strings.Contains(version, "usage:"):
+		return "BSD"
+	default:
+		return ""
+	}
+}
+
+// probe runs a program and returns the first line of its output, or an empty
+// string when it fails.
+func probe(ctx context.Context, program string, args ...string) string {
+	out, err := run(ctx, program, args...)
+	if err != nil {
+		return ""
+	}
+
+	line, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+
+	return strings.TrimSpace(line)
+}
+
+// run runs a program with a timeout and returns its standard output.
+func run(ctx context.Context, program string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, program, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("run %s: %w", program, err)
+	}
+
+	return string(out), nil
+}
+
+func onPath(programs []string) []string {
+	var found []string
+
+	for _, program := range programs {
+		if _, err := exec.LookPath(program); err == nil {
+			found = append(found, program)
+		}
+	}
+
+	return found
+}
+
+func missing(programs, found []string) []string {
+	var result []string
+
+	for _, program := range programs {
+		if !slices.Contains(found, program) {
+			result = append(result, program)
+		}
+	}
+
+	return result
+}
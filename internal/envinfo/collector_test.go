@@ -0,0 +1,157 @@
+package envinfo_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/envinfo"
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePath replaces $PATH with a directory holding scripts that print the
+// given output.
+func fakePath(t *testing.T, programs map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, script := range programs {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0o755))
+	}
+
+	t.Setenv("PATH", dir)
+
+	return dir
+}
+
+func writeOSRelease(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "os-release")
+	content := "NAME=\"Ubuntu\"\nVERSION_ID=\"24.04\"\nPRETTY_NAME=\"Ubuntu 24.04 LTS\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	return path
+}
+
+func TestCollect(t *testing.T) {
+	dir := fakePath(t, map[string]string{
+		"ls":     "echo 'ls (GNU coreutils) 9.4'",
+		"sed":    "echo 'sed (GNU sed) 4.9'",
+		"find":   "echo 'find: illegal option -- -' >&2; echo 'usage: find [-H | -L | -P] path' >&2; exit 1",
+		"apt":    "exit 0",
+		"rg":     "exit 0",
+		"fakesh": "echo 'fakesh 1.0'; echo 'second line'",
+	})
+
+	collector := envinfo.Collector{
+		Shell:     filepath.Join(dir, "fakesh"),
+		Facts:     config.AllFacts,
+		Tools:     []string{"rg", "fd"},
+		OSRelease: writeOSRelease(t),
+	}
+
+	assert.Equal(t, []string{
+		"Distro: Ubuntu 24.04 LTS",
+		"Shell version: fakesh 1.0",
+		"Coreutils: GNU ls, GNU sed, BSD find",
+		"Package managers: apt",
+		"Installed tools: rg",
+		"Not installed: fd",
+	}, collector.Collect(context.Background()))
+}
+
+func TestCollectAllowlist(t *testing.T) {
+	fakePath(t, map[string]string{
+		"ls":  "echo 'ls (GNU coreutils) 9.4'",
+		"sed": "echo 'sed (GNU sed) 4.9'",
+		"git": "exit 0",
+	})
+
+	collector := envinfo.Collector{
+		Shell:     "/bin/sh",
+		Facts:     []string{config.FactCoreutils, config.FactTools},
+		Tools:     []string{"git"},
+		OSRelease: writeOSRelease(t),
+	}
+
+	assert.Equal(t, []string{"Coreutils: GNU", "Installed tools: git"}, collector.Collect(context.Background()))
+}
+
+func TestCollectCoreutils(t *testing.T) {
+	tests := []struct {
+		name     string
+		programs map[string]string
+		want     []string
+	}{
+		{
+			name: "busybox",
+			programs: map[string]string{
+				"ls": "echo 'ls: unrecognized option: version' >&2; " +
+					"echo 'BusyBox v1.36.1 (2024-06-10 07:11:47 UTC) multi-call binary.' >&2; exit 1",
+				"sed":  "echo 'This is not GNU sed version 4.0'",
+				"find": "echo 'BusyBox v1.36.1 (2024-06-10 07:11:47 UTC) multi-call binary.' >&2; exit 1",
+			},
+			want: []string{"Coreutils: BusyBox"},
+		},
+		{
+			name: "failed probes are left out",
+			programs: map[string]string{
+				"ls":   "echo 'ls (GNU coreutils) 9.4'",
+				"sed":  "exit 1",
+				"find": "kill -9 $$",
+			},
+			want: []string{"Coreutils: GNU"},
+		},
+		{
+			name:     "nothing recognized",
+			programs: map[string]string{"ls": "exit 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakePath(t, tt.programs)
+
+			collector := envinfo.Collector{Facts: []string{config.FactCoreutils}}
+
+			assert.Equal(t, tt.want, collector.Collect(context.Background()))
+		})
+	}
+}
+
+func TestCachedCollect(t *testing.T) {
+	fakePath(t, map[string]string{"jq": "exit 0"})
+
+	store, err := cache.NewStore(t.TempDir(), cache.WithMaxAge(envinfo.CacheTTL))
+	require.NoError(t, err)
+
+	collector := envinfo.Collector{Facts: []string{config.FactTools}, Tools: []string{"jq"}}
+
+	lines, err := collector.CachedCollect(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Installed tools: jq"}, lines)
+
+	fakePath(t, nil)
+
+	lines, err = collector.CachedCollect(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Installed tools: jq"}, lines, "facts are reused on the same host")
+
+	refreshing, err := cache.NewStore(store.Dir(), cache.WithRefresh(true))
+	require.NoError(t, err)
+
+	lines, err = collector.CachedCollect(context.Background(), refreshing)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Not installed: jq"}, lines, "a refresh collects again")
+
+	collector.Tools = []string{"jq", "yq"}
+
+	lines, err = collector.CachedCollect(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Not installed: jq, yq"}, lines, "other settings are collected again")
+}
@@ -0,0 +1,4 @@
+// Package envinfo collects facts about the local machine, such as the distro,
+// the shell version and the tools on $PATH, so the model suggests commands
+// that work on it.
+package envinfo
@@ -0,0 +1,205 @@
+package envinfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/metalagman/aida/internal/config"
+)
+
+// shortHashSize is how much of a commit hash is shown for a detached HEAD.
+const shortHashSize = 7
+
+// GitCollector describes the git work tree containing Dir.
+type GitCollector struct {
+	Dir string
+	// Detail is one of config.GitDetailBranch, config.GitDetailStatus or
+	// config.GitDetailFiles; anything else collects nothing.
+	Detail string
+	// MaxFiles bounds the changed files listed.
+	MaxFiles int
+}
+
+// Number of space separated fields before the path in the porcelain v2
+// entries of changed, renamed and unmerged files, after the entry kind.
+const (
+	changedFields  = 7
+	renamedFields  = 8
+	unmergedFields = 9
+)
+
+// gitStatus is the parsed output of "git status --porcelain=v2 --branch".
+type gitStatus struct {
+	oid, head, upstream string
+	ahead, behind       string
+
+	staged, unstaged, untracked, conflicted int
+	// files holds a short status code and the path of every changed file.
+	files []string
+}
+
+// Collect returns one "Name: value" line per detail of the repository, or
+// nothing outside a work tree or when git is not installed.
+func (c GitCollector) Collect(ctx context.Context) []string {
+	if c.Detail != config.GitDetailBranch && c.Detail != config.GitDetailStatus && c.Detail != config.GitDetailFiles {
+		return nil
+	}
+
+	out, err := run(ctx, "git", "-C", c.Dir, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return nil
+	}
+
+	status := parseGitStatus(out)
+	lines := []string{"Git branch: " + status.branch(), "Git upstream: " + status.tracking()}
+
+	if c.Detail == config.GitDetailBranch {
+		return lines
+	}
+
+	lines = append(lines, "Git work tree: "+status.state())
+
+	if c.Detail == config.GitDetailFiles && len(status.files) > 0 {
+		lines = append(lines, "Git changed files: "+listFiles(status.files, c.MaxFiles))
+	}
+
+	return lines
+}
+
+func parseGitStatus(out string) gitStatus {
+	var status gitStatus
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if header, ok := strings.CutPrefix(line, "# "); ok {
+			status.parseHeader(header)
+
+			continue
+		}
+
+		kind, rest, _ := strings.Cut(line, " ")
+
+		switch kind {
+		case "?":
+			status.untracked++
+			status.files = append(status.files, "?? "+rest)
+		case "u":
+			status.conflicted++
+			status.files = append(status.files, "U "+entryPath(rest, unmergedFields))
+		case "1":
+			status.addChange(rest[:min(len(rest), 2)], entryPath(rest, changedFields))
+		case "2":
+			// Renames and copies end with "path<TAB>original path".
+			path, _, _ := strings.Cut(entryPath(rest, renamedFields), "\t")
+			status.addChange(rest[:min(len(rest), 2)], path)
+		}
+	}
+
+	return status
+}
+
+// entryPath returns what follows the first n fields of a status entry.
+func entryPath(entry string, n int) string {
+	parts := strings.SplitN(entry, " ", n+1)
+	if len(parts) <= n {
+		return ""
+	}
+
+	return parts[n]
+}
+
+func (s *gitStatus) parseHeader(header string) {
+	key, value, _ := strings.Cut(header, " ")
+
+	switch key {
+	case "branch.oid":
+		s.oid = value
+	case "branch.head":
+		s.head = value
+	case "branch.upstream":
+		s.upstream = value
+	case "branch.ab":
+		s.ahead, s.behind, _ = strings.Cut(value, " ")
+		s.ahead = strings.TrimPrefix(s.ahead, "+")
+		s.behind = strings.TrimPrefix(s.behind, "-")
+	}
+}
+
+// addChange counts a tracked change by its two-letter XY code, where X is
+// the staged and Y the unstaged state and "." means unchanged.
+func (s *gitStatus) addChange(xy, path string) {
+	if len(xy) != 2 {
+		return
+	}
+
+	if xy[0] != '.' {
+		s.staged++
+	}
+
+	if xy[1] != '.' {
+		s.unstaged++
+	}
+
+	s.files = append(s.files, strings.TrimSpace(strings.ReplaceAll(xy, ".", " "))+" "+path)
+}
+
+func (s gitStatus) branch() string {
+	switch {
+	case s.head == "(detached)":
+		return "detached HEAD at " + s.oid[:min(len(s.oid), shortHashSize)]
+	case s.oid == "(initial)":
+		return s.head + " (no commits yet)"
+	default:
+		return s.head
+	}
+}
+
+func (s gitStatus) tracking() string {
+	if s.upstream == "" {
+		return "none"
+	}
+
+	if s.ahead == "" || (s.ahead == "0" && s.behind == "0") {
+		return s.upstream + ", up to date"
+	}
+
+	return fmt.Sprintf("%s, %s ahead, %s behind", s.upstream, s.ahead, s.behind)
+}
+
+func (s gitStatus) state() string {
+	counts := []struct {
+		n    int
+		name string
+	}{
+		{s.staged, "staged"},
+		{s.unstaged, "unstaged"},
+		{s.untracked, "untracked"},
+		{s.conflicted, "conflicted"},
+	}
+
+	parts := []string{"dirty"}
+
+	for _, count := range counts {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count.n, count.name))
+		}
+	}
+
+	if len(parts) == 1 {
+		return "clean"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func listFiles(files []string, limit int) string {
+	if limit <= 0 || len(files) <= limit {
+		return strings.Join(files, ", ")
+	}
+
+	return fmt.Sprintf("%s (and %d more)", strings.Join(files[:limit], ", "), len(files)-limit)
+}
@@ -0,0 +1,111 @@
+package envinfo_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/envinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	args = append([]string{"-C", dir, "-c", "user.name=aida", "-c", "user.email=aida@example.com"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// gitClone returns a clone of a repository with one commit, so that its main
+// branch tracks origin/main.
+func gitClone(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	origin := t.TempDir()
+	git(t, origin, "init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(origin, "config.toml"), []byte("a = 1\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(origin, "main.go"), []byte("package main\n"), 0o644))
+	git(t, origin, "add", ".")
+	git(t, origin, "commit", "-q", "-m", "initial")
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	git(t, origin, "clone", "-q", origin, clone)
+
+	return clone
+}
+
+func TestGitCollector(t *testing.T) {
+	dir := gitClone(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# readme\n"), 0o644))
+	git(t, dir, "add", "README.md")
+	git(t, dir, "commit", "-q", "-m", "add readme")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte("a = 2\n"), 0o644))
+	git(t, dir, "mv", "main.go", "app.go")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("todo\n"), 0o644))
+
+	tests := []struct {
+		detail   string
+		maxFiles int
+		want     []string
+	}{
+		{detail: config.GitDetailOff},
+		{
+			detail: config.GitDetailBranch,
+			want:   []string{"Git branch: main", "Git upstream: origin/main, 1 ahead, 0 behind"},
+		},
+		{
+			detail: config.GitDetailStatus,
+			want: []string{
+				"Git branch: main",
+				"Git upstream: origin/main, 1 ahead, 0 behind",
+				"Git work tree: dirty, 1 staged, 1 unstaged, 1 untracked",
+			},
+		},
+		{
+			detail:   config.GitDetailFiles,
+			maxFiles: 2,
+			want: []string{
+				"Git branch: main",
+				"Git upstream: origin/main, 1 ahead, 0 behind",
+				"Git work tree: dirty, 1 staged, 1 unstaged, 1 untracked",
+				"Git changed files: R app.go, M config.toml (and 1 more)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.detail, func(t *testing.T) {
+			collector := envinfo.GitCollector{Dir: dir, Detail: tt.detail, MaxFiles: tt.maxFiles}
+			assert.Equal(t, tt.want, collector.Collect(context.Background()))
+		})
+	}
+}
+
+func TestGitCollectorClean(t *testing.T) {
+	dir := gitClone(t)
+
+	collector := envinfo.GitCollector{Dir: dir, Detail: config.GitDetailFiles}
+	assert.Equal(t, []string{
+		"Git branch: main",
+		"Git upstream: origin/main, up to date",
+		"Git work tree: clean",
+	}, collector.Collect(context.Background()))
+}
+
+func TestGitCollectorOutsideRepository(t *testing.T) {
+	collector := envinfo.GitCollector{Dir: t.TempDir(), Detail: config.GitDetailFiles}
+	assert.Empty(t, collector.Collect(context.Background()))
+}
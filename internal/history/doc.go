@@ -0,0 +1,3 @@
+// Package history keeps a local record of generated commands and what became
+// of them, so they can be searched and run again.
+package history
@@ -0,0 +1,80 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Search returns the entries whose prompt or command fuzzily matches query,
+// best match first and newer entries first among equal matches. Every
+// character of the query must appear in order; matches that are contiguous
+// or start at a word boundary rank higher.
+func Search(entries []Entry, query string) []Entry {
+	type scored struct {
+		entry Entry
+		score int
+	}
+
+	var matches []scored
+
+	for _, entry := range entries {
+		score, ok := fuzzyScore(query, entry.Prompt)
+		if commandScore, commandOK := fuzzyScore(query, entry.Command); commandOK && (!ok || commandScore > score) {
+			score, ok = commandScore, true
+		}
+
+		if ok {
+			matches = append(matches, scored{entry, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+
+		return matches[i].entry.ID > matches[j].entry.ID
+	})
+
+	result := make([]Entry, 0, len(matches))
+	for _, match := range matches {
+		result = append(result, match.entry)
+	}
+
+	return result
+}
+
+// fuzzyScore reports whether the runes of query appear in text in order,
+// ignoring case and spaces in the query, and how well they match.
+func fuzzyScore(query, text string) (int, bool) {
+	pattern := []rune(strings.ToLower(strings.Join(strings.Fields(query), "")))
+	if len(pattern) == 0 {
+		return 0, true
+	}
+
+	runes := []rune(strings.ToLower(text))
+	score, next, prev := 0, 0, -2
+
+	for i, r := range runes {
+		if next == len(pattern) {
+			break
+		}
+
+		if r != pattern[next] {
+			continue
+		}
+
+		switch {
+		case i == prev+1:
+			score += 2
+		case i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1]):
+			score++
+		}
+
+		prev = i
+		next++
+	}
+
+	return score, next == len(pattern)
+}
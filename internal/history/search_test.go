@@ -0,0 +1,41 @@
+package history_test
+
+import (
+	"testing"
+
+	"github.com/metalagman/aida/internal/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearch(t *testing.T) {
+	entries := []history.Entry{
+		{ID: 1, Prompt: "show disk usage sorted", Command: "du -sh * | sort -h"},
+		{ID: 2, Prompt: "list files", Command: "ls -la"},
+		{ID: 3, Prompt: "find large files", Command: "find . -size +100M"},
+		{ID: 4, Prompt: "disk usage of home", Command: "du -sh ~"},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{name: "contiguous", query: "disk usage", want: []int{4, 1}},
+		{name: "subsequence", query: "lgfl", want: []int{3}},
+		{name: "command", query: "sort -h", want: []int{1}},
+		{name: "case insensitive", query: "LS -LA", want: []int{2}},
+		{name: "no match", query: "docker", want: []int{}},
+		{name: "empty query", query: "", want: []int{4, 3, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := []int{}
+			for _, entry := range history.Search(entries, tt.query) {
+				ids = append(ids, entry.ID)
+			}
+
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
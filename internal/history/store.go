@@ -0,0 +1,180 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/metalagman/aida/internal/config"
+)
+
+// historyFile is the store's file name inside config.DataDir.
+const historyFile = "history.jsonl"
+
+// maxLineSize bounds a single history line; longer lines are skipped.
+const maxLineSize = 1 << 20
+
+// ErrNotFound is returned for an id that is not in the history.
+var ErrNotFound = errors.New("history entry not found")
+
+// Entry is one generated command.
+type Entry struct {
+	// ID is the position of the entry in the history, starting at 1. It is
+	// assigned when entries are read and not stored.
+	ID       int       `json:"id,omitempty"`
+	Time     time.Time `json:"time"`
+	Prompt   string    `json:"prompt"`
+	Command  string    `json:"command"`
+	Provider string    `json:"provider,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	Dir      string    `json:"dir,omitempty"`
+	Mode     string    `json:"mode"`
+	// Confirmed reports whether the user approved the command at the prompt.
+	Confirmed bool `json:"confirmed"`
+	Ran       bool `json:"ran"`
+	// ExitCode is the exit status of the command, -1 when it did not exit
+	// on its own or did not run.
+	ExitCode int `json:"exit_code"`
+}
+
+// Store appends entries to a JSON lines file.
+type Store struct {
+	path string
+}
+
+// NewStore returns a history stored at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultStore returns the history in config.DataDir.
+func DefaultStore() (*Store, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStore(filepath.Join(dir, historyFile)), nil
+}
+
+// Add appends entry to the history.
+func (s *Store) Add(entry Entry) error {
+	entry.ID = 0
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal history entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), config.DirPerm); err != nil {
+		return fmt.Errorf("create history dir: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, config.FilePerm)
+	if err != nil {
+		return fmt.Errorf("open history: %w", err)
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+
+		return fmt.Errorf("write history: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close history: %w", err)
+	}
+
+	return nil
+}
+
+// List returns every entry, oldest first. Lines that cannot be parsed keep
+// their id but are left out.
+func (s *Store) List() ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+
+	reader := bufio.NewReader(f)
+
+	for id := 1; ; id++ {
+		line, err := readLine(reader)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("read history: %w", err)
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+
+		entry.ID = id
+		entries = append(entries, entry)
+	}
+}
+
+// readLine returns the next line of r without its line ending. A line longer
+// than maxLineSize is read to its end and returned empty, so that it is
+// skipped like any other line that cannot be parsed.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	var (
+		line    []byte
+		tooLong bool
+	)
+
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if !tooLong {
+			line = append(line, chunk...)
+			tooLong = len(line) > maxLineSize
+		}
+
+		if isPrefix {
+			continue
+		}
+
+		if tooLong {
+			return nil, nil
+		}
+
+		return line, nil
+	}
+}
+
+// Get returns the entry with the given id.
+func (s *Store) Get(id int) (Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+
+	return Entry{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+}
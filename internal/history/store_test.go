@@ -0,0 +1,71 @@
+package history_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metalagman/aida/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aida", "history.jsonl")
+	store := history.NewStore(path)
+
+	entries, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	first := history.Entry{
+		Time: at, Prompt: "list files", Command: "ls -la", Provider: "openai", Model: "gpt-4o",
+		Dir: "/tmp", Mode: "confirm", Confirmed: true, Ran: true,
+	}
+	second := history.Entry{Time: at.Add(time.Minute), Prompt: "disk usage", Command: "du -sh",
+		Mode: "dry-run", ExitCode: 0}
+
+	require.NoError(t, store.Add(first))
+
+	// A damaged line is skipped but still takes up its id.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString("{\"time\":\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.NoError(t, store.Add(second))
+
+	first.ID, second.ID = 1, 3
+
+	entries, err = store.List()
+	require.NoError(t, err)
+	assert.Equal(t, []history.Entry{first, second}, entries)
+
+	got, err := store.Get(3)
+	require.NoError(t, err)
+	assert.Equal(t, second, got)
+
+	_, err = store.Get(2)
+	require.ErrorIs(t, err, history.ErrNotFound)
+}
+
+func TestStoreSkipsOversizedLines(t *testing.T) {
+	store := history.NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	huge := history.Entry{Time: at, Prompt: strings.Repeat("x", 2<<20), Command: "true", Mode: "yolo"}
+	last := history.Entry{Time: at, Prompt: "list files", Command: "ls", Mode: "confirm"}
+
+	require.NoError(t, store.Add(huge))
+	require.NoError(t, store.Add(last))
+
+	last.ID = 2
+
+	entries, err := store.List()
+	require.NoError(t, err)
+	assert.Equal(t, []history.Entry{last}, entries)
+}
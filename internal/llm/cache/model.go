@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"iter"
+
+	"github.com/metalagman/aida/internal/llm/provider"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Model answers requests from a Store and asks the wrapped model only on a
+// miss, storing its answer for next time.
+type Model struct {
+	model.LLM
+
+	store    *Store
+	provider string
+}
+
+// Wrap returns llm answering from store. The provider name is part of every
+// key, so entries that share a model name do not mix. A nil store returns llm
+// unchanged.
+func Wrap(llm model.LLM, store *Store, provider string) model.LLM {
+	if store == nil {
+		return llm
+	}
+
+	return &Model{LLM: llm, store: store, provider: provider}
+}
+
+// GenerateContent serves the request from the cache, or from the wrapped
+// model when it is not cached, expired or the store refreshes. Only complete
+// answers without errors are stored.
+func (m *Model) GenerateContent(
+	ctx context.Context,
+	req *model.LLMRequest,
+	stream bool,
+) iter.Seq2[*model.LLMResponse, error] {
+	key, err := Key(m.provider, m.Name(), req.Contents, req.Config)
+	if err != nil {
+		return m.LLM.GenerateContent(ctx, req, stream)
+	}
+
+	if !m.store.Refresh() {
+		if texts, ok := m.store.Get(key); ok {
+			return cachedResponses(texts)
+		}
+	}
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var (
+			texts    []string
+			answered bool
+		)
+
+		for resp, err := range m.LLM.GenerateContent(ctx, req, stream) {
+			if !yield(resp, err) || err != nil {
+				return
+			}
+
+			if resp == nil || resp.Partial || resp.Content == nil {
+				continue
+			}
+
+			text := contentText(resp.Content)
+			texts = append(texts, text)
+			answered = answered || text != ""
+		}
+
+		if answered {
+			// The cache is best effort; a failed write only costs a later call.
+			_ = m.store.Put(key, texts)
+		}
+	}
+}
+
+func cachedResponses(texts []string) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, text := range texts {
+			if !yield(&model.LLMResponse{
+				Content:        genai.NewContentFromText(text, genai.RoleModel),
+				CustomMetadata: map[string]any{provider.CachedMetadataKey: true},
+				TurnComplete:   true,
+			}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func contentText(content *genai.Content) string {
+	var text string
+
+	for _, part := range content.Parts {
+		if part != nil && !part.Thought {
+			text += part.Text
+		}
+	}
+
+	return text
+}
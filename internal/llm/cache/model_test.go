@@ -0,0 +1,157 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+type countingModel struct {
+	calls   int
+	replies []string
+	err     error
+}
+
+func (m *countingModel) Name() string {
+	return "fake"
+}
+
+func (m *countingModel) GenerateContent(
+	_ context.Context,
+	_ *model.LLMRequest,
+	stream bool,
+) iter.Seq2[*model.LLMResponse, error] {
+	m.calls++
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if m.err != nil {
+			yield(nil, m.err)
+
+			return
+		}
+
+		for _, reply := range m.replies {
+			if stream && !yield(&model.LLMResponse{
+				Content: genai.NewContentFromText(reply, genai.RoleModel),
+				Partial: true,
+			}, nil) {
+				return
+			}
+
+			if !yield(&model.LLMResponse{Content: genai.NewContentFromText(reply, genai.RoleModel)}, nil) {
+				return
+			}
+		}
+	}
+}
+
+type answer struct {
+	texts   []string
+	partial int
+	cached  bool
+}
+
+func generate(t *testing.T, llm model.LLM, prompt string) (answer, error) {
+	t.Helper()
+
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText(prompt, genai.RoleUser)},
+		Config:   &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText("system", "")},
+	}
+
+	var got answer
+
+	for resp, err := range llm.GenerateContent(context.Background(), req, true) {
+		if err != nil {
+			return got, err
+		}
+
+		if resp.Partial {
+			got.partial++
+
+			continue
+		}
+
+		got.texts = append(got.texts, resp.Content.Parts[0].Text)
+		got.cached, _ = resp.CustomMetadata[provider.CachedMetadataKey].(bool)
+	}
+
+	return got, nil
+}
+
+func TestModel(t *testing.T) {
+	store, err := cache.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	inner := &countingModel{replies: []string{"ls -la", "ls -lah"}}
+	llm := cache.Wrap(inner, store, "openai")
+
+	got, err := generate(t, llm, "list files")
+	require.NoError(t, err)
+	assert.Equal(t, answer{texts: []string{"ls -la", "ls -lah"}, partial: 2}, got)
+
+	got, err = generate(t, llm, "list files")
+	require.NoError(t, err)
+	assert.Equal(t, answer{texts: []string{"ls -la", "ls -lah"}, cached: true}, got)
+	assert.Equal(t, 1, inner.calls)
+
+	_, err = generate(t, llm, "list all files")
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls)
+
+	// The provider is part of the key.
+	_, err = generate(t, cache.Wrap(inner, store, "groq"), "list files")
+	require.NoError(t, err)
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestModelRefresh(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := cache.NewStore(dir)
+	require.NoError(t, err)
+
+	_, err = generate(t, cache.Wrap(&countingModel{replies: []string{"ls"}}, store, "openai"), "list files")
+	require.NoError(t, err)
+
+	refreshing, err := cache.NewStore(dir, cache.WithRefresh(true))
+	require.NoError(t, err)
+
+	inner := &countingModel{replies: []string{"ls -la"}}
+
+	got, err := generate(t, cache.Wrap(inner, refreshing, "openai"), "list files")
+	require.NoError(t, err)
+	assert.False(t, got.cached)
+	assert.Equal(t, 1, inner.calls)
+
+	got, err = generate(t, cache.Wrap(inner, store, "openai"), "list files")
+	require.NoError(t, err)
+	assert.Equal(t, answer{texts: []string{"ls -la"}, cached: true}, got)
+}
+
+func TestModelDoesNotCacheFailures(t *testing.T) {
+	store, err := cache.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	failing := &countingModel{err: errors.New("boom")}
+
+	_, err = generate(t, cache.Wrap(failing, store, "openai"), "list files")
+	require.Error(t, err)
+
+	_, err = generate(t, cache.Wrap(&countingModel{replies: []string{""}}, store, "openai"), "list files")
+	require.NoError(t, err)
+
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	assert.Zero(t, stats.Entries)
+
+	assert.Same(t, failing, cache.Wrap(failing, nil, "openai"))
+}
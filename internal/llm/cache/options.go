@@ -0,0 +1,13 @@
+package cache
+
+import "time"
+
+//go:generate go tool options-gen -from-struct=Options -out-filename=options_generated.go
+type Options struct {
+	// dir holds one file per cached answer.
+	dir string `option:"mandatory" validate:"required"`
+	// maxAge is how long an answer is reused.
+	maxAge time.Duration `default:"24h" validate:"min=0"`
+	// refresh skips cached answers while still storing new ones.
+	refresh bool `validate:"omitempty"`
+}
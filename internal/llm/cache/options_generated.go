@@ -0,0 +1,70 @@
+// Code generated by options-gen v0.55.3. DO NOT EDIT.
+
+package cache
+
+import (
+	fmt461e464ebed9 "fmt"
+	"time"
+
+	errors461e464ebed9 "github.com/kazhuravlev/options-gen/pkg/errors"
+	validator461e464ebed9 "github.com/kazhuravlev/options-gen/pkg/validator"
+)
+
+type OptOptionsSetter func(o *Options)
+
+func NewOptions(
+	dir string,
+	options ...OptOptionsSetter,
+) Options {
+	var o Options
+
+	// Setting defaults from field tag (if present)
+
+	o.maxAge, _ = time.ParseDuration("24h")
+
+	o.dir = dir
+
+	for _, opt := range options {
+		opt(&o)
+	}
+	return o
+}
+
+// maxAge is how long an answer is reused.
+func WithMaxAge(opt time.Duration) OptOptionsSetter {
+	return func(o *Options) { o.maxAge = opt }
+}
+
+// refresh skips cached answers while still storing new ones.
+func WithRefresh(opt bool) OptOptionsSetter {
+	return func(o *Options) { o.refresh = opt }
+}
+
+func (o *Options) Validate() error {
+	errs := new(errors461e464ebed9.ValidationErrors)
+	errs.Add(errors461e464ebed9.NewValidationError("dir", _validate_Options_dir(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("maxAge", _validate_Options_maxAge(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("refresh", _validate_Options_refresh(o)))
+	return errs.AsError()
+}
+
+func _validate_Options_dir(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.dir, "required"); err != nil {
+		return fmt461e464ebed9.Errorf("field `dir` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_maxAge(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.maxAge, "min=0"); err != nil {
+		return fmt461e464ebed9.Errorf("field `maxAge` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_refresh(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.refresh, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `refresh` did not pass the test: %w", err)
+	}
+	return nil
+}
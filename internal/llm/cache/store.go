@@ -0,0 +1,213 @@
+// Package cache reuses model answers for requests that were already sent, so
+// that repeating a prompt does not call the provider again.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	entrySuffix = ".json"
+	dirPerm     = 0o700
+	filePerm    = 0o600
+)
+
+// Store keeps answers on disk, one file per request key.
+type Store struct {
+	opts Options
+}
+
+// entry is the file format of a cached answer.
+type entry struct {
+	Created time.Time `json:"created"`
+	Texts   []string  `json:"texts"`
+}
+
+// Stats describes the contents of a store.
+type Stats struct {
+	Entries int
+	// Expired counts the entries older than the maximum age; they are removed when
+	// read or cleared.
+	Expired int
+	Size    int64
+}
+
+// NewStore creates a store in dir.
+func NewStore(dir string, options ...OptOptionsSetter) (*Store, error) {
+	opts := NewOptions(dir, options...)
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	return &Store{opts: opts}, nil
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.opts.dir
+}
+
+// Refresh reports whether cached entries are skipped while new ones are
+// still stored.
+func (s *Store) Refresh() bool {
+	return s.opts.refresh
+}
+
+// Key hashes the parts of a request that determine its answer.
+func Key(parts ...any) (string, error) {
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return "", fmt.Errorf("marshal cache key: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Get returns the answer stored under key unless it has expired. Unreadable
+// entries are reported as missing.
+func (s *Store) Get(key string) ([]string, bool) {
+	path := s.path(key)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var cached entry
+	if err := json.Unmarshal(data, &cached); err != nil || s.expired(cached.Created) {
+		_ = os.Remove(path)
+
+		return nil, false
+	}
+
+	return cached.Texts, true
+}
+
+// Put stores texts under key, replacing any previous answer.
+func (s *Store) Put(key string, texts []string) error {
+	data, err := json.Marshal(entry{Created: time.Now().UTC(), Texts: texts})
+	if err != nil {
+		return fmt.Errorf("marshal cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(s.opts.dir, dirPerm); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+
+	// Write to a temporary file first so that a concurrent reader never sees
+	// a partial entry.
+	tmp, err := os.CreateTemp(s.opts.dir, "."+key+"-*")
+	if err != nil {
+		return fmt.Errorf("create cache entry: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("close cache entry: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), filePerm); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("chmod cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("store cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Stats counts the entries of the store and their size on disk.
+func (s *Store) Stats() (Stats, error) {
+	var stats Stats
+
+	err := s.walk(func(_ string, info fs.FileInfo) error {
+		stats.Entries++
+		stats.Size += info.Size()
+
+		if s.expired(info.ModTime()) {
+			stats.Expired++
+		}
+
+		return nil
+	})
+
+	return stats, err
+}
+
+// Clear removes every entry and returns how many were removed.
+func (s *Store) Clear() (int, error) {
+	removed := 0
+
+	err := s.walk(func(path string, _ fs.FileInfo) error {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("remove cache entry: %w", err)
+		}
+
+		removed++
+
+		return nil
+	})
+
+	return removed, err
+}
+
+func (s *Store) walk(fn func(path string, info fs.FileInfo) error) error {
+	entries, err := os.ReadDir(s.opts.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("read cache dir: %w", err)
+	}
+
+	for _, dirEntry := range entries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, entrySuffix) {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+
+		if err := fn(filepath.Join(s.opts.dir, name), info); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) expired(created time.Time) bool {
+	return time.Since(created) > s.opts.maxAge
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.opts.dir, key+entrySuffix)
+}
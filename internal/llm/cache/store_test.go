@@ -0,0 +1,74 @@
+package cache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "responses")
+
+	store, err := cache.NewStore(dir)
+	require.NoError(t, err)
+
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, cache.Stats{}, stats)
+
+	key, err := cache.Key("openai", "gpt-4o", "list files")
+	require.NoError(t, err)
+
+	other, err := cache.Key("openai", "gpt-4o-mini", "list files")
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	_, ok := store.Get(key)
+	assert.False(t, ok)
+
+	require.NoError(t, store.Put(key, []string{"ls -la"}))
+	require.NoError(t, store.Put(other, []string{"ls"}))
+
+	texts, ok := store.Get(key)
+	require.True(t, ok)
+	assert.Equal(t, []string{"ls -la"}, texts)
+
+	info, err := os.Stat(filepath.Join(dir, key+".json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	stats, err = store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Zero(t, stats.Expired)
+	assert.Positive(t, stats.Size)
+
+	removed, err := store.Clear()
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	_, ok = store.Get(key)
+	assert.False(t, ok)
+}
+
+func TestStoreExpired(t *testing.T) {
+	store, err := cache.NewStore(t.TempDir(), cache.WithMaxAge(0))
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put("key", []string{"ls"}))
+
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Expired)
+
+	_, ok := store.Get("key")
+	assert.False(t, ok)
+
+	stats, err = store.Stats()
+	require.NoError(t, err)
+	assert.Zero(t, stats.Entries)
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"github.com/metalagman/aida/internal/llm/provider"
 	"github.com/metalagman/aida/internal/llm/templater"
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
@@ -16,20 +18,157 @@ import (
 const systemInstructionTemplate = `You are a shell command generator. Output ONLY the raw shell command,
 no markdown fences, no explanation. If you cannot fulfill the request,
 output UNABLE_TO_RUN_LOCAL.
+{{- if .Describe}}
+After the command, add one final line starting with "# " that describes
+in a few words how this command approaches the task.
+{{- end}}
 
 Environment:
 - OS: {{.OS}}
 - Arch: {{.Arch}}
 - Shell: {{.Shell}}
-- CWD: {{.CWD}}`
+- CWD: {{.CWD}}
+{{- range .Facts}}
+- {{.}}
+{{- end}}
+{{- if .Instructions}}
+
+Project instructions:
+{{.Instructions}}
+{{- end}}`
+
+const fixInstructionTemplate = `The command exited with status {{.ExitCode}}.
+{{- if .Stderr}}
+Stderr (tail):
+{{.Stderr}}
+{{- end}}
+Output a corrected command that fulfills the original request.`
+
+const turnOutcomeTemplate = `The command {{if .Ran}}exited with status {{.ExitCode}}{{else}}was not run{{end}}.
+{{- if .Output}}
+Output (tail):
+{{.Output}}
+{{- end}}`
+
+const inputTemplate = `Input piped to aida, for context. The command will not receive it on stdin:
+<input>
+{{.}}
+</input>`
 
 const defaultGenerateTimeout = 60 * time.Second
 
-func GenerateCommandWithModel(ctx context.Context, llmModel model.LLM, prompt string) (string, error) {
+// GenerateCommandWithModel asks the model for one command, or for
+// genReq.Candidates alternatives with a one-line description each.
+// Duplicate candidates are dropped. The usage names the model; callers fill
+// in the provider.
+func GenerateCommandWithModel(
+	ctx context.Context,
+	llmModel model.LLM,
+	genReq provider.Request,
+) (provider.Result, error) {
+	describe := genReq.Candidates > 1
+
+	data := environment()
+	data["Facts"] = genReq.Environment
+	data["Instructions"] = genReq.Instructions
+
+	if describe {
+		data["Describe"] = true
+	}
+
+	systemInstruction, err := templater.Render(systemInstructionTemplate, data)
+	if err != nil {
+		return provider.Result{}, err
+	}
+
+	contents, err := requestContents(genReq)
+	if err != nil {
+		return provider.Result{}, err
+	}
+
+	config := &genai.GenerateContentConfig{
+		SystemInstruction: systemContent(systemInstruction),
+	}
+	if describe {
+		config.CandidateCount = int32(genReq.Candidates) //nolint:gosec // bounded by the CLI
+	}
+
+	var onPartial func(string)
+	if !describe {
+		onPartial = genReq.OnPartial
+	}
+
+	texts, usage, err := generateTexts(ctx, llmModel, contents, config, onPartial)
+	if err != nil {
+		return provider.Result{}, err
+	}
+
+	candidates := make([]provider.Candidate, 0, len(texts))
+	seen := make(map[string]bool, len(texts))
+
+	for _, text := range texts {
+		candidate := provider.Candidate{Command: SanitizeCommand(text)}
+		if describe {
+			candidate = ParseCandidate(text)
+		}
+
+		if candidate.Command == "" || seen[candidate.Command] {
+			continue
+		}
+
+		seen[candidate.Command] = true
+		candidates = append(candidates, candidate)
+	}
+
+	if len(candidates) == 0 {
+		return provider.Result{}, fmt.Errorf("%w: model returned no command", llmerr.ErrEmptyResponse)
+	}
+
+	return provider.Result{Candidates: candidates, Usage: usage}, nil
+}
+
+// ParseCandidate splits a model response into a command and the trailing
+// "# description" line, tolerating code fences.
+func ParseCandidate(input string) provider.Candidate {
+	body := SanitizeCommand(input)
+
+	idx := strings.LastIndex(body, "\n")
+	if idx == -1 {
+		return provider.Candidate{Command: body}
+	}
+
+	last := strings.TrimSpace(body[idx+1:])
+	if !strings.HasPrefix(last, "#") {
+		return provider.Candidate{Command: body}
+	}
+
+	return provider.Candidate{
+		Command:     SanitizeCommand(body[:idx]),
+		Description: strings.TrimSpace(strings.TrimPrefix(last, "#")),
+	}
+}
+
+// generateTexts sends the contents to the model and returns the text of every
+// response. Models yield one response per candidate. When onPartial is set the
+// answer is streamed: partial responses carry text deltas, which are passed on
+// accumulated, followed by the complete responses. The usage is taken from
+// the last response that reports it; models sending one request per candidate
+// report the sum there.
+func generateTexts(
+	ctx context.Context,
+	llmModel model.LLM,
+	contents []*genai.Content,
+	config *genai.GenerateContentConfig,
+	onPartial func(string),
+) ([]string, provider.Usage, error) {
+	usage := provider.Usage{}
+
 	if llmModel == nil {
-		return "", fmt.Errorf("model is required")
+		return nil, usage, fmt.Errorf("model is required")
 	}
 
+	usage.Model = llmModel.Name()
+
 	if _, ok := ctx.Deadline(); !ok {
 		var cancel context.CancelFunc
 
@@ -37,54 +176,150 @@ func GenerateCommandWithModel(ctx context.Context, llmModel model.LLM, prompt st
 		defer cancel()
 	}
 
-	systemInstruction, err := templater.Render(systemInstructionTemplate, map[string]string{
+	req := &model.LLMRequest{
+		Model:    llmModel.Name(),
+		Contents: contents,
+		Config:   config,
+	}
+
+	var (
+		texts   []string
+		partial strings.Builder
+	)
+
+	for resp, err := range llmModel.GenerateContent(ctx, req, onPartial != nil) {
+		if err != nil {
+			return nil, usage, fmt.Errorf("generate content: %w", err)
+		}
+
+		if resp == nil {
+			continue
+		}
+
+		if metadata := resp.UsageMetadata; metadata != nil {
+			usage.PromptTokens = int(metadata.PromptTokenCount)
+			usage.CompletionTokens = int(metadata.CandidatesTokenCount + metadata.ThoughtsTokenCount)
+		}
+
+		if cached, _ := resp.CustomMetadata[provider.CachedMetadataKey].(bool); cached {
+			usage.Cached = true
+		}
+
+		if resp.Content == nil {
+			continue
+		}
+
+		text := contentText(resp.Content)
+
+		if resp.Partial {
+			if onPartial != nil {
+				partial.WriteString(text)
+				onPartial(partial.String())
+			}
+
+			continue
+		}
+
+		if onPartial != nil && len(texts) == 0 && partial.Len() == 0 {
+			onPartial(text)
+		}
+
+		texts = append(texts, text)
+	}
+
+	return texts, usage, nil
+}
+
+func contentText(content *genai.Content) string {
+	var sb strings.Builder
+
+	for _, part := range content.Parts {
+		if part.Text != "" {
+			sb.WriteString(part.Text)
+		}
+	}
+
+	return sb.String()
+}
+
+func systemContent(text string) *genai.Content {
+	return &genai.Content{
+		Parts: []*genai.Part{
+			{Text: text},
+		},
+	}
+}
+
+// environment returns the template data describing the local machine.
+func environment() map[string]any {
+	return map[string]any{
 		"OS":    runtime.GOOS,
 		"Arch":  runtime.GOARCH,
 		"Shell": defaultString(os.Getenv("AIDA_SHELL"), os.Getenv("SHELL"), "unknown"),
 		"CWD":   defaultString(currentDir(), "unknown"),
-	})
-	if err != nil {
-		return "", err
 	}
+}
 
-	req := &model.LLMRequest{
-		Model: llmModel.Name(),
-		Contents: []*genai.Content{
-			{
-				Role: "user",
-				Parts: []*genai.Part{
-					{Text: prompt},
-				},
-			},
-		},
-		Config: &genai.GenerateContentConfig{
-			SystemInstruction: &genai.Content{
-				Parts: []*genai.Part{
-					{Text: systemInstruction},
-				},
-			},
-		},
+// requestContents builds the conversation for a request: a user/model turn
+// pair for every earlier session turn, the user prompt, and a model/user turn
+// pair for every failed attempt. The outcome of a session turn is sent along
+// with the prompt that follows it, and piped input along with the prompt of
+// the request.
+func requestContents(req provider.Request) ([]*genai.Content, error) {
+	contents := make([]*genai.Content, 0, 2*len(req.History)+2*len(req.Failures)+1)
+	outcome := ""
+
+	for _, turn := range req.History {
+		contents = append(contents,
+			genai.NewContentFromText(joinParagraphs(outcome, turn.Prompt), genai.RoleUser),
+			genai.NewContentFromText(turn.Command, genai.RoleModel),
+		)
+
+		var err error
+
+		outcome, err = templater.Render(turnOutcomeTemplate, turn)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	var sb strings.Builder
+	input := ""
+	if req.Input != "" {
+		var err error
 
-	for resp, err := range llmModel.GenerateContent(ctx, req, false) {
+		input, err = templater.Render(inputTemplate, req.Input)
 		if err != nil {
-			return "", fmt.Errorf("generate content: %w", err)
+			return nil, err
 		}
+	}
 
-		if resp == nil || resp.Content == nil {
-			continue
+	contents = append(contents, genai.NewContentFromText(joinParagraphs(outcome, input, req.Prompt), genai.RoleUser))
+
+	for _, failure := range req.Failures {
+		followUp, err := templater.Render(fixInstructionTemplate, failure)
+		if err != nil {
+			return nil, err
 		}
 
-		for _, part := range resp.Content.Parts {
-			if part.Text != "" {
-				sb.WriteString(part.Text)
-			}
+		contents = append(contents,
+			genai.NewContentFromText(failure.Command, genai.RoleModel),
+			genai.NewContentFromText(followUp, genai.RoleUser),
+		)
+	}
+
+	return contents, nil
+}
+
+func joinParagraphs(values ...string) string {
+	paragraphs := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			paragraphs = append(paragraphs, value)
 		}
 	}
 
-	return SanitizeCommand(sb.String()), nil
+	return strings.Join(paragraphs, "\n\n")
 }
 
 func currentDir() string {
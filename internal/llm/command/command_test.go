@@ -0,0 +1,296 @@
+package command_test
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/metalagman/aida/internal/llm/command"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+type fakeModel struct {
+	reply    string
+	replies  []string
+	partials []string
+	request  *model.LLMRequest
+	stream   bool
+	usage    *genai.GenerateContentResponseUsageMetadata
+}
+
+func (m *fakeModel) Name() string {
+	return "fake"
+}
+
+func (m *fakeModel) GenerateContent(
+	_ context.Context,
+	req *model.LLMRequest,
+	stream bool,
+) iter.Seq2[*model.LLMResponse, error] {
+	m.request = req
+	m.stream = stream
+
+	replies := m.replies
+	if replies == nil {
+		replies = []string{m.reply}
+	}
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, partial := range m.partials {
+			resp := &model.LLMResponse{Content: genai.NewContentFromText(partial, genai.RoleModel), Partial: true}
+			if !yield(resp, nil) {
+				return
+			}
+		}
+
+		for _, reply := range replies {
+			resp := &model.LLMResponse{Content: genai.NewContentFromText(reply, genai.RoleModel), UsageMetadata: m.usage}
+			if !yield(resp, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestGenerateCommandWithModel(t *testing.T) {
+	llm := &fakeModel{reply: "```sh\nls -la\n```"}
+
+	got, err := command.GenerateCommandWithModel(context.Background(), llm, provider.Request{Prompt: "list files"})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Candidate{{Command: "ls -la"}}, got.Candidates)
+	assert.Zero(t, llm.request.Config.CandidateCount)
+
+	require.Len(t, llm.request.Contents, 1)
+	assert.Equal(t, genai.RoleUser, llm.request.Contents[0].Role)
+	assert.Equal(t, "list files", llm.request.Contents[0].Parts[0].Text)
+	assert.Contains(t, llm.request.Config.SystemInstruction.Parts[0].Text, "shell command generator")
+}
+
+func TestGenerateCommandWithModelStreams(t *testing.T) {
+	tests := []struct {
+		name     string
+		partials []string
+		want     []string
+	}{
+		{
+			name:     "partials",
+			partials: []string{"```sh\n", "ls ", "-la\n```"},
+			want:     []string{"```sh\n", "```sh\nls ", "```sh\nls -la\n```"},
+		},
+		{
+			name: "whole answer",
+			want: []string{"```sh\nls -la\n```"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &fakeModel{reply: "```sh\nls -la\n```", partials: tt.partials}
+
+			var got []string
+
+			candidates, err := command.GenerateCommandWithModel(context.Background(), llm, provider.Request{
+				Prompt:    "list files",
+				OnPartial: func(text string) { got = append(got, text) },
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []provider.Candidate{{Command: "ls -la"}}, candidates.Candidates)
+			assert.True(t, llm.stream)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGenerateCommandWithModelUsage(t *testing.T) {
+	llm := &fakeModel{reply: "ls -la", usage: &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     120,
+		CandidatesTokenCount: 4,
+		ThoughtsTokenCount:   30,
+	}}
+
+	got, err := command.GenerateCommandWithModel(context.Background(), llm, provider.Request{Prompt: "list files"})
+	require.NoError(t, err)
+	assert.Equal(t, provider.Usage{Model: "fake", PromptTokens: 120, CompletionTokens: 34}, got.Usage)
+}
+
+func TestGenerateCommandWithModelReplaysFailures(t *testing.T) {
+	llm := &fakeModel{reply: "ls -G"}
+
+	got, err := command.GenerateCommandWithModel(context.Background(), llm, provider.Request{
+		Prompt: "list files",
+		Failures: []provider.Failure{
+			{Command: "ls --color", ExitCode: 2, Stderr: "ls: unrecognized option"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Candidate{{Command: "ls -G"}}, got.Candidates)
+
+	contents := llm.request.Contents
+	require.Len(t, contents, 3)
+	assert.Equal(t, genai.RoleModel, contents[1].Role)
+	assert.Equal(t, "ls --color", contents[1].Parts[0].Text)
+	assert.Equal(t, genai.RoleUser, contents[2].Role)
+	assert.Contains(t, contents[2].Parts[0].Text, "exited with status 2")
+	assert.Contains(t, contents[2].Parts[0].Text, "ls: unrecognized option")
+}
+
+func TestGenerateCommandWithModelHistory(t *testing.T) {
+	llm := &fakeModel{reply: "find . -name '*.log' -size +10M"}
+
+	_, err := command.GenerateCommandWithModel(context.Background(), llm, provider.Request{
+		Prompt: "now only the ones bigger than 10MB",
+		History: []provider.Turn{
+			{Prompt: "find log files", Command: "find . -name '*.log'", Ran: true, Output: "./app.log\n"},
+			{Prompt: "and delete them", Command: "find . -name '*.log' -delete"},
+		},
+	})
+	require.NoError(t, err)
+
+	contents := llm.request.Contents
+	require.Len(t, contents, 5)
+	assert.Equal(t, "find log files", contents[0].Parts[0].Text)
+	assert.Equal(t, genai.RoleModel, contents[1].Role)
+	assert.Equal(t, "find . -name '*.log'", contents[1].Parts[0].Text)
+	assert.Equal(t, "The command exited with status 0.\nOutput (tail):\n./app.log\n\n\nand delete them",
+		contents[2].Parts[0].Text)
+	assert.Equal(t, genai.RoleUser, contents[4].Role)
+	assert.Equal(t, "The command was not run.\n\nnow only the ones bigger than 10MB", contents[4].Parts[0].Text)
+}
+
+func TestGenerateCommandWithModelInput(t *testing.T) {
+	llm := &fakeModel{reply: "systemctl restart api"}
+
+	_, err := command.GenerateCommandWithModel(context.Background(), llm, provider.Request{
+		Prompt: "find the failing service and restart it",
+		Input:  "api.service: Main process exited, code=exited",
+	})
+	require.NoError(t, err)
+
+	contents := llm.request.Contents
+	require.Len(t, contents, 1)
+	assert.Equal(t, "Input piped to aida, for context. The command will not receive it on stdin:\n"+
+		"<input>\napi.service: Main process exited, code=exited\n</input>\n\n"+
+		"find the failing service and restart it", contents[0].Parts[0].Text)
+}
+
+func TestGenerateCommandWithModelEnvironment(t *testing.T) {
+	llm := &fakeModel{reply: "rg TODO"}
+
+	_, err := command.GenerateCommandWithModel(context.Background(), llm, provider.Request{
+		Prompt:      "find TODOs",
+		Environment: []string{"Distro: Ubuntu 24.04 LTS", "Installed tools: rg"},
+	})
+	require.NoError(t, err)
+
+	instruction := llm.request.Config.SystemInstruction.Parts[0].Text
+	assert.Contains(t, instruction, "\n- CWD: ")
+	assert.True(t, strings.HasSuffix(instruction, "\n- Distro: Ubuntu 24.04 LTS\n- Installed tools: rg"), instruction)
+}
+
+func TestGenerateCommandWithModelInstructions(t *testing.T) {
+	llm := &fakeModel{reply: "task build"}
+
+	_, err := command.GenerateCommandWithModel(context.Background(), llm, provider.Request{
+		Prompt:       "build the project",
+		Environment:  []string{"Installed tools: task"},
+		Instructions: "This repo uses task, not make.",
+	})
+	require.NoError(t, err)
+
+	instruction := llm.request.Config.SystemInstruction.Parts[0].Text
+	assert.True(t, strings.HasSuffix(instruction,
+		"\n- Installed tools: task\n\nProject instructions:\nThis repo uses task, not make."), instruction)
+}
+
+func TestGenerateCommandWithModelCandidates(t *testing.T) {
+	llm := &fakeModel{replies: []string{
+		"find . -name '*.log' -exec rm {} +\n# delete matches with find -exec",
+		"```sh\nfind . -name '*.log' -print0 | xargs -0 rm\n# pipe matches to xargs\n```",
+		"find . -name '*.log' -exec rm {} +\n# same command again",
+	}}
+
+	got, err := command.GenerateCommandWithModel(context.Background(), llm, provider.Request{
+		Prompt:     "delete log files",
+		Candidates: 3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Candidate{
+		{Command: "find . -name '*.log' -exec rm {} +", Description: "delete matches with find -exec"},
+		{Command: "find . -name '*.log' -print0 | xargs -0 rm", Description: "pipe matches to xargs"},
+	}, got.Candidates)
+	assert.Equal(t, int32(3), llm.request.Config.CandidateCount)
+	assert.Contains(t, llm.request.Config.SystemInstruction.Parts[0].Text, `starting with "# "`)
+}
+
+func TestParseCandidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  provider.Candidate
+	}{
+		{
+			name:  "command only",
+			input: "ls -la",
+			want:  provider.Candidate{Command: "ls -la"},
+		},
+		{
+			name:  "with description",
+			input: "ls -la\n# long listing",
+			want:  provider.Candidate{Command: "ls -la", Description: "long listing"},
+		},
+		{
+			name:  "multi-line command",
+			input: "for f in *; do\n  echo \"$f\"\ndone\n# loop over files",
+			want:  provider.Candidate{Command: "for f in *; do\n  echo \"$f\"\ndone", Description: "loop over files"},
+		},
+		{
+			name:  "comment is not last",
+			input: "# list\nls",
+			want:  provider.Candidate{Command: "# list\nls"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, command.ParseCandidate(tt.input))
+		})
+	}
+}
+
+func TestExplainCommandWithModel(t *testing.T) {
+	llm := &fakeModel{reply: "```json\n" + `{
+		"summary": "Lists files by size",
+		"segments": [
+			{"text": "ls -la", "kind": "command", "explanation": "list all files"},
+			{"text": "|", "kind": "pipe", "explanation": "send output to sort"}
+		],
+		"side_effects": []
+	}` + "\n```", usage: &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     200,
+		CandidatesTokenCount: 60,
+	}}
+
+	got, err := command.ExplainCommandWithModel(context.Background(), llm, "ls -la | sort -k5 -n")
+	require.NoError(t, err)
+	assert.Equal(t, "Lists files by size", got.Summary)
+	require.Len(t, got.Segments, 2)
+	assert.Equal(t, provider.Segment{Text: "|", Kind: "pipe", Explanation: "send output to sort"}, got.Segments[1])
+	assert.Empty(t, got.SideEffects)
+	assert.Equal(t, provider.Usage{Model: "fake", PromptTokens: 200, CompletionTokens: 60}, got.Usage)
+
+	assert.Equal(t, "ls -la | sort -k5 -n", llm.request.Contents[0].Parts[0].Text)
+	assert.Equal(t, "application/json", llm.request.Config.ResponseMIMEType)
+}
+
+func TestParseExplanationInvalidJSON(t *testing.T) {
+	_, err := command.ParseExplanation("this is not json")
+	require.Error(t, err)
+
+	_, err = command.ParseExplanation("  ")
+	require.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/llm/templater"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+const explainInstructionTemplate = `You explain shell commands to people who are about to run them.
+Break the command into segments: every program invocation, flag, argument,
+pipeline stage, redirection and control operator. Describe anything the
+command changes outside of printing output (files written or deleted,
+processes, network access, privileges) as side effects.
+
+Respond with a single JSON object and nothing else, using this schema:
+{"summary": string, "segments": [{"text": string, "kind": string, "explanation": string}], "side_effects": [string]}
+Use one of these kinds: command, flag, argument, pipe, redirection, operator, substitution.
+
+Environment:
+- OS: {{.OS}}
+- Arch: {{.Arch}}
+- Shell: {{.Shell}}
+- CWD: {{.CWD}}`
+
+// ExplainCommandWithModel asks the model for a structured explanation of a shell command.
+func ExplainCommandWithModel(ctx context.Context, llmModel model.LLM, shellCommand string) (provider.Explanation, error) {
+	shellCommand = strings.TrimSpace(shellCommand)
+	if shellCommand == "" {
+		return provider.Explanation{}, fmt.Errorf("command is required")
+	}
+
+	systemInstruction, err := templater.Render(explainInstructionTemplate, environment())
+	if err != nil {
+		return provider.Explanation{}, err
+	}
+
+	texts, usage, err := generateTexts(ctx, llmModel, []*genai.Content{
+		genai.NewContentFromText(shellCommand, genai.RoleUser),
+	}, &genai.GenerateContentConfig{
+		SystemInstruction: systemContent(systemInstruction),
+		ResponseMIMEType:  "application/json",
+	}, nil)
+	if err != nil {
+		return provider.Explanation{Usage: usage}, err
+	}
+
+	var text string
+	if len(texts) > 0 {
+		text = texts[0]
+	}
+
+	explanation, err := ParseExplanation(text)
+	explanation.Usage = usage
+
+	return explanation, err
+}
+
+// ParseExplanation decodes a model response into an explanation, tolerating code fences.
+func ParseExplanation(input string) (provider.Explanation, error) {
+	var explanation provider.Explanation
+
+	body := SanitizeCommand(input)
+	if body == "" {
+		return explanation, fmt.Errorf("empty explanation")
+	}
+
+	if err := json.Unmarshal([]byte(body), &explanation); err != nil {
+		return explanation, fmt.Errorf("parse explanation: %w", err)
+	}
+
+	return explanation, nil
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"github.com/metalagman/aida/internal/llm/provider"
+)
+
+// chainEntry is one provider of a fallback chain with the model it was built for.
+type chainEntry struct {
+	provider Provider
+	model    string
+}
+
+// fallbackProvider tries providers in order and moves on to the next one when
+// a provider is unavailable: rejected credentials, rate limits, exhausted
+// quota, server errors, timeouts or an empty answer. Other errors are returned as they are.
+type fallbackProvider struct {
+	entries []chainEntry
+	verbose io.Writer
+}
+
+func (p *fallbackProvider) GenerateCommand(ctx context.Context, req provider.Request) (provider.Result, error) {
+	return tryEach(ctx, p, func(entry chainEntry) (provider.Result, error) {
+		return entry.provider.GenerateCommand(ctx, req)
+	})
+}
+
+func (p *fallbackProvider) ExplainCommand(ctx context.Context, command string) (provider.Explanation, error) {
+	return tryEach(ctx, p, func(entry chainEntry) (provider.Explanation, error) {
+		return entry.provider.ExplainCommand(ctx, command)
+	})
+}
+
+// Name returns the name of the first provider of the chain.
+func (p *fallbackProvider) Name() string {
+	return p.entries[0].provider.Name()
+}
+
+func tryEach[T any](ctx context.Context, p *fallbackProvider, call func(chainEntry) (T, error)) (T, error) {
+	var (
+		zero T
+		errs []error
+	)
+
+	for i, entry := range p.entries {
+		result, err := call(entry)
+		if err == nil {
+			p.logf("Answered by %s (%s)\n", entry.provider.Name(), entry.model)
+
+			return result, nil
+		}
+
+		if ctx.Err() != nil || !shouldFailOver(err) {
+			return zero, err
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", entry.provider.Name(), err))
+
+		if next := i + 1; next < len(p.entries) {
+			p.logf("%s failed: %v; falling back to %s\n", entry.provider.Name(), err, p.entries[next].provider.Name())
+		}
+	}
+
+	if len(errs) == 1 {
+		return zero, errors.Unwrap(errs[0])
+	}
+
+	return zero, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+func (p *fallbackProvider) logf(format string, args ...any) {
+	if p.verbose != nil {
+		_, _ = fmt.Fprintf(p.verbose, format, args...)
+	}
+}
+
+// shouldFailOver reports whether err means the provider is unavailable, so
+// that another provider may still answer the same request.
+func shouldFailOver(err error) bool {
+	if errors.Is(err, llmerr.ErrAuth) ||
+		errors.Is(err, llmerr.ErrRateLimited) ||
+		errors.Is(err, llmerr.ErrQuotaExceeded) ||
+		errors.Is(err, llmerr.ErrEmptyResponse) {
+		return true
+	}
+
+	var apiErr *llmerr.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusRequestTimeout || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,70 @@
+// Package llmerr defines the errors returned by LLM providers, so that callers
+// can tell failures apart without matching on message text.
+package llmerr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrAuth means the credentials were missing, invalid or lack permission.
+	ErrAuth = errors.New("authentication failed")
+	// ErrRateLimited means too many requests were sent in a short time.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrQuotaExceeded means the account ran out of quota or credits.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrModelNotFound means the configured model does not exist or is not available.
+	ErrModelNotFound = errors.New("model not found")
+	// ErrContextTooLong means the request does not fit in the model's context window.
+	ErrContextTooLong = errors.New("context too long")
+	// ErrSafetyBlocked means the prompt or the answer was blocked by a safety filter.
+	ErrSafetyBlocked = errors.New("blocked by safety filter")
+	// ErrEmptyResponse means the model answered without any usable content.
+	ErrEmptyResponse = errors.New("empty response")
+)
+
+// APIError is returned when a provider API answers with a non-2xx status.
+// errors.Is matches it against its Kind.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	// Code is the error type or code reported by the API, if any.
+	Code string
+	// Message is the error reported by the API, or the raw response body.
+	Message string
+	// Kind is one of the sentinel errors of this package, or nil when the
+	// failure does not match any of them.
+	Kind error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s request failed: %s", e.Provider, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// KindForStatus returns the sentinel error matching an HTTP status, or nil.
+func KindForStatus(status int) error {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrAuth
+	case http.StatusNotFound:
+		return ErrModelNotFound
+	case http.StatusRequestEntityTooLarge:
+		return ErrContextTooLong
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	default:
+		return nil
+	}
+}
+
+// Safety returns an error reporting that provider blocked the request or the
+// answer, giving reason as the provider's explanation.
+func Safety(provider, reason string) error {
+	return fmt.Errorf("%w by %s: %s", ErrSafetyBlocked, provider, reason)
+}
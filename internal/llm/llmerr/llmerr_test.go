@@ -0,0 +1,49 @@
+package llmerr_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError(t *testing.T) {
+	err := fmt.Errorf("generate content: %w", &llmerr.APIError{
+		Provider:   "openai",
+		StatusCode: http.StatusTooManyRequests,
+		Message:    "slow down",
+		Kind:       llmerr.KindForStatus(http.StatusTooManyRequests),
+	})
+
+	assert.ErrorIs(t, err, llmerr.ErrRateLimited)
+	assert.NotErrorIs(t, err, llmerr.ErrAuth)
+	assert.Equal(t, "generate content: openai request failed: slow down", err.Error())
+
+	var apiErr *llmerr.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+}
+
+func TestKindForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, llmerr.ErrAuth},
+		{http.StatusForbidden, llmerr.ErrAuth},
+		{http.StatusNotFound, llmerr.ErrModelNotFound},
+		{http.StatusRequestEntityTooLarge, llmerr.ErrContextTooLong},
+		{http.StatusTooManyRequests, llmerr.ErrRateLimited},
+		{http.StatusBadRequest, nil},
+		{http.StatusInternalServerError, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			assert.Equal(t, tt.want, llmerr.KindForStatus(tt.status))
+		})
+	}
+}
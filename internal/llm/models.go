@@ -8,22 +8,29 @@ import (
 	"github.com/metalagman/aida/internal/config"
 	"github.com/metalagman/aida/internal/llm/provider"
 	"github.com/metalagman/aida/internal/llm/providers/aistudio"
+	"github.com/metalagman/aida/internal/llm/providers/anthropic"
+	"github.com/metalagman/aida/internal/llm/providers/ollama"
 	"github.com/metalagman/aida/internal/llm/providers/openai"
 )
 
 type ModelInfo = provider.ModelInfo
 
 func ListModels(ctx context.Context, provider string, cfg config.ProviderConfig) ([]ModelInfo, error) {
-	provider = config.NormalizeProviderName(provider)
-	if provider == "" {
+	if strings.TrimSpace(provider) == "" {
 		return nil, fmt.Errorf("provider name is required")
 	}
 
-	switch provider {
-	case "aistudio":
+	switch config.ProviderType(provider, cfg) {
+	case config.ProviderAIStudio:
 		return aistudio.ListModels(ctx, cfg)
-	case "openai":
+	case config.ProviderOpenAI:
 		return openai.ListModels(ctx, cfg)
+	case config.ProviderAnthropic:
+		return anthropic.ListModels(ctx, cfg)
+	case config.ProviderOllama:
+		cfg.Host = ollamaHost(cfg)
+
+		return ollama.ListModels(ctx, cfg)
 	default:
 		return nil, fmt.Errorf("unsupported provider %q", provider)
 	}
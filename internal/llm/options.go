@@ -0,0 +1,19 @@
+package llm
+
+import (
+	"io"
+
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/metalagman/aida/internal/llm/redact"
+)
+
+//go:generate go tool options-gen -from-struct=Options -out-filename=options_generated.go
+type Options struct {
+	// verbose receives which provider and model answered, and why a provider
+	// was skipped.
+	verbose io.Writer `validate:"omitempty"`
+	// cache answers repeated requests of every provider from disk.
+	cache *cache.Store `validate:"omitempty"`
+	// redactor masks secrets in the requests of every provider.
+	redactor *redact.Redactor `validate:"omitempty"`
+}
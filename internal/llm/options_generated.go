@@ -0,0 +1,73 @@
+// Code generated by options-gen v0.55.3. DO NOT EDIT.
+
+package llm
+
+import (
+	fmt461e464ebed9 "fmt"
+	"io"
+
+	errors461e464ebed9 "github.com/kazhuravlev/options-gen/pkg/errors"
+	validator461e464ebed9 "github.com/kazhuravlev/options-gen/pkg/validator"
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/metalagman/aida/internal/llm/redact"
+)
+
+type OptOptionsSetter func(o *Options)
+
+func NewOptions(
+	options ...OptOptionsSetter,
+) Options {
+	var o Options
+
+	// Setting defaults from field tag (if present)
+
+	for _, opt := range options {
+		opt(&o)
+	}
+	return o
+}
+
+// verbose receives which provider and model answered, and why a provider
+// was skipped.
+func WithVerbose(opt io.Writer) OptOptionsSetter {
+	return func(o *Options) { o.verbose = opt }
+}
+
+// cache answers repeated requests of every provider from disk.
+func WithCache(opt *cache.Store) OptOptionsSetter {
+	return func(o *Options) { o.cache = opt }
+}
+
+// redactor masks secrets in the requests of every provider.
+func WithRedactor(opt *redact.Redactor) OptOptionsSetter {
+	return func(o *Options) { o.redactor = opt }
+}
+
+func (o *Options) Validate() error {
+	errs := new(errors461e464ebed9.ValidationErrors)
+	errs.Add(errors461e464ebed9.NewValidationError("verbose", _validate_Options_verbose(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("cache", _validate_Options_cache(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("redactor", _validate_Options_redactor(o)))
+	return errs.AsError()
+}
+
+func _validate_Options_verbose(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.verbose, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `verbose` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_cache(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.cache, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `cache` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_redactor(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.redactor, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `redactor` did not pass the test: %w", err)
+	}
+	return nil
+}
@@ -7,24 +7,102 @@ import (
 	"github.com/metalagman/aida/internal/config"
 	"github.com/metalagman/aida/internal/llm/provider"
 	"github.com/metalagman/aida/internal/llm/providers/aistudio"
+	"github.com/metalagman/aida/internal/llm/providers/anthropic"
+	"github.com/metalagman/aida/internal/llm/providers/ollama"
 	"github.com/metalagman/aida/internal/llm/providers/openai"
 )
 
 type Provider = provider.Provider
 
-// NewProvider constructs a provider based on config.
-func NewProvider(ctx context.Context, cfg *config.Config) (Provider, error) {
-	name, active, err := cfg.ActiveProvider()
+// NewProvider constructs a provider based on config. When fallback providers
+// are configured, or a verbose writer is given, the result tries the chain of
+// providers in order. Fallbacks that cannot be built are left out of the chain
+// and reported on the verbose writer; only a broken primary is an error.
+func NewProvider(ctx context.Context, cfg *config.Config, options ...OptOptionsSetter) (Provider, error) {
+	opts := NewOptions(options...)
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	chain, err := cfg.ProviderChain()
 	if err != nil {
 		return nil, err
 	}
 
-	switch name {
-	case "aistudio":
-		return aistudio.NewProvider(ctx, active.APIKey, active.Model)
-	case "openai":
-		return openai.NewProvider(active.APIKey, active.Model)
+	entries := make([]chainEntry, 0, len(chain))
+
+	for i, named := range chain {
+		p, err := newProvider(ctx, named.Name, named.Config, opts)
+
+		switch {
+		case err != nil && i == 0:
+			return nil, fmt.Errorf("provider %s: %w", named.Name, err)
+		case err != nil:
+			if opts.verbose != nil {
+				_, _ = fmt.Fprintf(opts.verbose, "Skipping fallback provider %s: %v\n", named.Name, err)
+			}
+
+			continue
+		}
+
+		entries = append(entries, chainEntry{provider: p, model: named.Config.Model})
+	}
+
+	if len(entries) == 1 && opts.verbose == nil {
+		return entries[0].provider, nil
+	}
+
+	return &fallbackProvider{entries: entries, verbose: opts.verbose}, nil
+}
+
+// newProvider constructs the provider for a named config entry. Custom entries
+// are built by the provider their type selects.
+func newProvider(
+	ctx context.Context,
+	name string,
+	active config.ProviderConfig,
+	opts Options,
+) (Provider, error) {
+	switch config.ProviderType(name, active) {
+	case config.ProviderAIStudio:
+		return aistudio.NewProvider(ctx, active.APIKey, active.Model,
+			aistudio.WithBaseURL(active.BaseURL),
+			aistudio.WithMaxRetries(active.Retries()),
+			aistudio.WithCache(opts.cache),
+			aistudio.WithRedactor(opts.redactor),
+		)
+	case config.ProviderOpenAI:
+		return openai.NewProvider(active.APIKey, active.Model,
+			openai.WithBaseURL(active.BaseURL),
+			openai.WithName(name),
+			openai.WithStreamUsage(config.NormalizeProviderName(name) == config.ProviderOpenAI),
+			openai.WithMaxRetries(active.Retries()),
+			openai.WithCache(opts.cache),
+			openai.WithRedactor(opts.redactor),
+		)
+	case config.ProviderAnthropic:
+		return anthropic.NewProvider(active.APIKey, active.Model,
+			anthropic.WithBaseURL(active.BaseURL),
+			anthropic.WithMaxRetries(active.Retries()),
+			anthropic.WithCache(opts.cache),
+			anthropic.WithRedactor(opts.redactor),
+		)
+	case config.ProviderOllama:
+		return ollama.NewProvider(ollamaHost(active), active.Model,
+			ollama.WithMaxRetries(active.Retries()),
+			ollama.WithCache(opts.cache),
+			ollama.WithRedactor(opts.redactor),
+		)
 	default:
 		return nil, fmt.Errorf("unsupported llm provider %q", name)
 	}
 }
+
+// ollamaHost returns the configured Ollama address; base_url works as an alias of host.
+func ollamaHost(cfg config.ProviderConfig) string {
+	if cfg.Host != "" {
+		return cfg.Host
+	}
+
+	return cfg.BaseURL
+}
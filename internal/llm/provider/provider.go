@@ -2,14 +2,108 @@ package provider
 
 import "context"
 
-// Provider generates a single shell command from a user prompt.
+// CachedMetadataKey is set in the CustomMetadata of model responses served
+// from a cache rather than by the provider.
+const CachedMetadataKey = "aida_cached"
+
+// Provider generates shell commands from a user prompt.
 type Provider interface {
-	GenerateCommand(ctx context.Context, prompt string) (string, error)
+	GenerateCommand(ctx context.Context, req Request) (Result, error)
+	ExplainCommand(ctx context.Context, command string) (Explanation, error)
 	Name() string
 }
 
+// Request describes a single command generation call.
+type Request struct {
+	Prompt string
+	// Input holds an excerpt of data piped to aida. It is sent to the model as
+	// context for the prompt.
+	Input string
+	// Environment lists facts about the local machine, such as the distro and
+	// the tools on $PATH, as "Name: value" lines for the system instruction.
+	Environment []string
+	// Instructions are project-specific guidance for the system instruction,
+	// such as the build tool a repository uses.
+	Instructions string
+	// Failures lists previously generated commands that exited with a non-zero
+	// status, oldest first. They are replayed to the model as follow-up turns.
+	Failures []Failure
+	// History lists earlier turns of an interactive session, oldest first, so
+	// that follow-up prompts can refine previous commands.
+	History []Turn
+	// Candidates is the number of alternative commands to ask for. Values
+	// below 2 ask for a single command.
+	Candidates int
+	// OnPartial, when set, asks for a streamed answer and is called with the
+	// raw text received so far. Models that cannot stream call it once with
+	// the whole answer. It is ignored when several candidates are requested.
+	OnPartial func(text string)
+}
+
+// Turn is one finished prompt of an interactive session.
+type Turn struct {
+	Prompt string
+	// Command is the last command proposed for the prompt.
+	Command string
+	// Ran reports whether Command was executed.
+	Ran      bool
+	ExitCode int
+	// Output holds a bounded tail of the command's combined output.
+	Output string
+}
+
+// Result is the outcome of a command generation call.
+type Result struct {
+	Candidates []Candidate
+	Usage      Usage
+}
+
+// Usage reports the tokens a call consumed and which model answered it.
+// Token counts are zero when the provider does not report them.
+type Usage struct {
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	// Cached is set when the answer was reused from the local cache, so no
+	// tokens were spent on it.
+	Cached bool
+}
+
+// Candidate is one generated command.
+type Candidate struct {
+	Command string
+	// Description is a one-line summary of the command. It is only filled in
+	// when several candidates were requested.
+	Description string
+}
+
+// Failure describes a generated command that exited with a non-zero status.
+type Failure struct {
+	Command  string
+	ExitCode int
+	// Stderr holds a bounded tail of the command's standard error.
+	Stderr string
+}
+
 type ModelInfo struct {
 	Name             string
 	DisplayName      string
 	SupportedActions []string
 }
+
+// Explanation is a structured breakdown of an existing shell command.
+type Explanation struct {
+	Summary     string    `json:"summary"`
+	Segments    []Segment `json:"segments"`
+	SideEffects []string  `json:"side_effects"`
+	// Usage reports the tokens the explanation consumed.
+	Usage Usage `json:"-"`
+}
+
+// Segment explains one part of a command: a pipeline stage, flag, argument or redirection.
+type Segment struct {
+	Text        string `json:"text"`
+	Kind        string `json:"kind"`
+	Explanation string `json:"explanation"`
+}
@@ -0,0 +1,184 @@
+package llm_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm"
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chatServer(t *testing.T, status int, content string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if status != http.StatusOK {
+			http.Error(w, `{"error":{"message":"unavailable"}}`, status)
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"content": content}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func chainConfig(urls ...string) *config.Config {
+	cfg := &config.Config{Providers: map[string]config.ProviderConfig{}}
+	names := []string{"first", "second", "third"}
+	noRetries := 0
+
+	for i, url := range urls {
+		cfg.Providers[names[i]] = config.ProviderConfig{
+			Type:       config.ProviderTypeOpenAICompatible,
+			APIKey:     "key",
+			Model:      names[i] + "-model",
+			BaseURL:    url,
+			MaxRetries: &noRetries,
+		}
+	}
+
+	cfg.DefaultProvider = names[0]
+	cfg.FallbackProviders = names[1:len(urls)]
+
+	return cfg
+}
+
+func TestNewProviderFallsBack(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		wantErr  bool
+		wantLog  string
+		wantCmds []provider.Candidate
+	}{
+		{
+			name:     "rate limited",
+			status:   http.StatusTooManyRequests,
+			wantLog:  "Answered by second (second-model)",
+			wantCmds: []provider.Candidate{{Command: "ls"}},
+		},
+		{
+			name:     "server error",
+			status:   http.StatusBadGateway,
+			wantLog:  "falling back to second",
+			wantCmds: []provider.Candidate{{Command: "ls"}},
+		},
+		{
+			name:     "unauthorized",
+			status:   http.StatusUnauthorized,
+			wantLog:  "Answered by second (second-model)",
+			wantCmds: []provider.Candidate{{Command: "ls"}},
+		},
+		{
+			name:    "bad request",
+			status:  http.StatusBadRequest,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := chatServer(t, tt.status, "")
+			second := chatServer(t, http.StatusOK, "ls")
+
+			var log bytes.Buffer
+
+			p, err := llm.NewProvider(context.Background(), chainConfig(first.URL, second.URL), llm.WithVerbose(&log))
+			require.NoError(t, err)
+			assert.Equal(t, "first", p.Name())
+
+			cmds, err := p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files"})
+			if tt.wantErr {
+				require.Error(t, err)
+
+				var apiErr *llmerr.APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tt.status, apiErr.StatusCode)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCmds, cmds.Candidates)
+			assert.Contains(t, log.String(), tt.wantLog)
+		})
+	}
+}
+
+func TestNewProviderEmptyResponseFallsBack(t *testing.T) {
+	first := chatServer(t, http.StatusOK, "   ")
+	second := chatServer(t, http.StatusOK, "pwd")
+
+	p, err := llm.NewProvider(context.Background(), chainConfig(first.URL, second.URL))
+	require.NoError(t, err)
+
+	cmds, err := p.GenerateCommand(context.Background(), provider.Request{Prompt: "where am I"})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Candidate{{Command: "pwd"}}, cmds.Candidates)
+}
+
+func TestNewProviderAllFail(t *testing.T) {
+	first := chatServer(t, http.StatusServiceUnavailable, "")
+	second := chatServer(t, http.StatusTooManyRequests, "")
+
+	p, err := llm.NewProvider(context.Background(), chainConfig(first.URL, second.URL))
+	require.NoError(t, err)
+
+	_, err = p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "all providers failed")
+	assert.Contains(t, err.Error(), "first:")
+	assert.Contains(t, err.Error(), "second:")
+}
+
+func TestNewProviderSingle(t *testing.T) {
+	server := chatServer(t, http.StatusOK, "ls")
+
+	p, err := llm.NewProvider(context.Background(), chainConfig(server.URL))
+	require.NoError(t, err)
+	assert.Equal(t, "first", p.Name())
+}
+
+func TestNewProviderSkipsBrokenFallback(t *testing.T) {
+	server := chatServer(t, http.StatusOK, "ls")
+
+	cfg := chainConfig(server.URL, "http://localhost:1")
+	second := cfg.Providers["second"]
+	second.Model = ""
+	cfg.Providers["second"] = second
+
+	var log bytes.Buffer
+
+	p, err := llm.NewProvider(context.Background(), cfg, llm.WithVerbose(&log))
+	require.NoError(t, err)
+	assert.Contains(t, log.String(), "Skipping fallback provider second:")
+
+	cmds, err := p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files"})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Candidate{{Command: "ls"}}, cmds.Candidates)
+}
+
+func TestNewProviderBrokenPrimary(t *testing.T) {
+	cfg := chainConfig("http://localhost:1", "http://localhost:2")
+	first := cfg.Providers["first"]
+	first.Model = ""
+	cfg.Providers["first"] = first
+
+	_, err := llm.NewProvider(context.Background(), cfg)
+	require.ErrorContains(t, err, "provider first:")
+}
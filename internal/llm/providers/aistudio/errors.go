@@ -0,0 +1,60 @@
+package aistudio
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"google.golang.org/genai"
+)
+
+// apiError converts a Gemini API error into an *llmerr.APIError. Other errors
+// are returned unchanged.
+func apiError(err error) error {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	return &llmerr.APIError{
+		Provider:   "aistudio",
+		StatusCode: apiErr.Code,
+		Code:       apiErr.Status,
+		Message:    apiErr.Message,
+		Kind:       errorKind(apiErr),
+	}
+}
+
+func errorKind(apiErr genai.APIError) error {
+	message := strings.ToLower(apiErr.Message)
+
+	switch {
+	case apiErr.Status == "UNAUTHENTICATED", apiErr.Status == "PERMISSION_DENIED",
+		// Gemini rejects unknown API keys with 400 INVALID_ARGUMENT.
+		strings.Contains(message, "api key"):
+		return llmerr.ErrAuth
+	case apiErr.Status == "RESOURCE_EXHAUSTED":
+		return llmerr.ErrRateLimited
+	case apiErr.Status == "NOT_FOUND":
+		return llmerr.ErrModelNotFound
+	case apiErr.Code == http.StatusBadRequest && strings.Contains(message, "token") &&
+		strings.Contains(message, "exceed"):
+		return llmerr.ErrContextTooLong
+	default:
+		return llmerr.KindForStatus(apiErr.Code)
+	}
+}
+
+// safetyFinish reports whether a candidate was stopped by a safety filter.
+func safetyFinish(reason genai.FinishReason) bool {
+	switch reason {
+	case genai.FinishReasonSafety,
+		genai.FinishReasonBlocklist,
+		genai.FinishReasonProhibitedContent,
+		genai.FinishReasonSPII:
+		return true
+	default:
+		return false
+	}
+}
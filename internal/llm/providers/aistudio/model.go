@@ -0,0 +1,218 @@
+package aistudio
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Model adapts the Gemini API to the ADK model.LLM interface. Unlike the ADK
+// gemini model it yields one response per candidate, so CandidateCount works.
+type Model struct {
+	name   string
+	client *genai.Client
+}
+
+// NewModel creates a model.LLM adapter backed by the Gemini API.
+func NewModel(ctx context.Context, modelName string, cfg *genai.ClientConfig) (*Model, error) {
+	client, err := genai.NewClient(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create genai client: %w", err)
+	}
+
+	return &Model{
+		name:   modelName,
+		client: client,
+	}, nil
+}
+
+func (m *Model) Name() string {
+	return m.name
+}
+
+// GenerateContent calls the Gemini API. When stream is set the answer is read
+// incrementally: a partial response is yielded for every text chunk of the
+// first candidate, followed by one complete response per candidate.
+func (m *Model) GenerateContent(
+	ctx context.Context,
+	req *model.LLMRequest,
+	stream bool,
+) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var (
+			resp *genai.GenerateContentResponse
+			err  error
+		)
+
+		if stream {
+			resp, err = m.stream(ctx, req, yield)
+		} else {
+			resp, err = m.client.Models.GenerateContent(ctx, m.name, req.Contents, req.Config)
+		}
+
+		if err != nil {
+			yield(nil, fmt.Errorf("call gemini model: %w", apiError(err)))
+
+			return
+		}
+
+		if resp == nil {
+			return
+		}
+
+		yieldCandidates(resp, yield)
+	}
+}
+
+// stream reads a streamed answer, yielding partial responses as they arrive,
+// and returns the chunks merged into a single response. It returns nil once
+// yield asks to stop.
+func (m *Model) stream(
+	ctx context.Context,
+	req *model.LLMRequest,
+	yield func(*model.LLMResponse, error) bool,
+) (*genai.GenerateContentResponse, error) {
+	merged := &genai.GenerateContentResponse{}
+	texts := map[int32]string{}
+
+	for chunk, err := range m.client.Models.GenerateContentStream(ctx, m.name, req.Contents, req.Config) {
+		if err != nil {
+			return nil, err
+		}
+
+		if chunk.PromptFeedback != nil {
+			merged.PromptFeedback = chunk.PromptFeedback
+		}
+
+		if chunk.UsageMetadata != nil {
+			merged.UsageMetadata = chunk.UsageMetadata
+		}
+
+		for _, candidate := range chunk.Candidates {
+			if candidate == nil {
+				continue
+			}
+
+			merged.Candidates = mergeCandidate(merged.Candidates, candidate)
+
+			text := candidateText(candidate)
+			if text == "" {
+				continue
+			}
+
+			texts[candidate.Index] += text
+
+			if candidate.Index != 0 {
+				continue
+			}
+
+			if !yield(&model.LLMResponse{
+				Content: &genai.Content{
+					Role:  genai.RoleModel,
+					Parts: []*genai.Part{{Text: text}},
+				},
+				Partial: true,
+			}, nil) {
+				return nil, nil
+			}
+		}
+	}
+
+	for _, candidate := range merged.Candidates {
+		if text := texts[candidate.Index]; text != "" {
+			candidate.Content = &genai.Content{
+				Role:  genai.RoleModel,
+				Parts: []*genai.Part{{Text: text}},
+			}
+		}
+	}
+
+	return merged, nil
+}
+
+// mergeCandidate records the finish reason of a streamed candidate chunk,
+// keeping one entry per candidate index.
+func mergeCandidate(candidates []*genai.Candidate, chunk *genai.Candidate) []*genai.Candidate {
+	for _, candidate := range candidates {
+		if candidate.Index == chunk.Index {
+			if chunk.FinishReason != "" {
+				candidate.FinishReason = chunk.FinishReason
+			}
+
+			return candidates
+		}
+	}
+
+	return append(candidates, &genai.Candidate{
+		Index:        chunk.Index,
+		FinishReason: chunk.FinishReason,
+	})
+}
+
+func candidateText(candidate *genai.Candidate) string {
+	if candidate.Content == nil {
+		return ""
+	}
+
+	var text string
+
+	for _, part := range candidate.Content.Parts {
+		if part != nil && !part.Thought {
+			text += part.Text
+		}
+	}
+
+	return text
+}
+
+// yieldCandidates yields one complete response per answered candidate, or the
+// reason nothing was answered.
+func yieldCandidates(resp *genai.GenerateContentResponse, yield func(*model.LLMResponse, error) bool) {
+	if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+		yield(nil, llmerr.Safety("aistudio", string(resp.PromptFeedback.BlockReason)))
+
+		return
+	}
+
+	var (
+		answered bool
+		blocked  genai.FinishReason
+	)
+
+	for _, candidate := range resp.Candidates {
+		if candidate == nil {
+			continue
+		}
+
+		if candidate.Content == nil {
+			if safetyFinish(candidate.FinishReason) {
+				blocked = candidate.FinishReason
+			}
+
+			continue
+		}
+
+		answered = true
+
+		if !yield(&model.LLMResponse{
+			Content:       candidate.Content,
+			UsageMetadata: resp.UsageMetadata,
+			FinishReason:  candidate.FinishReason,
+			TurnComplete:  true,
+		}, nil) {
+			return
+		}
+	}
+
+	switch {
+	case answered:
+	case blocked != "":
+		yield(nil, llmerr.Safety("aistudio", string(blocked)))
+	default:
+		yield(nil, fmt.Errorf("%w from gemini", llmerr.ErrEmptyResponse))
+	}
+}
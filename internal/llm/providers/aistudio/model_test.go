@@ -0,0 +1,185 @@
+package aistudio_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"github.com/metalagman/aida/internal/llm/providers/aistudio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	adkmodel "google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestModel_GenerateContentCandidates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			GenerationConfig struct {
+				CandidateCount int `json:"candidateCount"`
+			} `json:"generationConfig"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, 2, payload.GenerationConfig.CandidateCount)
+		assert.Contains(t, r.URL.Path, "gemini-test:generateContent")
+
+		resp := map[string]any{
+			"candidates": []map[string]any{
+				{"content": map[string]any{"role": "model", "parts": []map[string]any{{"text": "ls -la"}}}},
+				{"content": map[string]any{"role": "model", "parts": []map[string]any{{"text": "ls -lah"}}}},
+			},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	m, err := aistudio.NewModel(context.Background(), "gemini-test", &genai.ClientConfig{
+		APIKey:      "test-key",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	require.NoError(t, err)
+
+	req := &adkmodel.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("list files", genai.RoleUser)},
+		Config:   &genai.GenerateContentConfig{CandidateCount: 2},
+	}
+
+	var got []string
+
+	for resp, err := range m.GenerateContent(context.Background(), req, false) {
+		require.NoError(t, err)
+
+		got = append(got, resp.Content.Parts[0].Text)
+	}
+
+	assert.Equal(t, []string{"ls -la", "ls -lah"}, got)
+}
+
+func TestModel_GenerateContentStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "gemini-test:streamGenerateContent")
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		for _, chunk := range []string{
+			`{"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"ls "}]}}]}`,
+			`{"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"-la"}]},"finishReason":"STOP"}]}`,
+		} {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	}))
+	defer server.Close()
+
+	m, err := aistudio.NewModel(context.Background(), "gemini-test", &genai.ClientConfig{
+		APIKey:      "test-key",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	require.NoError(t, err)
+
+	req := &adkmodel.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("list files", genai.RoleUser)},
+		Config:   &genai.GenerateContentConfig{},
+	}
+
+	var partials, complete []string
+
+	for resp, err := range m.GenerateContent(context.Background(), req, true) {
+		require.NoError(t, err)
+
+		if resp.Partial {
+			partials = append(partials, resp.Content.Parts[0].Text)
+
+			continue
+		}
+
+		assert.Equal(t, genai.FinishReasonStop, resp.FinishReason)
+		complete = append(complete, resp.Content.Parts[0].Text)
+	}
+
+	assert.Equal(t, []string{"ls ", "-la"}, partials)
+	assert.Equal(t, []string{"ls -la"}, complete)
+}
+
+func TestModel_GenerateContentErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{
+			name:    "invalid key",
+			status:  http.StatusBadRequest,
+			body:    `{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","status":"INVALID_ARGUMENT"}}`,
+			wantErr: llmerr.ErrAuth,
+		},
+		{
+			name:    "exhausted",
+			status:  http.StatusTooManyRequests,
+			body:    `{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}`,
+			wantErr: llmerr.ErrRateLimited,
+		},
+		{
+			name:    "model",
+			status:  http.StatusNotFound,
+			body:    `{"error":{"code":404,"message":"models/gemini-test is not found","status":"NOT_FOUND"}}`,
+			wantErr: llmerr.ErrModelNotFound,
+		},
+		{
+			name:    "blocked prompt",
+			status:  http.StatusOK,
+			body:    `{"promptFeedback":{"blockReason":"SAFETY"}}`,
+			wantErr: llmerr.ErrSafetyBlocked,
+		},
+		{
+			name:    "blocked answer",
+			status:  http.StatusOK,
+			body:    `{"candidates":[{"finishReason":"SAFETY"}]}`,
+			wantErr: llmerr.ErrSafetyBlocked,
+		},
+		{
+			name:    "empty",
+			status:  http.StatusOK,
+			body:    `{"candidates":[]}`,
+			wantErr: llmerr.ErrEmptyResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			m, err := aistudio.NewModel(context.Background(), "gemini-test", &genai.ClientConfig{
+				APIKey:      "test-key",
+				Backend:     genai.BackendGeminiAPI,
+				HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+			})
+			require.NoError(t, err)
+
+			req := &adkmodel.LLMRequest{
+				Contents: []*genai.Content{genai.NewContentFromText("list files", genai.RoleUser)},
+			}
+
+			var gotErr error
+
+			for _, err := range m.GenerateContent(context.Background(), req, false) {
+				if err != nil {
+					gotErr = err
+				}
+			}
+
+			require.ErrorIs(t, gotErr, tt.wantErr)
+		})
+	}
+}
@@ -3,7 +3,6 @@ package aistudio
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/metalagman/aida/internal/config"
 	"github.com/metalagman/aida/internal/llm/provider"
@@ -13,12 +12,7 @@ import (
 const defaultPageSize = 100
 
 func ListModels(ctx context.Context, cfg config.ProviderConfig) ([]provider.ModelInfo, error) {
-	var clientConfig *genai.ClientConfig
-	if strings.TrimSpace(cfg.APIKey) != "" {
-		clientConfig = &genai.ClientConfig{APIKey: cfg.APIKey}
-	}
-
-	client, err := genai.NewClient(ctx, clientConfig)
+	client, err := genai.NewClient(ctx, clientConfig(cfg.APIKey, cfg.BaseURL, cfg.Retries()))
 	if err != nil {
 		return nil, fmt.Errorf("create genai client: %w", err)
 	}
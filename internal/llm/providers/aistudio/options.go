@@ -1,7 +1,19 @@
 package aistudio
 
+import (
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/metalagman/aida/internal/llm/redact"
+)
+
 //go:generate go tool options-gen -from-struct=Options -out-filename=options_generated.go
 type Options struct {
-	apiKey string `validate:"omitempty"`
-	model  string `option:"mandatory"   validate:"required"`
+	apiKey  string `validate:"omitempty"`
+	model   string `option:"mandatory"   validate:"required"`
+	baseURL string `validate:"omitempty"`
+	// maxRetries is how many times a transient failure is retried.
+	maxRetries int `validate:"omitempty"`
+	// cache, when set, answers repeated requests without calling the API.
+	cache *cache.Store `validate:"omitempty"`
+	// redactor, when set, masks secrets in requests and restores them in answers.
+	redactor *redact.Redactor `validate:"omitempty"`
 }
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/metalagman/aida/internal/llm/cache"
 	"github.com/metalagman/aida/internal/llm/command"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/llm/redact"
+	"github.com/metalagman/aida/internal/llm/retry"
 	"google.golang.org/adk/model"
-	"google.golang.org/adk/model/gemini"
 	"google.golang.org/genai"
 )
 
@@ -16,33 +19,59 @@ type Provider struct {
 	model model.LLM
 }
 
-func NewProvider(ctx context.Context, apiKey string, modelName string) (*Provider, error) {
+func NewProvider(ctx context.Context, apiKey string, modelName string, options ...OptOptionsSetter) (*Provider, error) {
 	opts := NewOptions(
 		modelName,
-		WithApiKey(apiKey),
+		append([]OptOptionsSetter{WithApiKey(apiKey)}, options...)...,
 	)
 	if err := opts.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid options: %w", err)
 	}
 
-	var cfg *genai.ClientConfig
-	if strings.TrimSpace(opts.apiKey) != "" {
-		cfg = &genai.ClientConfig{APIKey: opts.apiKey}
-	}
-
-	m, err := gemini.NewModel(ctx, opts.model, cfg)
+	m, err := NewModel(ctx, opts.model, clientConfig(opts.apiKey, opts.baseURL, opts.maxRetries))
 	if err != nil {
 		return nil, fmt.Errorf("create gemini model: %w", err)
 	}
 
 	return &Provider{
 		opts:  opts,
-		model: m,
+		model: redact.Wrap(cache.Wrap(m, opts.cache, "aistudio"), opts.redactor),
 	}, nil
 }
 
-func (p *Provider) GenerateCommand(ctx context.Context, prompt string) (string, error) {
-	return command.GenerateCommandWithModel(ctx, p.model, prompt)
+func (p *Provider) GenerateCommand(ctx context.Context, req provider.Request) (provider.Result, error) {
+	result, err := command.GenerateCommandWithModel(ctx, p.model, req)
+	result.Usage.Provider = p.Name()
+
+	return result, err
+}
+
+func (p *Provider) ExplainCommand(ctx context.Context, shellCommand string) (provider.Explanation, error) {
+	explanation, err := command.ExplainCommandWithModel(ctx, p.model, shellCommand)
+	explanation.Usage.Provider = p.Name()
+
+	return explanation, err
+}
+
+// clientConfig returns the genai client config for the key, endpoint and
+// retry count, or nil to let genai read its settings from the environment.
+func clientConfig(apiKey, baseURL string, maxRetries int) *genai.ClientConfig {
+	if strings.TrimSpace(apiKey) == "" && strings.TrimSpace(baseURL) == "" && maxRetries <= 0 {
+		return nil
+	}
+
+	cfg := &genai.ClientConfig{
+		APIKey:      apiKey,
+		HTTPOptions: genai.HTTPOptions{BaseURL: strings.TrimSpace(baseURL)},
+	}
+
+	if maxRetries > 0 {
+		// The genai client has no overall timeout; requests are bounded by
+		// the context deadline instead.
+		cfg.HTTPClient = retry.NewClient(0, maxRetries)
+	}
+
+	return cfg
 }
 
 func (p *Provider) Name() string {
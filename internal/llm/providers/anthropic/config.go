@@ -0,0 +1,27 @@
+package anthropic
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+	anthropicVersion        = "2023-06-01"
+)
+
+// resolveBaseURL returns the API base URL without a trailing slash, falling
+// back to the Anthropic endpoint.
+func resolveBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return defaultAnthropicBaseURL
+	}
+
+	return baseURL
+}
+
+func setAnthropicHeaders(req *http.Request, apiKey string) {
+	req.Header.Set("X-Api-Key", apiKey)
+	req.Header.Set("Anthropic-Version", anthropicVersion)
+}
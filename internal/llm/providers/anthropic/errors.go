@@ -0,0 +1,60 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+)
+
+// apiError converts an error response of the Messages API into an
+// *llmerr.APIError.
+func apiError(status int, respBody []byte) *llmerr.APIError {
+	var parsed anthropicErrorResponse
+
+	_ = json.Unmarshal(respBody, &parsed)
+
+	return &llmerr.APIError{
+		Provider:   "anthropic",
+		StatusCode: status,
+		Code:       parsed.Error.Type,
+		Message:    errorMessage(respBody),
+		Kind:       errorKind(status, parsed.Error.Type, parsed.Error.Message),
+	}
+}
+
+func errorKind(status int, errorType, message string) error {
+	message = strings.ToLower(message)
+
+	switch {
+	case errorType == "authentication_error", errorType == "permission_error":
+		return llmerr.ErrAuth
+	case errorType == "not_found_error":
+		return llmerr.ErrModelNotFound
+	case errorType == "rate_limit_error":
+		return llmerr.ErrRateLimited
+	case strings.Contains(message, "credit balance"):
+		return llmerr.ErrQuotaExceeded
+	case errorType == "request_too_large", strings.Contains(message, "prompt is too long"):
+		return llmerr.ErrContextTooLong
+	default:
+		return llmerr.KindForStatus(status)
+	}
+}
+
+// errorMessage extracts the message from an API error body, falling back to the raw body.
+func errorMessage(respBody []byte) string {
+	var parsed anthropicErrorResponse
+	if err := json.Unmarshal(respBody, &parsed); err == nil && parsed.Error.Message != "" {
+		return parsed.Error.Type + ": " + parsed.Error.Message
+	}
+
+	return strings.TrimSpace(string(respBody))
+}
+
+type anthropicErrorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
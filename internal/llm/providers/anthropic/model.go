@@ -0,0 +1,339 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strings"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"github.com/metalagman/aida/internal/llm/retry"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// defaultMaxTokens is sent when the request does not set MaxOutputTokens; the
+// Messages API requires a limit.
+const defaultMaxTokens = 1024
+
+// Model adapts Anthropic's Messages API to the ADK model.LLM interface.
+type Model struct {
+	name    string
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewAnthropicModel creates a model.LLM adapter backed by the Anthropic Messages API.
+func NewAnthropicModel(apiKey string, modelName string, options ...OptOptionsSetter) (*Model, error) {
+	return newModel(NewOptions(apiKey, modelName, options...))
+}
+
+// NewAnthropicModelWithClient creates a model.LLM adapter with a custom HTTP client.
+func NewAnthropicModelWithClient(apiKey string, modelName string, client *http.Client) (*Model, error) {
+	return NewAnthropicModel(apiKey, modelName, WithClient(client))
+}
+
+func newModel(opts Options) (*Model, error) {
+	if strings.TrimSpace(opts.apiKey) == "" {
+		return nil, fmt.Errorf("api_key is required for anthropic provider")
+	}
+
+	if strings.TrimSpace(opts.model) == "" {
+		return nil, fmt.Errorf("model is required for anthropic provider")
+	}
+
+	client := opts.client
+	if client == nil {
+		client = retry.NewClient(anthropicTimeout, opts.maxRetries)
+	}
+
+	return &Model{
+		name:    opts.model,
+		apiKey:  opts.apiKey,
+		baseURL: resolveBaseURL(opts.baseURL),
+		client:  client,
+	}, nil
+}
+
+func (m *Model) Name() string {
+	return m.name
+}
+
+// GenerateContent sends the request to the Messages API. The API has no
+// equivalent of CandidateCount, so one request is sent per candidate. Each response
+// reports the usage summed over the requests sent so far.
+func (m *Model) GenerateContent(
+	ctx context.Context,
+	req *model.LLMRequest,
+	stream bool,
+) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		payload, err := buildMessagesRequest(req, m.name)
+		if err != nil {
+			yield(nil, err)
+
+			return
+		}
+
+		count := 1
+		if req.Config != nil && req.Config.CandidateCount > 1 {
+			count = int(req.Config.CandidateCount)
+		}
+
+		var usage genai.GenerateContentResponseUsageMetadata
+
+		for range count {
+			resp, err := m.generate(ctx, payload)
+			if err == nil {
+				addUsage(&usage, resp)
+			}
+
+			if !yield(resp, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// addUsage adds the usage of resp to total and reports the sum on resp, so
+// that the last response carries the usage of every request sent for it.
+func addUsage(total *genai.GenerateContentResponseUsageMetadata, resp *model.LLMResponse) {
+	if resp.UsageMetadata == nil {
+		return
+	}
+
+	total.PromptTokenCount += resp.UsageMetadata.PromptTokenCount
+	total.CandidatesTokenCount += resp.UsageMetadata.CandidatesTokenCount
+	total.TotalTokenCount += resp.UsageMetadata.TotalTokenCount
+
+	sum := *total
+	resp.UsageMetadata = &sum
+}
+
+func (m *Model) generate(ctx context.Context, payload anthropicMessagesRequest) (*model.LLMResponse, error) {
+	respBody, err := m.doMessagesRequest(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMessagesResponse(respBody)
+}
+
+func buildMessagesRequest(req *model.LLMRequest, modelName string) (anthropicMessagesRequest, error) {
+	messages := anthropicMessagesFromRequest(req)
+	if len(messages) == 0 {
+		return anthropicMessagesRequest{}, fmt.Errorf("anthropic request missing content")
+	}
+
+	payload := anthropicMessagesRequest{
+		Model:     modelName,
+		Messages:  messages,
+		MaxTokens: defaultMaxTokens,
+	}
+
+	applyRequestConfig(&payload, req)
+
+	return payload, nil
+}
+
+func applyRequestConfig(payload *anthropicMessagesRequest, req *model.LLMRequest) {
+	if req == nil || req.Config == nil {
+		return
+	}
+
+	if req.Config.SystemInstruction != nil {
+		payload.System = strings.TrimSpace(contentText(req.Config.SystemInstruction))
+	}
+
+	if req.Config.Temperature != nil {
+		payload.Temperature = float64(*req.Config.Temperature)
+	}
+
+	if req.Config.TopP != nil {
+		payload.TopP = float64(*req.Config.TopP)
+	}
+
+	if req.Config.MaxOutputTokens > 0 {
+		payload.MaxTokens = req.Config.MaxOutputTokens
+	}
+
+	if len(req.Config.StopSequences) > 0 {
+		payload.StopSequences = req.Config.StopSequences
+	}
+}
+
+func (m *Model) doMessagesRequest(ctx context.Context, payload anthropicMessagesRequest) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal anthropic request: %w", err)
+	}
+
+	endpoint := m.baseURL + "/messages"
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create anthropic request: %w", err)
+	}
+
+	setAnthropicHeaders(httpReq, m.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send anthropic request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read anthropic response: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, apiError(resp.StatusCode, respBody)
+	}
+
+	return respBody, nil
+}
+
+func parseMessagesResponse(respBody []byte) (*model.LLMResponse, error) {
+	var parsed anthropicMessagesResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("parse anthropic response: %w", err)
+	}
+
+	var sb strings.Builder
+
+	for _, block := range parsed.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+
+	if strings.TrimSpace(sb.String()) == "" && parsed.StopReason == "refusal" {
+		return nil, llmerr.Safety("anthropic", "refusal")
+	}
+
+	if strings.TrimSpace(sb.String()) == "" {
+		return nil, fmt.Errorf("%w from anthropic", llmerr.ErrEmptyResponse)
+	}
+
+	return &model.LLMResponse{
+		Content: &genai.Content{
+			Role: "model",
+			Parts: []*genai.Part{
+				{Text: sb.String()},
+			},
+		},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     parsed.Usage.InputTokens,
+			CandidatesTokenCount: parsed.Usage.OutputTokens,
+			TotalTokenCount:      parsed.Usage.InputTokens + parsed.Usage.OutputTokens,
+		},
+		TurnComplete: true,
+	}, nil
+}
+
+// anthropicMessagesFromRequest converts the request contents to messages. The
+// Messages API requires alternating roles starting with the user, so
+// consecutive turns of the same role are merged.
+func anthropicMessagesFromRequest(req *model.LLMRequest) []anthropicMessage {
+	if req == nil {
+		return nil
+	}
+
+	var messages []anthropicMessage
+
+	for _, content := range req.Contents {
+		if content == nil {
+			continue
+		}
+
+		text := contentText(content)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		role := anthropicRole(content.Role)
+
+		if len(messages) == 0 && role != "user" {
+			continue
+		}
+
+		if last := len(messages) - 1; last >= 0 && messages[last].Role == role {
+			messages[last].Content += "\n\n" + text
+
+			continue
+		}
+
+		messages = append(messages, anthropicMessage{
+			Role:    role,
+			Content: text,
+		})
+	}
+
+	return messages
+}
+
+func contentText(content *genai.Content) string {
+	if content == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	for _, part := range content.Parts {
+		if part == nil || part.Text == "" {
+			continue
+		}
+
+		sb.WriteString(part.Text)
+	}
+
+	return sb.String()
+}
+
+func anthropicRole(role string) string {
+	switch strings.ToLower(strings.TrimSpace(role)) {
+	case "assistant", "model":
+		return "assistant"
+	default:
+		return "user"
+	}
+}
+
+type anthropicMessagesRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int32              `json:"max_tokens"`
+	Temperature   float64            `json:"temperature,omitempty"`
+	TopP          float64            `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicMessagesResponse struct {
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+}
+
+type anthropicContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int32 `json:"input_tokens"`
+	OutputTokens int32 `json:"output_tokens"`
+}
@@ -0,0 +1,76 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/metalagman/aida/internal/llm/providers/anthropic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	adkmodel "google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestAnthropicModel_GenerateContent(t *testing.T) {
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		var payload struct {
+			Messages []message `json:"messages"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+		// Leading model turns are dropped and consecutive user turns merged.
+		assert.Equal(t, []message{
+			{Role: "user", Content: "find logs\n\nonly big ones"},
+			{Role: "assistant", Content: "find . -name '*.log'"},
+			{Role: "user", Content: "it failed"},
+		}, payload.Messages)
+
+		resp := map[string]any{
+			"content": []map[string]any{{"type": "text", "text": "find . -name '*.log' -size +10M"}},
+			"usage":   map[string]any{"input_tokens": 20, "output_tokens": 8},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	m, err := anthropic.NewAnthropicModel("test-key", "claude-test", anthropic.WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	req := &adkmodel.LLMRequest{
+		Contents: []*genai.Content{
+			genai.NewContentFromText("hello", genai.RoleModel),
+			genai.NewContentFromText("find logs", genai.RoleUser),
+			genai.NewContentFromText("only big ones", genai.RoleUser),
+			genai.NewContentFromText("find . -name '*.log'", genai.RoleModel),
+			genai.NewContentFromText("it failed", genai.RoleUser),
+		},
+		Config: &genai.GenerateContentConfig{CandidateCount: 2},
+	}
+
+	var got []*adkmodel.LLMResponse
+
+	for resp, err := range m.GenerateContent(context.Background(), req, false) {
+		require.NoError(t, err)
+
+		got = append(got, resp)
+	}
+
+	require.Len(t, got, 2)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, "find . -name '*.log' -size +10M", got[0].Content.Parts[0].Text)
+	assert.Equal(t, int32(28), got[0].UsageMetadata.TotalTokenCount)
+	assert.Equal(t, &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount: 40, CandidatesTokenCount: 16, TotalTokenCount: 56,
+	}, got[1].UsageMetadata)
+}
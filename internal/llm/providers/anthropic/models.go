@@ -0,0 +1,130 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/llm/retry"
+)
+
+const defaultPageSize = 100
+
+type anthropicModelList struct {
+	Data    []anthropicModel `json:"data"`
+	HasMore bool             `json:"has_more"`
+	LastID  string           `json:"last_id"`
+}
+
+type anthropicModel struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+}
+
+func ListModels(ctx context.Context, cfg config.ProviderConfig) ([]provider.ModelInfo, error) {
+	apiKey := strings.TrimSpace(cfg.APIKey)
+	if apiKey == "" {
+		return nil, fmt.Errorf("api_key is required for anthropic provider")
+	}
+
+	client := retry.NewClient(anthropicTimeout, cfg.Retries())
+	baseURL := resolveBaseURL(cfg.BaseURL)
+
+	var (
+		models  []provider.ModelInfo
+		afterID string
+	)
+
+	for {
+		respBody, err := fetchModelList(ctx, client, baseURL, apiKey, afterID)
+		if err != nil {
+			return nil, err
+		}
+
+		list, err := parseModelList(respBody)
+		if err != nil {
+			return nil, err
+		}
+
+		models = append(models, modelInfos(list)...)
+
+		if !list.HasMore || list.LastID == "" {
+			return models, nil
+		}
+
+		afterID = list.LastID
+	}
+}
+
+func fetchModelList(ctx context.Context, client *http.Client, baseURL, apiKey, afterID string) ([]byte, error) {
+	query := url.Values{}
+	query.Set("limit", fmt.Sprint(defaultPageSize))
+
+	if afterID != "" {
+		query.Set("after_id", afterID)
+	}
+
+	endpoint := baseURL + "/models?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create anthropic request: %w", err)
+	}
+
+	setAnthropicHeaders(req, apiKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send anthropic request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read anthropic response: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, apiError(resp.StatusCode, respBody)
+	}
+
+	return respBody, nil
+}
+
+func parseModelList(respBody []byte) (anthropicModelList, error) {
+	var list anthropicModelList
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return list, fmt.Errorf("parse anthropic response: %w", err)
+	}
+
+	return list, nil
+}
+
+func modelInfos(list anthropicModelList) []provider.ModelInfo {
+	models := make([]provider.ModelInfo, 0, len(list.Data))
+
+	for _, model := range list.Data {
+		if strings.TrimSpace(model.ID) == "" {
+			continue
+		}
+
+		displayName := model.DisplayName
+		if displayName == "" {
+			displayName = model.ID
+		}
+
+		models = append(models, provider.ModelInfo{
+			Name:             model.ID,
+			DisplayName:      displayName,
+			SupportedActions: []string{"generateContent"},
+		})
+	}
+
+	return models
+}
@@ -0,0 +1,117 @@
+// Code generated by options-gen v0.55.3. DO NOT EDIT.
+
+package anthropic
+
+import (
+	fmt461e464ebed9 "fmt"
+	"net/http"
+
+	errors461e464ebed9 "github.com/kazhuravlev/options-gen/pkg/errors"
+	validator461e464ebed9 "github.com/kazhuravlev/options-gen/pkg/validator"
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/metalagman/aida/internal/llm/redact"
+)
+
+type OptOptionsSetter func(o *Options)
+
+func NewOptions(
+	apiKey string,
+	model string,
+	options ...OptOptionsSetter,
+) Options {
+	var o Options
+
+	// Setting defaults from field tag (if present)
+
+	o.apiKey = apiKey
+	o.model = model
+
+	for _, opt := range options {
+		opt(&o)
+	}
+	return o
+}
+
+func WithClient(opt *http.Client) OptOptionsSetter {
+	return func(o *Options) { o.client = opt }
+}
+
+func WithBaseURL(opt string) OptOptionsSetter {
+	return func(o *Options) { o.baseURL = opt }
+}
+
+// maxRetries is how many times a transient failure is retried when no client is given.
+func WithMaxRetries(opt int) OptOptionsSetter {
+	return func(o *Options) { o.maxRetries = opt }
+}
+
+// cache, when set, answers repeated requests without calling the API.
+func WithCache(opt *cache.Store) OptOptionsSetter {
+	return func(o *Options) { o.cache = opt }
+}
+
+// redactor, when set, masks secrets in requests and restores them in answers.
+func WithRedactor(opt *redact.Redactor) OptOptionsSetter {
+	return func(o *Options) { o.redactor = opt }
+}
+
+func (o *Options) Validate() error {
+	errs := new(errors461e464ebed9.ValidationErrors)
+	errs.Add(errors461e464ebed9.NewValidationError("apiKey", _validate_Options_apiKey(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("model", _validate_Options_model(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("client", _validate_Options_client(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("baseURL", _validate_Options_baseURL(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("maxRetries", _validate_Options_maxRetries(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("cache", _validate_Options_cache(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("redactor", _validate_Options_redactor(o)))
+	return errs.AsError()
+}
+
+func _validate_Options_apiKey(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.apiKey, "required"); err != nil {
+		return fmt461e464ebed9.Errorf("field `apiKey` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_model(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.model, "required"); err != nil {
+		return fmt461e464ebed9.Errorf("field `model` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_client(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.client, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `client` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_baseURL(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.baseURL, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `baseURL` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_maxRetries(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.maxRetries, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `maxRetries` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_cache(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.cache, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `cache` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_redactor(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.redactor, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `redactor` did not pass the test: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/metalagman/aida/internal/llm/command"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/llm/redact"
+	"google.golang.org/adk/model"
+)
+
+const (
+	anthropicTimeout = 30 * time.Second
+)
+
+type Provider struct {
+	opts  Options
+	model model.LLM
+}
+
+func NewProvider(apiKey string, model string, options ...OptOptionsSetter) (*Provider, error) {
+	opts := NewOptions(apiKey, model, options...)
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	anthropicModel, err := newModel(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		opts:  opts,
+		model: redact.Wrap(cache.Wrap(anthropicModel, opts.cache, "anthropic"), opts.redactor),
+	}, nil
+}
+
+// NewProviderWithClient creates an Anthropic provider with a custom HTTP client.
+func NewProviderWithClient(apiKey string, model string, client *http.Client) (*Provider, error) {
+	return NewProvider(apiKey, model, WithClient(client))
+}
+
+func (p *Provider) GenerateCommand(ctx context.Context, req provider.Request) (provider.Result, error) {
+	result, err := command.GenerateCommandWithModel(ctx, p.model, req)
+	result.Usage.Provider = p.Name()
+
+	return result, err
+}
+
+func (p *Provider) ExplainCommand(ctx context.Context, shellCommand string) (provider.Explanation, error) {
+	explanation, err := command.ExplainCommandWithModel(ctx, p.model, shellCommand)
+	explanation.Usage.Provider = p.Name()
+
+	return explanation, err
+}
+
+func (p *Provider) Name() string {
+	return "anthropic"
+}
@@ -0,0 +1,180 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/llm/providers/anthropic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnthropicProvider_GenerateCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "2023-06-01", r.Header.Get("Anthropic-Version"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var payload struct {
+			Model     string `json:"model"`
+			System    string `json:"system"`
+			MaxTokens int    `json:"max_tokens"`
+			Messages  []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "claude-test", payload.Model)
+		assert.Contains(t, payload.System, "shell command generator")
+		assert.Positive(t, payload.MaxTokens)
+
+		if assert.Len(t, payload.Messages, 1) {
+			assert.Equal(t, "user", payload.Messages[0].Role)
+			assert.Equal(t, "list files", payload.Messages[0].Content)
+		}
+
+		resp := map[string]any{
+			"content": []map[string]any{
+				{"type": "text", "text": "ls -la"},
+			},
+			"usage": map[string]any{"input_tokens": 12, "output_tokens": 3},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	p, err := anthropic.NewProvider("test-key", "claude-test", anthropic.WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	cmd, err := p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files"})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Candidate{{Command: "ls -la"}}, cmd.Candidates)
+	assert.Equal(t, provider.Usage{
+		Provider: "anthropic", Model: "claude-test", PromptTokens: 12, CompletionTokens: 3,
+	}, cmd.Usage)
+}
+
+func TestAnthropicProvider_GenerateCommandError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name:    "authentication",
+			status:  http.StatusUnauthorized,
+			body:    `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`,
+			wantErr: llmerr.ErrAuth,
+			wantMsg: "authentication_error: invalid x-api-key",
+		},
+		{
+			name:    "rate limit",
+			status:  http.StatusTooManyRequests,
+			body:    `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`,
+			wantErr: llmerr.ErrRateLimited,
+		},
+		{
+			name:    "credits",
+			status:  http.StatusBadRequest,
+			body:    `{"type":"error","error":{"type":"invalid_request_error","message":"Your credit balance is too low"}}`,
+			wantErr: llmerr.ErrQuotaExceeded,
+		},
+		{
+			name:    "model",
+			status:  http.StatusNotFound,
+			body:    `{"type":"error","error":{"type":"not_found_error","message":"model: claude-test"}}`,
+			wantErr: llmerr.ErrModelNotFound,
+		},
+		{
+			name:    "prompt too long",
+			status:  http.StatusBadRequest,
+			body:    `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 300000 tokens"}}`,
+			wantErr: llmerr.ErrContextTooLong,
+		},
+		{
+			name:    "refusal",
+			status:  http.StatusOK,
+			body:    `{"content":[],"stop_reason":"refusal"}`,
+			wantErr: llmerr.ErrSafetyBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p, err := anthropic.NewProvider("bad-key", "claude-test", anthropic.WithBaseURL(server.URL))
+			require.NoError(t, err)
+
+			_, err = p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files"})
+			require.ErrorIs(t, err, tt.wantErr)
+
+			if tt.wantMsg != "" {
+				require.ErrorContains(t, err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestNewAnthropicProvider(t *testing.T) {
+	t.Run("valid config", func(t *testing.T) {
+		p, err := anthropic.NewProvider("key", "model")
+		require.NoError(t, err)
+		assert.NotNil(t, p)
+		assert.Equal(t, "anthropic", p.Name())
+	})
+
+	t.Run("missing api key", func(t *testing.T) {
+		_, err := anthropic.NewProvider("", "model")
+		assert.Error(t, err)
+	})
+
+	t.Run("missing model", func(t *testing.T) {
+		_, err := anthropic.NewProvider("key", "")
+		assert.Error(t, err)
+	})
+}
+
+func TestListAnthropicModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("X-Api-Key"))
+
+		resp := map[string]any{
+			"data":     []map[string]any{{"id": "claude-sonnet-4-5", "display_name": "Claude Sonnet 4.5"}},
+			"has_more": true,
+			"last_id":  "claude-sonnet-4-5",
+		}
+		if r.URL.Query().Get("after_id") == "claude-sonnet-4-5" {
+			resp = map[string]any{
+				"data":     []map[string]any{{"id": "claude-haiku-4-5"}},
+				"has_more": false,
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	models, err := anthropic.ListModels(context.Background(), config.ProviderConfig{APIKey: "test-key", BaseURL: server.URL})
+	require.NoError(t, err)
+	require.Len(t, models, 2)
+	assert.Equal(t, "claude-sonnet-4-5", models[0].Name)
+	assert.Equal(t, "Claude Sonnet 4.5", models[0].DisplayName)
+	assert.Equal(t, "claude-haiku-4-5", models[1].Name)
+	assert.Equal(t, "claude-haiku-4-5", models[1].DisplayName)
+}
@@ -0,0 +1,27 @@
+package ollama
+
+import (
+	"os"
+	"strings"
+)
+
+const defaultOllamaHost = "http://localhost:11434"
+
+// ResolveHost returns the server address to use: the configured host, then
+// OLLAMA_HOST, then the default local address. A missing scheme means http.
+func ResolveHost(host string) string {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		host = strings.TrimSpace(os.Getenv("OLLAMA_HOST"))
+	}
+
+	if host == "" {
+		return defaultOllamaHost
+	}
+
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+
+	return strings.TrimRight(host, "/")
+}
@@ -0,0 +1,49 @@
+package ollama
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+)
+
+// apiError converts an error response of the Ollama API into an
+// *llmerr.APIError.
+func apiError(status int, respBody []byte) *llmerr.APIError {
+	message := errorMessage(respBody)
+
+	return &llmerr.APIError{
+		Provider:   "ollama",
+		StatusCode: status,
+		Message:    message,
+		Kind:       errorKind(status, message),
+	}
+}
+
+func errorKind(status int, message string) error {
+	message = strings.ToLower(message)
+
+	switch {
+	case status == http.StatusNotFound && strings.Contains(message, "model"):
+		return llmerr.ErrModelNotFound
+	case strings.Contains(message, "context length"):
+		return llmerr.ErrContextTooLong
+	default:
+		return llmerr.KindForStatus(status)
+	}
+}
+
+// errorMessage extracts the message from an API error body, falling back to the raw body.
+func errorMessage(respBody []byte) string {
+	var parsed ollamaErrorResponse
+	if err := json.Unmarshal(respBody, &parsed); err == nil && parsed.Error != "" {
+		return parsed.Error
+	}
+
+	return strings.TrimSpace(string(respBody))
+}
+
+type ollamaErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,303 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strings"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Model adapts Ollama's /api/chat endpoint to the ADK model.LLM interface.
+type Model struct {
+	name   string
+	host   string
+	client *http.Client
+}
+
+// NewOllamaModel creates a model.LLM adapter backed by an Ollama server.
+func NewOllamaModel(host string, modelName string) (*Model, error) {
+	return NewOllamaModelWithClient(host, modelName, nil)
+}
+
+// NewOllamaModelWithClient creates a model.LLM adapter with a custom HTTP client.
+func NewOllamaModelWithClient(host string, modelName string, client *http.Client) (*Model, error) {
+	if strings.TrimSpace(modelName) == "" {
+		return nil, fmt.Errorf("model is required for ollama provider")
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: ollamaTimeout}
+	}
+
+	return &Model{
+		name:   modelName,
+		host:   ResolveHost(host),
+		client: client,
+	}, nil
+}
+
+func (m *Model) Name() string {
+	return m.name
+}
+
+// GenerateContent sends the request to /api/chat. Ollama has no equivalent of
+// CandidateCount, so one request is sent per candidate. Each response
+// reports the usage summed over the requests sent so far.
+func (m *Model) GenerateContent(
+	ctx context.Context,
+	req *model.LLMRequest,
+	stream bool,
+) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		payload, err := buildChatRequest(req, m.name)
+		if err != nil {
+			yield(nil, err)
+
+			return
+		}
+
+		count := 1
+		if req.Config != nil && req.Config.CandidateCount > 1 {
+			count = int(req.Config.CandidateCount)
+		}
+
+		var usage genai.GenerateContentResponseUsageMetadata
+
+		for range count {
+			resp, err := m.generate(ctx, payload)
+			if err == nil {
+				addUsage(&usage, resp)
+			}
+
+			if !yield(resp, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// addUsage adds the usage of resp to total and reports the sum on resp, so
+// that the last response carries the usage of every request sent for it.
+func addUsage(total *genai.GenerateContentResponseUsageMetadata, resp *model.LLMResponse) {
+	if resp.UsageMetadata == nil {
+		return
+	}
+
+	total.PromptTokenCount += resp.UsageMetadata.PromptTokenCount
+	total.CandidatesTokenCount += resp.UsageMetadata.CandidatesTokenCount
+	total.TotalTokenCount += resp.UsageMetadata.TotalTokenCount
+
+	sum := *total
+	resp.UsageMetadata = &sum
+}
+
+func (m *Model) generate(ctx context.Context, payload ollamaChatRequest) (*model.LLMResponse, error) {
+	respBody, err := m.doChatRequest(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseChatResponse(respBody)
+}
+
+func buildChatRequest(req *model.LLMRequest, modelName string) (ollamaChatRequest, error) {
+	messages := ollamaMessagesFromRequest(req)
+	if len(messages) == 0 {
+		return ollamaChatRequest{}, fmt.Errorf("ollama request missing content")
+	}
+
+	payload := ollamaChatRequest{
+		Model:    modelName,
+		Messages: messages,
+		Stream:   false,
+	}
+
+	applyRequestConfig(&payload, req)
+
+	return payload, nil
+}
+
+func applyRequestConfig(payload *ollamaChatRequest, req *model.LLMRequest) {
+	if req == nil || req.Config == nil {
+		return
+	}
+
+	options := ollamaOptions{
+		NumPredict: req.Config.MaxOutputTokens,
+		Stop:       req.Config.StopSequences,
+	}
+
+	if req.Config.Temperature != nil {
+		options.Temperature = float64(*req.Config.Temperature)
+	}
+
+	if req.Config.TopP != nil {
+		options.TopP = float64(*req.Config.TopP)
+	}
+
+	if options.Temperature != 0 || options.TopP != 0 || options.NumPredict > 0 || len(options.Stop) > 0 {
+		payload.Options = &options
+	}
+
+	if req.Config.ResponseMIMEType == "application/json" {
+		payload.Format = "json"
+	}
+}
+
+func (m *Model) doChatRequest(ctx context.Context, payload ollamaChatRequest) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal ollama request: %w", err)
+	}
+
+	endpoint := m.host + "/api/chat"
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create ollama request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send ollama request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read ollama response: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, apiError(resp.StatusCode, respBody)
+	}
+
+	return respBody, nil
+}
+
+func parseChatResponse(respBody []byte) (*model.LLMResponse, error) {
+	var parsed ollamaChatResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("parse ollama response: %w", err)
+	}
+
+	if strings.TrimSpace(parsed.Message.Content) == "" {
+		return nil, fmt.Errorf("%w from ollama", llmerr.ErrEmptyResponse)
+	}
+
+	return &model.LLMResponse{
+		Content: &genai.Content{
+			Role: "model",
+			Parts: []*genai.Part{
+				{Text: parsed.Message.Content},
+			},
+		},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     parsed.PromptEvalCount,
+			CandidatesTokenCount: parsed.EvalCount,
+			TotalTokenCount:      parsed.PromptEvalCount + parsed.EvalCount,
+		},
+		TurnComplete: true,
+	}, nil
+}
+
+func ollamaMessagesFromRequest(req *model.LLMRequest) []ollamaMessage {
+	if req == nil {
+		return nil
+	}
+
+	var messages []ollamaMessage
+
+	if req.Config != nil && req.Config.SystemInstruction != nil {
+		text := contentText(req.Config.SystemInstruction)
+		if strings.TrimSpace(text) != "" {
+			messages = append(messages, ollamaMessage{
+				Role:    "system",
+				Content: text,
+			})
+		}
+	}
+
+	for _, content := range req.Contents {
+		if content == nil {
+			continue
+		}
+
+		text := contentText(content)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		messages = append(messages, ollamaMessage{
+			Role:    ollamaRole(content.Role),
+			Content: text,
+		})
+	}
+
+	return messages
+}
+
+func contentText(content *genai.Content) string {
+	if content == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	for _, part := range content.Parts {
+		if part == nil || part.Text == "" {
+			continue
+		}
+
+		sb.WriteString(part.Text)
+	}
+
+	return sb.String()
+}
+
+func ollamaRole(role string) string {
+	switch strings.ToLower(strings.TrimSpace(role)) {
+	case "assistant", "model":
+		return "assistant"
+	case "system":
+		return "system"
+	default:
+		return "user"
+	}
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"`
+	Options  *ollamaOptions  `json:"options,omitempty"`
+}
+
+type ollamaOptions struct {
+	Temperature float64  `json:"temperature,omitempty"`
+	TopP        float64  `json:"top_p,omitempty"`
+	NumPredict  int32    `json:"num_predict,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	PromptEvalCount int32         `json:"prompt_eval_count"`
+	EvalCount       int32         `json:"eval_count"`
+}
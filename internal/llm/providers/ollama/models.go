@@ -0,0 +1,79 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/llm/retry"
+)
+
+type ollamaTagList struct {
+	Models []ollamaTag `json:"models"`
+}
+
+type ollamaTag struct {
+	Name string `json:"name"`
+}
+
+// ListModels lists the models installed on the Ollama server.
+func ListModels(ctx context.Context, cfg config.ProviderConfig) ([]provider.ModelInfo, error) {
+	respBody, err := fetchTags(ctx, retry.NewClient(ollamaTimeout, cfg.Retries()), ResolveHost(cfg.Host))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTags(respBody)
+}
+
+func fetchTags(ctx context.Context, client *http.Client, host string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, host+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create ollama request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send ollama request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read ollama response: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, apiError(resp.StatusCode, respBody)
+	}
+
+	return respBody, nil
+}
+
+func parseTags(respBody []byte) ([]provider.ModelInfo, error) {
+	var list ollamaTagList
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, fmt.Errorf("parse ollama response: %w", err)
+	}
+
+	models := make([]provider.ModelInfo, 0, len(list.Models))
+
+	for _, model := range list.Models {
+		if strings.TrimSpace(model.Name) == "" {
+			continue
+		}
+
+		models = append(models, provider.ModelInfo{
+			Name:             model.Name,
+			DisplayName:      model.Name,
+			SupportedActions: []string{"generateContent"},
+		})
+	}
+
+	return models, nil
+}
@@ -0,0 +1,21 @@
+package ollama
+
+import (
+	"net/http"
+
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/metalagman/aida/internal/llm/redact"
+)
+
+//go:generate go tool options-gen -from-struct=Options -out-filename=options_generated.go
+type Options struct {
+	host   string       `validate:"omitempty"`
+	model  string       `option:"mandatory"   validate:"required"`
+	client *http.Client `validate:"omitempty"`
+	// maxRetries is how many times a transient failure is retried when no client is given.
+	maxRetries int `validate:"omitempty"`
+	// cache, when set, answers repeated requests without calling the API.
+	cache *cache.Store `validate:"omitempty"`
+	// redactor, when set, masks secrets in requests and restores them in answers.
+	redactor *redact.Redactor `validate:"omitempty"`
+}
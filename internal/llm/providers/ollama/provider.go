@@ -0,0 +1,71 @@
+package ollama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/metalagman/aida/internal/llm/command"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/llm/redact"
+	"github.com/metalagman/aida/internal/llm/retry"
+	"google.golang.org/adk/model"
+)
+
+// ollamaTimeout is generous because local models may need to be loaded first.
+const ollamaTimeout = 2 * time.Minute
+
+type Provider struct {
+	opts  Options
+	model model.LLM
+}
+
+func NewProvider(host string, model string, options ...OptOptionsSetter) (*Provider, error) {
+	opts := NewOptions(
+		model,
+		append([]OptOptionsSetter{WithHost(host)}, options...)...,
+	)
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	client := opts.client
+	if client == nil {
+		client = retry.NewClient(ollamaTimeout, opts.maxRetries)
+	}
+
+	ollamaModel, err := NewOllamaModelWithClient(opts.host, opts.model, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		opts:  opts,
+		model: redact.Wrap(cache.Wrap(ollamaModel, opts.cache, "ollama"), opts.redactor),
+	}, nil
+}
+
+// NewProviderWithClient creates an Ollama provider with a custom HTTP client.
+func NewProviderWithClient(host string, model string, client *http.Client) (*Provider, error) {
+	return NewProvider(host, model, WithClient(client))
+}
+
+func (p *Provider) GenerateCommand(ctx context.Context, req provider.Request) (provider.Result, error) {
+	result, err := command.GenerateCommandWithModel(ctx, p.model, req)
+	result.Usage.Provider = p.Name()
+
+	return result, err
+}
+
+func (p *Provider) ExplainCommand(ctx context.Context, shellCommand string) (provider.Explanation, error) {
+	explanation, err := command.ExplainCommandWithModel(ctx, p.model, shellCommand)
+	explanation.Usage.Provider = p.Name()
+
+	return explanation, err
+}
+
+func (p *Provider) Name() string {
+	return "ollama"
+}
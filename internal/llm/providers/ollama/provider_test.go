@@ -0,0 +1,150 @@
+package ollama_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/llm/providers/ollama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaProvider_GenerateCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var payload struct {
+			Model    string `json:"model"`
+			Stream   bool   `json:"stream"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "llama3.2", payload.Model)
+		assert.False(t, payload.Stream)
+
+		if assert.Len(t, payload.Messages, 2) {
+			assert.Equal(t, "system", payload.Messages[0].Role)
+			assert.Equal(t, "user", payload.Messages[1].Role)
+			assert.Equal(t, "list files", payload.Messages[1].Content)
+		}
+
+		resp := map[string]any{
+			"message":           map[string]any{"role": "assistant", "content": "ls -la"},
+			"done":              true,
+			"prompt_eval_count": 30,
+			"eval_count":        4,
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	// The scheme is optional, as with OLLAMA_HOST.
+	p, err := ollama.NewProvider(strings.TrimPrefix(server.URL, "http://"), "llama3.2")
+	require.NoError(t, err)
+
+	cmd, err := p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files"})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Candidate{{Command: "ls -la"}}, cmd.Candidates)
+	assert.Equal(t, provider.Usage{
+		Provider: "ollama", Model: "llama3.2", PromptTokens: 30, CompletionTokens: 4,
+	}, cmd.Usage)
+}
+
+func TestOllamaProvider_GenerateCommandCandidatesUsage(t *testing.T) {
+	commands := []string{"ls -la\n# long listing", "ls -1\n# one per line", "find . -maxdepth 1\n# find"}
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		resp := map[string]any{
+			"message":           map[string]any{"role": "assistant", "content": commands[requests]},
+			"done":              true,
+			"prompt_eval_count": 30,
+			"eval_count":        4,
+		}
+		requests++
+
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	p, err := ollama.NewProvider(server.URL, "llama3.2")
+	require.NoError(t, err)
+
+	cmd, err := p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files", Candidates: 3})
+	require.NoError(t, err)
+	assert.Len(t, cmd.Candidates, 3)
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, provider.Usage{
+		Provider: "ollama", Model: "llama3.2", PromptTokens: 90, CompletionTokens: 12,
+	}, cmd.Usage)
+}
+
+func TestOllamaProvider_GenerateCommandError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	p, err := ollama.NewProvider(server.URL, "missing")
+	require.NoError(t, err)
+
+	_, err = p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files"})
+	require.ErrorContains(t, err, `model "missing" not found`)
+	require.ErrorIs(t, err, llmerr.ErrModelNotFound)
+}
+
+func TestNewOllamaProvider(t *testing.T) {
+	t.Run("no api key needed", func(t *testing.T) {
+		p, err := ollama.NewProvider("", "llama3.2")
+		require.NoError(t, err)
+		assert.Equal(t, "ollama", p.Name())
+	})
+
+	t.Run("missing model", func(t *testing.T) {
+		_, err := ollama.NewProvider("", "")
+		assert.Error(t, err)
+	})
+}
+
+func TestResolveHost(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", "")
+	assert.Equal(t, "http://localhost:11434", ollama.ResolveHost(""))
+	assert.Equal(t, "http://gpu-box:11434", ollama.ResolveHost("gpu-box:11434"))
+	assert.Equal(t, "https://ollama.internal", ollama.ResolveHost("https://ollama.internal/"))
+
+	t.Setenv("OLLAMA_HOST", "127.0.0.1:11500")
+	assert.Equal(t, "http://127.0.0.1:11500", ollama.ResolveHost(""))
+}
+
+func TestListOllamaModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/tags", r.URL.Path)
+
+		resp := map[string]any{
+			"models": []map[string]any{
+				{"name": "llama3.2:latest"},
+				{"name": "qwen2.5-coder:7b"},
+			},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	models, err := ollama.ListModels(context.Background(), config.ProviderConfig{Host: server.URL})
+	require.NoError(t, err)
+	require.Len(t, models, 2)
+	assert.Equal(t, "llama3.2:latest", models[0].Name)
+	assert.Equal(t, "qwen2.5-coder:7b", models[1].Name)
+}
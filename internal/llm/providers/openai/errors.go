@@ -0,0 +1,60 @@
+package openai
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/metalagman/aida/internal/llm/llmerr"
+)
+
+// apiError converts an error response of the chat completions API into an
+// *llmerr.APIError.
+func apiError(providerName string, status int, respBody []byte) *llmerr.APIError {
+	var parsed openAIErrorResponse
+
+	_ = json.Unmarshal(respBody, &parsed)
+
+	message := strings.TrimSpace(parsed.Error.Message)
+	if message == "" {
+		message = strings.TrimSpace(string(respBody))
+	}
+
+	code := strings.Trim(string(parsed.Error.Code), `"`)
+	if code == "" || code == "null" {
+		code = parsed.Error.Type
+	}
+
+	return &llmerr.APIError{
+		Provider:   providerName,
+		StatusCode: status,
+		Code:       code,
+		Message:    message,
+		Kind:       errorKind(status, code),
+	}
+}
+
+func errorKind(status int, code string) error {
+	switch code {
+	case "invalid_api_key":
+		return llmerr.ErrAuth
+	case "insufficient_quota":
+		return llmerr.ErrQuotaExceeded
+	case "model_not_found":
+		return llmerr.ErrModelNotFound
+	case "context_length_exceeded", "string_above_max_length":
+		return llmerr.ErrContextTooLong
+	case "content_filter", "content_policy_violation":
+		return llmerr.ErrSafetyBlocked
+	default:
+		return llmerr.KindForStatus(status)
+	}
+}
+
+type openAIErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		// Code is a string for OpenAI but a number for some compatible servers.
+		Code json.RawMessage `json:"code"`
+	} `json:"error"`
+}
@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -10,40 +11,61 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"github.com/metalagman/aida/internal/llm/retry"
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
 )
 
 // Model adapts OpenAI's chat completions to the ADK model.LLM interface.
 type Model struct {
-	name   string
-	apiKey string
-	client *http.Client
+	name    string
+	apiKey  string
+	baseURL string
+	client  *http.Client
+	// provider names the config entry in errors, "openai" by default.
+	provider string
+	// streamUsage adds stream_options.include_usage to streamed requests.
+	streamUsage bool
 }
 
-// NewOpenAIModel creates a model.LLM adapter backed by OpenAI chat completions.
-func NewOpenAIModel(apiKey string, modelName string) (*Model, error) {
-	return NewOpenAIModelWithClient(apiKey, modelName, nil)
+// NewOpenAIModel creates a model.LLM adapter backed by OpenAI chat completions
+// or any API compatible with them.
+func NewOpenAIModel(apiKey string, modelName string, options ...OptOptionsSetter) (*Model, error) {
+	return newModel(NewOptions(apiKey, modelName, options...))
 }
 
 // NewOpenAIModelWithClient creates a model.LLM adapter with a custom HTTP client.
 func NewOpenAIModelWithClient(apiKey string, modelName string, client *http.Client) (*Model, error) {
-	if strings.TrimSpace(apiKey) == "" {
+	return NewOpenAIModel(apiKey, modelName, WithClient(client))
+}
+
+func newModel(opts Options) (*Model, error) {
+	if strings.TrimSpace(opts.apiKey) == "" && opts.baseURL == "" {
 		return nil, fmt.Errorf("api_key is required for openai provider")
 	}
 
-	if strings.TrimSpace(modelName) == "" {
+	if strings.TrimSpace(opts.model) == "" {
 		return nil, fmt.Errorf("model is required for openai provider")
 	}
 
+	client := opts.client
 	if client == nil {
-		client = &http.Client{Timeout: openAITimeout}
+		client = retry.NewClient(openAITimeout, opts.maxRetries)
+	}
+
+	providerName := opts.name
+	if providerName == "" {
+		providerName = "openai"
 	}
 
 	return &Model{
-		name:   modelName,
-		apiKey: apiKey,
-		client: client,
+		name:        opts.model,
+		apiKey:      opts.apiKey,
+		baseURL:     resolveBaseURL(opts.baseURL),
+		client:      client,
+		provider:    providerName,
+		streamUsage: opts.streamUsage,
 	}, nil
 }
 
@@ -51,19 +73,40 @@ func (m *Model) Name() string {
 	return m.name
 }
 
+// GenerateContent sends the request to the chat completions API. When stream
+// is set the answer is read as server-sent events: a partial response is
+// yielded for every text delta of the first choice, followed by one complete
+// response per choice.
 func (m *Model) GenerateContent(
 	ctx context.Context,
 	req *model.LLMRequest,
 	stream bool,
 ) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
-		resp, err := m.generate(ctx, req)
-		yield(resp, err)
+		generate := m.generate
+		if stream {
+			generate = func(ctx context.Context, req *model.LLMRequest) ([]*model.LLMResponse, error) {
+				return m.generateStream(ctx, req, yield)
+			}
+		}
+
+		responses, err := generate(ctx, req)
+		if err != nil {
+			yield(nil, err)
+
+			return
+		}
+
+		for _, resp := range responses {
+			if !yield(resp, nil) {
+				return
+			}
+		}
 	}
 }
 
-//nolint:cyclop,funlen
-func (m *Model) generate(ctx context.Context, req *model.LLMRequest) (*model.LLMResponse, error) {
+// generate returns one response per choice.
+func (m *Model) generate(ctx context.Context, req *model.LLMRequest) ([]*model.LLMResponse, error) {
 	payload, err := buildChatRequest(req, m.name)
 	if err != nil {
 		return nil, err
@@ -74,15 +117,124 @@ func (m *Model) generate(ctx context.Context, req *model.LLMRequest) (*model.LLM
 		return nil, err
 	}
 
-	content, err := parseChatResponse(respBody)
+	var parsed openAIChatResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, fmt.Errorf("parse openai response: %w", err)
+	}
+
+	contents, err := choiceContents(m.provider, parsed.Choices)
 	if err != nil {
 		return nil, err
 	}
 
-	return &model.LLMResponse{
-		Content:      content,
-		TurnComplete: true,
-	}, nil
+	return completeResponses(contents, parsed.Usage.metadata()), nil
+}
+
+// generateStream streams the answer, yielding partial responses as they
+// arrive, and returns one complete response per choice. It returns no
+// responses and no error once yield asks to stop.
+func (m *Model) generateStream(
+	ctx context.Context,
+	req *model.LLMRequest,
+	yield func(*model.LLMResponse, error) bool,
+) ([]*model.LLMResponse, error) {
+	payload, err := buildChatRequest(req, m.name)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.Stream = true
+	if m.streamUsage {
+		payload.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+
+	resp, err := m.send(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var (
+		choices []openAIChoice
+		usage   *openAIUsage
+	)
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, streamBufferSize), maxStreamLineSize)
+
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("parse openai stream: %w", err)
+		}
+
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+
+		for _, delta := range chunk.Choices {
+			for len(choices) <= delta.Index {
+				choices = append(choices, openAIChoice{})
+			}
+
+			choices[delta.Index].Message.Content += delta.Delta.Content
+			if delta.FinishReason != "" {
+				choices[delta.Index].FinishReason = delta.FinishReason
+			}
+
+			if delta.Index != 0 || delta.Delta.Content == "" {
+				continue
+			}
+
+			if !yield(&model.LLMResponse{
+				Content: &genai.Content{
+					Role:  "model",
+					Parts: []*genai.Part{{Text: delta.Delta.Content}},
+				},
+				Partial: true,
+			}, nil) {
+				return nil, nil
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read openai stream: %w", err)
+	}
+
+	contents, err := choiceContents(m.provider, choices)
+	if err != nil {
+		return nil, err
+	}
+
+	return completeResponses(contents, usage.metadata()), nil
+}
+
+func completeResponses(
+	contents []*genai.Content,
+	usage *genai.GenerateContentResponseUsageMetadata,
+) []*model.LLMResponse {
+	responses := make([]*model.LLMResponse, 0, len(contents))
+
+	for _, content := range contents {
+		responses = append(responses, &model.LLMResponse{
+			Content:       content,
+			UsageMetadata: usage,
+			TurnComplete:  true,
+		})
+	}
+
+	return responses
 }
 
 func buildChatRequest(req *model.LLMRequest, modelName string) (openAIChatRequest, error) {
@@ -118,61 +270,102 @@ func applyRequestConfig(payload *openAIChatRequest, req *model.LLMRequest) {
 		payload.MaxTokens = req.Config.MaxOutputTokens
 	}
 
+	if req.Config.CandidateCount > 1 {
+		payload.N = req.Config.CandidateCount
+	}
+
 	if len(req.Config.StopSequences) > 0 {
 		payload.Stop = req.Config.StopSequences
 	}
+
+	if req.Config.ResponseMIMEType == "application/json" {
+		payload.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	}
 }
 
 func (m *Model) doChatRequest(ctx context.Context, payload openAIChatRequest) ([]byte, error) {
+	resp, err := m.send(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read openai response: %w", err)
+	}
+
+	return respBody, nil
+}
+
+// send posts the payload and returns the response of a successful request;
+// the caller closes its body.
+func (m *Model) send(ctx context.Context, payload openAIChatRequest) (*http.Response, error) {
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("marshal openai request: %w", err)
 	}
 
-	endpoint := getOpenAIBaseURL() + "/chat/completions"
+	endpoint := m.baseURL + "/chat/completions"
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("create openai request: %w", err)
 	}
 
-	httpReq.Header.Set("Authorization", "Bearer "+m.apiKey)
+	setAuthorization(httpReq, m.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := m.client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("send openai request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read openai response: %w", err)
-	}
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		return nil, fmt.Errorf("openai request failed: %s", strings.TrimSpace(string(respBody)))
+		defer resp.Body.Close()
+
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read openai response: %w", err)
+		}
+
+		return nil, apiError(m.provider, resp.StatusCode, respBody)
 	}
 
-	return respBody, nil
+	return resp, nil
 }
 
-func parseChatResponse(respBody []byte) (*genai.Content, error) {
-	var parsed openAIChatResponse
-	if err := json.Unmarshal(respBody, &parsed); err != nil {
-		return nil, fmt.Errorf("parse openai response: %w", err)
+// choiceContents returns the content of every non-empty choice.
+func choiceContents(providerName string, choices []openAIChoice) ([]*genai.Content, error) {
+	var (
+		contents []*genai.Content
+		filtered bool
+	)
+
+	for _, choice := range choices {
+		if strings.TrimSpace(choice.Message.Content) == "" {
+			filtered = filtered || choice.FinishReason == "content_filter"
+
+			continue
+		}
+
+		contents = append(contents, &genai.Content{
+			Role: "model",
+			Parts: []*genai.Part{
+				{Text: choice.Message.Content},
+			},
+		})
 	}
 
-	if len(parsed.Choices) == 0 || strings.TrimSpace(parsed.Choices[0].Message.Content) == "" {
-		return nil, fmt.Errorf("openai response missing content")
+	if len(contents) == 0 && filtered {
+		return nil, llmerr.Safety(providerName, "content_filter")
 	}
 
-	return &genai.Content{
-		Role: "model",
-		Parts: []*genai.Part{
-			{Text: parsed.Choices[0].Message.Content},
-		},
-	}, nil
+	if len(contents) == 0 {
+		return nil, fmt.Errorf("%w from %s", llmerr.ErrEmptyResponse, providerName)
+	}
+
+	return contents, nil
 }
 
 func openAIMessagesFromRequest(req *model.LLMRequest) []openAIMessage {
@@ -245,16 +438,62 @@ type openAIChatRequest struct {
 	Messages    []openAIMessage `json:"messages"`
 	Temperature float64         `json:"temperature,omitempty"`
 	TopP        float64         `json:"top_p,omitempty"`
+	N           int32           `json:"n,omitempty"`
 	MaxTokens   int32           `json:"max_tokens,omitempty"`
 	Stop        []string        `json:"stop,omitempty"`
+	Stream      bool            `json:"stream,omitempty"`
+
+	StreamOptions  *openAIStreamOptions  `json:"stream_options,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// openAIStreamOptions asks for a final stream event reporting token usage.
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// openAIStreamChunk is one server-sent event of a streamed chat completion.
+type openAIStreamChunk struct {
+	Choices []openAIStreamChoice `json:"choices"`
+	Usage   *openAIUsage         `json:"usage"`
+}
+
+type openAIStreamChoice struct {
+	Index        int           `json:"index"`
+	Delta        openAIMessage `json:"delta"`
+	FinishReason string        `json:"finish_reason"`
+}
+
+type openAIResponseFormat struct {
+	Type string `json:"type"`
 }
 
 type openAIChatResponse struct {
 	Choices []openAIChoice `json:"choices"`
+	Usage   *openAIUsage   `json:"usage"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int32 `json:"prompt_tokens"`
+	CompletionTokens int32 `json:"completion_tokens"`
+	TotalTokens      int32 `json:"total_tokens"`
+}
+
+func (u *openAIUsage) metadata() *genai.GenerateContentResponseUsageMetadata {
+	if u == nil {
+		return nil
+	}
+
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     u.PromptTokens,
+		CandidatesTokenCount: u.CompletionTokens,
+		TotalTokenCount:      u.TotalTokens,
+	}
 }
 
 type openAIChoice struct {
-	Message openAIMessage `json:"message"`
+	Message      openAIMessage `json:"message"`
+	FinishReason string        `json:"finish_reason"`
 }
 
 type openAIMessage struct {
@@ -3,6 +3,7 @@ package openai_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -19,10 +20,7 @@ func TestOpenAIModel_GenerateContent(t *testing.T) {
 	server := newOpenAIModelTestServer(t)
 	defer server.Close()
 
-	openai.SetOpenAIBaseURL(server.URL)
-	defer openai.SetOpenAIBaseURL("https://api.openai.com/v1")
-
-	openAIModel, err := openai.NewOpenAIModel("test-key", "gpt-4o")
+	openAIModel, err := openai.NewOpenAIModel("test-key", "gpt-4o", openai.WithBaseURL(server.URL))
 	require.NoError(t, err)
 
 	req := newOpenAIModelRequest()
@@ -102,3 +100,119 @@ func newOpenAIModelRequest() *adkmodel.LLMRequest {
 		},
 	}
 }
+
+func TestOpenAIModel_GenerateContentCandidates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			N int `json:"n"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, 2, payload.N)
+
+		resp := map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"content": "ls -la"}},
+				{"message": map[string]any{"content": "ls -lah"}},
+			},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	openAIModel, err := openai.NewOpenAIModel("test-key", "gpt-4o", openai.WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	req := newOpenAIModelRequest()
+	req.Config.CandidateCount = 2
+
+	var got []string
+
+	for resp, err := range openAIModel.GenerateContent(context.Background(), req, false) {
+		require.NoError(t, err)
+
+		got = append(got, resp.Content.Parts[0].Text)
+	}
+
+	assert.Equal(t, []string{"ls -la", "ls -lah"}, got)
+}
+
+func TestOpenAIModel_GenerateContentStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Stream        bool `json:"stream"`
+			StreamOptions struct {
+				IncludeUsage bool `json:"include_usage"`
+			} `json:"stream_options"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.True(t, payload.Stream)
+		assert.True(t, payload.StreamOptions.IncludeUsage)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		for _, chunk := range []string{
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"ls "}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"-la"},"finish_reason":"stop"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":42,"completion_tokens":5,"total_tokens":47}}`,
+		} {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	openAIModel, err := openai.NewOpenAIModel("test-key", "gpt-4o",
+		openai.WithBaseURL(server.URL), openai.WithStreamUsage(true))
+	require.NoError(t, err)
+
+	var partials, complete []string
+
+	for resp, err := range openAIModel.GenerateContent(context.Background(), newOpenAIModelRequest(), true) {
+		require.NoError(t, err)
+
+		if resp.Partial {
+			partials = append(partials, resp.Content.Parts[0].Text)
+
+			continue
+		}
+
+		assert.True(t, resp.TurnComplete)
+		require.NotNil(t, resp.UsageMetadata)
+		assert.Equal(t, int32(5), resp.UsageMetadata.CandidatesTokenCount)
+		complete = append(complete, resp.Content.Parts[0].Text)
+	}
+
+	assert.Equal(t, []string{"ls ", "-la"}, partials)
+	assert.Equal(t, []string{"ls -la"}, complete)
+}
+
+func TestOpenAIModel_GenerateContentStreamWithoutUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, true, payload["stream"])
+		assert.NotContains(t, payload, "stream_options")
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, `data: {"choices":[{"index":0,"delta":{"content":"ls"},"finish_reason":"stop"}]}`+"\n\n")
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	openAIModel, err := openai.NewOpenAIModel("test-key", "local-model", openai.WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	var complete []string
+
+	for resp, err := range openAIModel.GenerateContent(context.Background(), newOpenAIModelRequest(), true) {
+		require.NoError(t, err)
+
+		if !resp.Partial {
+			complete = append(complete, resp.Content.Parts[0].Text)
+		}
+	}
+
+	assert.Equal(t, []string{"ls"}, complete)
+}
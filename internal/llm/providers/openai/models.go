@@ -10,6 +10,7 @@ import (
 
 	"github.com/metalagman/aida/internal/config"
 	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/llm/retry"
 )
 
 type openAIModelList struct {
@@ -22,16 +23,13 @@ type openAIModel struct {
 
 func ListModels(ctx context.Context, cfg config.ProviderConfig) ([]provider.ModelInfo, error) {
 	apiKey := strings.TrimSpace(cfg.APIKey)
-	if apiKey == "" {
+	if apiKey == "" && cfg.BaseURL == "" {
 		return nil, fmt.Errorf("api_key is required for openai provider")
 	}
 
-	client, err := openAIClient()
-	if err != nil {
-		return nil, err
-	}
+	client := retry.NewClient(openAITimeout, cfg.Retries())
 
-	respBody, err := fetchModelList(ctx, client, apiKey)
+	respBody, err := fetchModelList(ctx, client, resolveBaseURL(cfg.BaseURL), apiKey)
 	if err != nil {
 		return nil, err
 	}
@@ -39,26 +37,15 @@ func ListModels(ctx context.Context, cfg config.ProviderConfig) ([]provider.Mode
 	return parseModelList(respBody)
 }
 
-func openAIClient() (*http.Client, error) {
-	factory := getOpenAIHTTPClientFactory()
-
-	client := factory()
-	if client == nil {
-		return nil, fmt.Errorf("openai http client factory returned nil")
-	}
-
-	return client, nil
-}
-
-func fetchModelList(ctx context.Context, client *http.Client, apiKey string) ([]byte, error) {
-	endpoint := getOpenAIBaseURL() + "/models"
+func fetchModelList(ctx context.Context, client *http.Client, baseURL, apiKey string) ([]byte, error) {
+	endpoint := baseURL + "/models"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create openai request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+	setAuthorization(req, apiKey)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -72,7 +59,7 @@ func fetchModelList(ctx context.Context, client *http.Client, apiKey string) ([]
 	}
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		return nil, fmt.Errorf("openai request failed: %s", strings.TrimSpace(string(respBody)))
+		return nil, apiError("openai", resp.StatusCode, respBody)
 	}
 
 	return respBody, nil
@@ -100,3 +87,11 @@ func parseModelList(respBody []byte) ([]provider.ModelInfo, error) {
 
 	return models, nil
 }
+
+// setAuthorization adds the bearer token, leaving the header out when there is
+// no key, as for local servers that reject or ignore it.
+func setAuthorization(req *http.Request, apiKey string) {
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+}
@@ -1,10 +1,27 @@
 package openai
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/metalagman/aida/internal/llm/cache"
+	"github.com/metalagman/aida/internal/llm/redact"
+)
 
 //go:generate go tool options-gen -from-struct=Options -out-filename=options_generated.go
 type Options struct {
-	apiKey string       `option:"mandatory"   validate:"required"`
-	model  string       `option:"mandatory"   validate:"required"`
-	client *http.Client `validate:"omitempty"`
+	// apiKey may be empty for a compatible server behind baseURL that needs no key.
+	apiKey  string       `option:"mandatory"   validate:"omitempty"`
+	model   string       `option:"mandatory"   validate:"required"`
+	client  *http.Client `validate:"omitempty"`
+	baseURL string       `validate:"omitempty"`
+	name    string       `validate:"omitempty"`
+	// streamUsage asks for the token usage at the end of a streamed answer.
+	// OpenAI supports it, but some compatible servers reject the field.
+	streamUsage bool `validate:"omitempty"`
+	// maxRetries is how many times a transient failure is retried when no client is given.
+	maxRetries int `validate:"omitempty"`
+	// cache, when set, answers repeated requests without calling the API.
+	cache *cache.Store `validate:"omitempty"`
+	// redactor, when set, masks secrets in requests and restores them in answers.
+	redactor *redact.Redactor `validate:"omitempty"`
 }
@@ -6,12 +6,19 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/metalagman/aida/internal/llm/cache"
 	"github.com/metalagman/aida/internal/llm/command"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/llm/redact"
 	"google.golang.org/adk/model"
 )
 
 const (
 	openAITimeout = 30 * time.Second
+
+	streamBufferSize = 64 << 10
+	// maxStreamLineSize bounds a single server-sent event line.
+	maxStreamLineSize = 1 << 20
 )
 
 type Provider struct {
@@ -19,49 +26,49 @@ type Provider struct {
 	model model.LLM
 }
 
-func NewProvider(apiKey string, model string) (*Provider, error) {
-	opts := NewOptions(apiKey, model)
+// NewProvider creates a provider for OpenAI or, with WithBaseURL, any
+// OpenAI-compatible API.
+func NewProvider(apiKey string, model string, options ...OptOptionsSetter) (*Provider, error) {
+	opts := NewOptions(apiKey, model, options...)
 	if err := opts.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid options: %w", err)
 	}
 
-	openAIModel, err := NewOpenAIModel(opts.apiKey, opts.model)
+	openAIModel, err := newModel(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Provider{
-		opts:  opts,
-		model: openAIModel,
-	}, nil
+	p := &Provider{opts: opts}
+	p.model = redact.Wrap(cache.Wrap(openAIModel, opts.cache, p.Name()), opts.redactor)
+
+	return p, nil
 }
 
 // NewProviderWithClient creates an OpenAI provider with a custom HTTP client.
 func NewProviderWithClient(apiKey string, model string, client *http.Client) (*Provider, error) {
-	opts := NewOptions(
-		apiKey,
-		model,
-		WithClient(client),
-	)
-	if err := opts.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid options: %w", err)
-	}
+	return NewProvider(apiKey, model, WithClient(client))
+}
 
-	openAIModel, err := NewOpenAIModelWithClient(opts.apiKey, opts.model, opts.client)
-	if err != nil {
-		return nil, err
-	}
+func (p *Provider) GenerateCommand(ctx context.Context, req provider.Request) (provider.Result, error) {
+	result, err := command.GenerateCommandWithModel(ctx, p.model, req)
+	result.Usage.Provider = p.Name()
 
-	return &Provider{
-		opts:  opts,
-		model: openAIModel,
-	}, nil
+	return result, err
 }
 
-func (p *Provider) GenerateCommand(ctx context.Context, prompt string) (string, error) {
-	return command.GenerateCommandWithModel(ctx, p.model, prompt)
+func (p *Provider) ExplainCommand(ctx context.Context, shellCommand string) (provider.Explanation, error) {
+	explanation, err := command.ExplainCommandWithModel(ctx, p.model, shellCommand)
+	explanation.Usage.Provider = p.Name()
+
+	return explanation, err
 }
 
+// Name returns the configured entry name, "openai" by default.
 func (p *Provider) Name() string {
+	if p.opts.name != "" {
+		return p.opts.name
+	}
+
 	return "openai"
 }
@@ -3,11 +3,14 @@ package openai_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/llmerr"
+	"github.com/metalagman/aida/internal/llm/provider"
 	"github.com/metalagman/aida/internal/llm/providers/openai"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,20 +29,95 @@ func TestOpenAIProvider_GenerateCommand(t *testing.T) {
 					},
 				},
 			},
+			"usage": map[string]interface{}{"prompt_tokens": 42, "completion_tokens": 5, "total_tokens": 47},
 		}
 		_ = json.NewEncoder(w).Encode(resp)
 	}))
 	defer server.Close()
 
-	openai.SetOpenAIBaseURL(server.URL)
-	defer openai.SetOpenAIBaseURL("https://api.openai.com/v1")
-
-	p, err := openai.NewProvider("test-key", "gpt-4o")
+	p, err := openai.NewProvider("test-key", "gpt-4o", openai.WithBaseURL(server.URL))
 	require.NoError(t, err)
 
-	cmd, err := p.GenerateCommand(context.Background(), "list files")
+	cmd, err := p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files"})
 	require.NoError(t, err)
-	assert.Equal(t, "ls -la", cmd)
+	assert.Equal(t, []provider.Candidate{{Command: "ls -la"}}, cmd.Candidates)
+	assert.Equal(t, provider.Usage{
+		Provider: "openai", Model: "gpt-4o", PromptTokens: 42, CompletionTokens: 5,
+	}, cmd.Usage)
+}
+
+func TestOpenAIProvider_GenerateCommandError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{
+			name:    "invalid key",
+			status:  http.StatusUnauthorized,
+			body:    `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`,
+			wantErr: llmerr.ErrAuth,
+		},
+		{
+			name:    "quota",
+			status:  http.StatusTooManyRequests,
+			body:    `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`,
+			wantErr: llmerr.ErrQuotaExceeded,
+		},
+		{
+			name:    "rate limit",
+			status:  http.StatusTooManyRequests,
+			body:    `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`,
+			wantErr: llmerr.ErrRateLimited,
+		},
+		{
+			name:    "model",
+			status:  http.StatusNotFound,
+			body:    `{"error":{"message":"The model does not exist","type":"invalid_request_error","code":"model_not_found"}}`,
+			wantErr: llmerr.ErrModelNotFound,
+		},
+		{
+			name:    "context",
+			status:  http.StatusBadRequest,
+			body:    `{"error":{"message":"maximum context length","type":"invalid_request_error","code":"context_length_exceeded"}}`,
+			wantErr: llmerr.ErrContextTooLong,
+		},
+		{
+			name:    "numeric code",
+			status:  http.StatusUnauthorized,
+			body:    `{"error":{"message":"bad key","code":401}}`,
+			wantErr: llmerr.ErrAuth,
+		},
+		{
+			name:    "content filter",
+			status:  http.StatusOK,
+			body:    `{"choices":[{"message":{"content":""},"finish_reason":"content_filter"}]}`,
+			wantErr: llmerr.ErrSafetyBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p, err := openai.NewProvider("test-key", "gpt-4o", openai.WithBaseURL(server.URL), openai.WithName("groq"))
+			require.NoError(t, err)
+
+			_, err = p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files"})
+			require.ErrorIs(t, err, tt.wantErr)
+
+			var apiErr *llmerr.APIError
+			if errors.As(err, &apiErr) {
+				assert.Equal(t, "groq", apiErr.Provider)
+				assert.Equal(t, tt.status, apiErr.StatusCode)
+			}
+		})
+	}
 }
 
 func TestNewOpenAIProvider(t *testing.T) {
@@ -55,12 +133,36 @@ func TestNewOpenAIProvider(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("missing api key with base url", func(t *testing.T) {
+		_, err := openai.NewProvider("", "model", openai.WithBaseURL("http://localhost:8080/v1"))
+		assert.NoError(t, err)
+	})
+
 	t.Run("missing model", func(t *testing.T) {
 		_, err := openai.NewProvider("key", "")
 		assert.Error(t, err)
 	})
 }
 
+func TestOpenAIProvider_GenerateCommandWithoutAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Header["Authorization"]
+		assert.False(t, ok, "authorization header should be omitted")
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]any{"content": "ls -la"}}},
+		})
+	}))
+	defer server.Close()
+
+	p, err := openai.NewProvider("", "local-model", openai.WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	cmd, err := p.GenerateCommand(context.Background(), provider.Request{Prompt: "list files"})
+	require.NoError(t, err)
+	assert.Equal(t, []provider.Candidate{{Command: "ls -la"}}, cmd.Candidates)
+}
+
 func TestListOpenAIModels(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
@@ -75,10 +177,7 @@ func TestListOpenAIModels(t *testing.T) {
 	}))
 	defer server.Close()
 
-	openai.SetOpenAIBaseURL(server.URL)
-	defer openai.SetOpenAIBaseURL("https://api.openai.com/v1")
-
-	models, err := openai.ListModels(context.Background(), config.ProviderConfig{APIKey: "test-key"})
+	models, err := openai.ListModels(context.Background(), config.ProviderConfig{APIKey: "test-key", BaseURL: server.URL})
 	require.NoError(t, err)
 	assert.Len(t, models, 2)
 	assert.Equal(t, "gpt-4o", models[0].Name)
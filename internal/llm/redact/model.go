@@ -0,0 +1,141 @@
+package redact
+
+import (
+	"context"
+	"iter"
+	"strings"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// placeholderNote tells the model how to treat placeholders. It is added to
+// the system instruction of requests that had secrets masked.
+const placeholderNote = "Some values were replaced with placeholders such as REDACTED_API_KEY_1. " +
+	"Use a placeholder verbatim wherever its value is needed."
+
+// Model masks secrets in the requests sent to the wrapped model and restores
+// them in its answers.
+type Model struct {
+	model.LLM
+
+	redactor *Redactor
+}
+
+// Wrap returns llm behind redactor. A nil redactor returns llm unchanged.
+func Wrap(llm model.LLM, redactor *Redactor) model.LLM {
+	if redactor == nil {
+		return llm
+	}
+
+	return &Model{LLM: llm, redactor: redactor}
+}
+
+// GenerateContent sends a masked copy of req; req itself is left untouched.
+// Streamed text is held back while it may end inside a placeholder.
+func (m *Model) GenerateContent(
+	ctx context.Context,
+	req *model.LLMRequest,
+	stream bool,
+) iter.Seq2[*model.LLMResponse, error] {
+	session := m.redactor.NewSession()
+	masked := session.request(req)
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var (
+			partial  strings.Builder
+			restored int
+		)
+
+		for resp, err := range m.LLM.GenerateContent(ctx, masked, stream) {
+			if resp == nil || resp.Content == nil || !session.Redacted() {
+				if !yield(resp, err) {
+					return
+				}
+
+				continue
+			}
+
+			out := *resp
+
+			if resp.Partial {
+				// Restore the text received so far and pass on what is new.
+				out.Content = mapText(resp.Content, func(text string) string {
+					partial.WriteString(text)
+
+					raw := partial.String()
+					text = session.Restore(raw[:len(raw)-session.pending(raw)])
+					delta := text[min(restored, len(text)):]
+					restored = len(text)
+
+					return delta
+				})
+			} else {
+				out.Content = mapText(resp.Content, session.Restore)
+			}
+
+			if !yield(&out, err) {
+				return
+			}
+		}
+	}
+}
+
+// request returns a copy of req with the secrets in its contents and system
+// instruction masked.
+func (s *Session) request(req *model.LLMRequest) *model.LLMRequest {
+	masked := *req
+
+	masked.Contents = make([]*genai.Content, len(req.Contents))
+	for i, content := range req.Contents {
+		masked.Contents[i] = mapText(content, s.Redact)
+	}
+
+	if req.Config != nil && req.Config.SystemInstruction != nil {
+		config := *req.Config
+		config.SystemInstruction = mapText(req.Config.SystemInstruction, s.Redact)
+		masked.Config = &config
+	}
+
+	if s.Redacted() {
+		config := genai.GenerateContentConfig{}
+		if masked.Config != nil {
+			config = *masked.Config
+		}
+
+		instruction := &genai.Content{Role: genai.RoleUser}
+		if config.SystemInstruction != nil {
+			instruction.Role = config.SystemInstruction.Role
+			instruction.Parts = append(instruction.Parts, config.SystemInstruction.Parts...)
+		}
+
+		instruction.Parts = append(instruction.Parts, genai.NewPartFromText("\n\n"+placeholderNote))
+		config.SystemInstruction = instruction
+		masked.Config = &config
+	}
+
+	return &masked
+}
+
+// mapText returns a copy of content with fn applied to the text of its parts.
+func mapText(content *genai.Content, fn func(string) string) *genai.Content {
+	if content == nil {
+		return nil
+	}
+
+	out := &genai.Content{Role: content.Role, Parts: make([]*genai.Part, len(content.Parts))}
+
+	for i, part := range content.Parts {
+		if part == nil || part.Text == "" {
+			out.Parts[i] = part
+
+			continue
+		}
+
+		copied := *part
+		copied.Text = fn(part.Text)
+		out.Parts[i] = &copied
+	}
+
+	return out
+}
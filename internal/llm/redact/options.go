@@ -0,0 +1,10 @@
+package redact
+
+//go:generate go tool options-gen -from-struct=Options -out-filename=options_generated.go
+type Options struct {
+	// patterns are extra regular expressions for secrets. The first capture
+	// group is masked when there is one, the whole match otherwise.
+	patterns []string `validate:"omitempty"`
+	// onRedact, when set, is called for every secret masked in a request.
+	onRedact func(Redaction) `validate:"omitempty"`
+}
@@ -0,0 +1,57 @@
+// Code generated by options-gen v0.55.3. DO NOT EDIT.
+
+package redact
+
+import (
+	fmt461e464ebed9 "fmt"
+
+	errors461e464ebed9 "github.com/kazhuravlev/options-gen/pkg/errors"
+	validator461e464ebed9 "github.com/kazhuravlev/options-gen/pkg/validator"
+)
+
+type OptOptionsSetter func(o *Options)
+
+func NewOptions(
+	options ...OptOptionsSetter,
+) Options {
+	var o Options
+
+	// Setting defaults from field tag (if present)
+
+	for _, opt := range options {
+		opt(&o)
+	}
+	return o
+}
+
+// patterns are extra regular expressions for secrets. The first capture
+// group is masked when there is one, the whole match otherwise.
+func WithPatterns(opt []string) OptOptionsSetter {
+	return func(o *Options) { o.patterns = opt }
+}
+
+// onRedact, when set, is called for every secret masked in a request.
+func WithOnRedact(opt func(Redaction)) OptOptionsSetter {
+	return func(o *Options) { o.onRedact = opt }
+}
+
+func (o *Options) Validate() error {
+	errs := new(errors461e464ebed9.ValidationErrors)
+	errs.Add(errors461e464ebed9.NewValidationError("patterns", _validate_Options_patterns(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("onRedact", _validate_Options_onRedact(o)))
+	return errs.AsError()
+}
+
+func _validate_Options_patterns(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.patterns, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `patterns` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_onRedact(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.onRedact, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `onRedact` did not pass the test: %w", err)
+	}
+	return nil
+}
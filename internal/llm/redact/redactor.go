@@ -0,0 +1,217 @@
+// Package redact masks secrets in requests before they leave the machine and
+// restores them in the answers.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kinds of secrets, used in placeholders such as REDACTED_API_KEY_1.
+const (
+	KindPrivateKey   = "private_key"
+	KindURLPassword  = "url_password"
+	KindBearerToken  = "bearer_token"
+	KindAWSAccessKey = "aws_access_key"
+	KindAWSSecretKey = "aws_secret_key"
+	KindAPIKey       = "api_key"
+	KindSecret       = "secret"
+	KindCustom       = "custom"
+)
+
+const (
+	placeholderPrefix = "REDACTED_"
+	// previewSize is how many leading characters of a secret are shown when
+	// auditing redactions.
+	previewSize = 4
+)
+
+// builtinRules detect common secrets. Rules with a capture group mask only the
+// group, keeping the surrounding key name or URL.
+var builtinRules = []rule{
+	{KindPrivateKey, regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{KindURLPassword, regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:([^\s@/]+)@`)},
+	{KindBearerToken, regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`)},
+	{KindAWSAccessKey, regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{KindAWSSecretKey, regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})\b`)},
+	{KindAPIKey, regexp.MustCompile(
+		`\b(?:sk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}|AIza[0-9A-Za-z_-]{35}|gh[pousr]_[A-Za-z0-9]{36,}|` +
+			`github_pat_[A-Za-z0-9_]{22,}|xox[abprs]-[A-Za-z0-9-]{10,}|gsk_[A-Za-z0-9]{20,}|glpat-[A-Za-z0-9_-]{20,})`,
+	)},
+	{KindSecret, regexp.MustCompile(
+		`(?i)\b[a-z0-9_]*(?:api[_-]?key|secret|token|passw(?:or)?d|pwd)["']?\s*[=:]\s*["']?([^\s"'&;]{8,})`,
+	)},
+}
+
+type rule struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// Redaction describes a masked secret without revealing it.
+type Redaction struct {
+	Kind        string
+	Placeholder string
+	// Preview is the start of the secret and its length, e.g. "sk-p… (51 chars)".
+	Preview string
+}
+
+// Redactor masks secrets found by the built-in rules and the configured
+// patterns.
+type Redactor struct {
+	opts  Options
+	rules []rule
+}
+
+// NewRedactor compiles the configured patterns after the built-in rules.
+func NewRedactor(options ...OptOptionsSetter) (*Redactor, error) {
+	opts := NewOptions(options...)
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	rules := slices.Clone(builtinRules)
+
+	for _, pattern := range opts.patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+
+		rules = append(rules, rule{KindCustom, re})
+	}
+
+	return &Redactor{opts: opts, rules: rules}, nil
+}
+
+// Session masks the text of one request and restores its placeholders in the
+// answer. The same secret gets the same placeholder within a session.
+type Session struct {
+	redactor *Redactor
+	secrets  map[string]string // placeholder -> secret
+	names    map[string]string // secret -> placeholder
+	counts   map[string]int
+}
+
+// NewSession starts masking a new request.
+func (r *Redactor) NewSession() *Session {
+	return &Session{
+		redactor: r,
+		secrets:  map[string]string{},
+		names:    map[string]string{},
+		counts:   map[string]int{},
+	}
+}
+
+// Redact replaces every secret in text with a placeholder.
+func (s *Session) Redact(text string) string {
+	for _, rule := range s.redactor.rules {
+		text = s.redactRule(text, rule)
+	}
+
+	return text
+}
+
+func (s *Session) redactRule(text string, rule rule) string {
+	matches := rule.re.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var sb strings.Builder
+
+	last := 0
+
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if len(match) >= 4 && match[2] >= 0 {
+			start, end = match[2], match[3]
+		}
+
+		secret := text[start:end]
+		if start == end || strings.HasPrefix(secret, placeholderPrefix) {
+			continue
+		}
+
+		sb.WriteString(text[last:start])
+		sb.WriteString(s.placeholder(rule.kind, secret))
+		last = end
+	}
+
+	sb.WriteString(text[last:])
+
+	return sb.String()
+}
+
+func (s *Session) placeholder(kind, secret string) string {
+	if name, ok := s.names[secret]; ok {
+		return name
+	}
+
+	s.counts[kind]++
+	name := placeholderPrefix + strings.ToUpper(kind) + "_" + strconv.Itoa(s.counts[kind])
+	s.names[secret] = name
+	s.secrets[name] = secret
+
+	if onRedact := s.redactor.opts.onRedact; onRedact != nil {
+		onRedact(Redaction{Kind: kind, Placeholder: name, Preview: preview(secret)})
+	}
+
+	return name
+}
+
+// Redacted reports whether any secret was masked.
+func (s *Session) Redacted() bool {
+	return len(s.secrets) > 0
+}
+
+// Restore replaces the placeholders in text with the secrets they stand for.
+func (s *Session) Restore(text string) string {
+	if len(s.secrets) == 0 || !strings.Contains(text, placeholderPrefix) {
+		return text
+	}
+
+	// Longer placeholders first, so REDACTED_SECRET_1 does not match the
+	// start of REDACTED_SECRET_12.
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+
+	slices.SortFunc(names, func(a, b string) int { return len(b) - len(a) })
+
+	for _, name := range names {
+		text = strings.ReplaceAll(text, name, s.secrets[name])
+	}
+
+	return text
+}
+
+// pending returns how many bytes at the end of text could be the start of a
+// placeholder, so streamed text is not restored halfway through one.
+func (s *Session) pending(text string) int {
+	longest := 0
+
+	for name := range s.secrets {
+		for n := min(len(name), len(text)); n > longest; n-- {
+			if strings.HasSuffix(text, name[:n]) {
+				longest = n
+
+				break
+			}
+		}
+	}
+
+	return longest
+}
+
+func preview(secret string) string {
+	runes := []rune(secret)
+	shown := string(runes[:min(len(runes), previewSize)])
+
+	return fmt.Sprintf("%s… (%d chars)", shown, utf8.RuneCountInString(secret))
+}
@@ -0,0 +1,18 @@
+package retry
+
+import (
+	"net/http"
+	"time"
+)
+
+//go:generate go tool options-gen -from-struct=Options -out-filename=options_generated.go
+type Options struct {
+	// base sends the requests; http.DefaultTransport when nil.
+	base http.RoundTripper `validate:"omitempty"`
+	// maxRetries is how many times a failed request is repeated.
+	maxRetries int `default:"2" validate:"min=0"`
+	// baseDelay is the backoff before the first retry; it doubles after every attempt.
+	baseDelay time.Duration `default:"500ms" validate:"min=0"`
+	// maxDelay caps the backoff between two attempts.
+	maxDelay time.Duration `default:"8s" validate:"min=0"`
+}
@@ -0,0 +1,88 @@
+// Code generated by options-gen v0.55.3. DO NOT EDIT.
+
+package retry
+
+import (
+	fmt461e464ebed9 "fmt"
+	"net/http"
+	"time"
+
+	errors461e464ebed9 "github.com/kazhuravlev/options-gen/pkg/errors"
+	validator461e464ebed9 "github.com/kazhuravlev/options-gen/pkg/validator"
+)
+
+type OptOptionsSetter func(o *Options)
+
+func NewOptions(
+	options ...OptOptionsSetter,
+) Options {
+	var o Options
+
+	// Setting defaults from field tag (if present)
+
+	o.maxRetries = 2
+	o.baseDelay, _ = time.ParseDuration("500ms")
+	o.maxDelay, _ = time.ParseDuration("8s")
+
+	for _, opt := range options {
+		opt(&o)
+	}
+	return o
+}
+
+// base sends the requests; http.DefaultTransport when nil.
+func WithBase(opt http.RoundTripper) OptOptionsSetter {
+	return func(o *Options) { o.base = opt }
+}
+
+// maxRetries is how many times a failed request is repeated.
+func WithMaxRetries(opt int) OptOptionsSetter {
+	return func(o *Options) { o.maxRetries = opt }
+}
+
+// baseDelay is the backoff before the first retry; it doubles after every attempt.
+func WithBaseDelay(opt time.Duration) OptOptionsSetter {
+	return func(o *Options) { o.baseDelay = opt }
+}
+
+// maxDelay caps the backoff between two attempts.
+func WithMaxDelay(opt time.Duration) OptOptionsSetter {
+	return func(o *Options) { o.maxDelay = opt }
+}
+
+func (o *Options) Validate() error {
+	errs := new(errors461e464ebed9.ValidationErrors)
+	errs.Add(errors461e464ebed9.NewValidationError("base", _validate_Options_base(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("maxRetries", _validate_Options_maxRetries(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("baseDelay", _validate_Options_baseDelay(o)))
+	errs.Add(errors461e464ebed9.NewValidationError("maxDelay", _validate_Options_maxDelay(o)))
+	return errs.AsError()
+}
+
+func _validate_Options_base(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.base, "omitempty"); err != nil {
+		return fmt461e464ebed9.Errorf("field `base` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_maxRetries(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.maxRetries, "min=0"); err != nil {
+		return fmt461e464ebed9.Errorf("field `maxRetries` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_baseDelay(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.baseDelay, "min=0"); err != nil {
+		return fmt461e464ebed9.Errorf("field `baseDelay` did not pass the test: %w", err)
+	}
+	return nil
+}
+
+func _validate_Options_maxDelay(o *Options) error {
+	if err := validator461e464ebed9.GetValidatorFor(o).Var(o.maxDelay, "min=0"); err != nil {
+		return fmt461e464ebed9.Errorf("field `maxDelay` did not pass the test: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+// Package retry provides an http.RoundTripper that repeats provider API calls
+// failing with transient errors.
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Transport retries requests that fail with 429, 500-504 or a dropped
+// connection. The delay between attempts grows exponentially with jitter,
+// follows the Retry-After header when the server sends one, and never runs
+// past the deadline of the request context. A 429 reporting exhausted quota
+// or credits is returned right away, since waiting does not help.
+type Transport struct {
+	opts Options
+}
+
+// NewTransport creates a retrying transport.
+func NewTransport(options ...OptOptionsSetter) (*Transport, error) {
+	opts := NewOptions(options...)
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.base == nil {
+		opts.base = http.DefaultTransport
+	}
+
+	return &Transport{opts: opts}, nil
+}
+
+// NewClient returns an HTTP client with the given timeout whose requests are
+// retried up to maxRetries times. A maxRetries of zero disables retrying.
+func NewClient(timeout time.Duration, maxRetries int) *http.Client {
+	client := &http.Client{Timeout: timeout}
+
+	if maxRetries > 0 {
+		if transport, err := NewTransport(WithMaxRetries(maxRetries)); err == nil {
+			client.Transport = transport
+		}
+	}
+
+	return client
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.opts.base.RoundTrip(req)
+		if attempt >= t.opts.maxRetries || !retryable(resp, err) {
+			return resp, err
+		}
+
+		next, rewindErr := rewind(req)
+		if rewindErr != nil {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		if !fitsDeadline(req.Context(), delay) {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		if sleepErr := sleep(req.Context(), delay); sleepErr != nil {
+			return nil, sleepErr
+		}
+
+		req = next
+	}
+}
+
+// retryable reports whether a request that got resp or err may succeed when
+// sent again.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, io.EOF)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return !quotaExhausted(resp)
+	case http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// quotaMarkers are error codes and phrases of 429 bodies that report exhausted
+// quota or credits rather than a temporary rate limit.
+var quotaMarkers = []string{"insufficient_quota", "credit balance", "billing"}
+
+// quotaBodyLimit bounds how much of a 429 body is searched for quotaMarkers.
+const quotaBodyLimit = 16 << 10
+
+// quotaExhausted reports whether a 429 response says that retrying cannot
+// succeed, either with an X-Should-Retry: false header or a quota error in its
+// body. The body is left readable for the caller.
+func quotaExhausted(resp *http.Response) bool {
+	if strings.EqualFold(resp.Header.Get("X-Should-Retry"), "false") {
+		return true
+	}
+
+	if resp.Body == nil {
+		return false
+	}
+
+	head, err := io.ReadAll(io.LimitReader(resp.Body, quotaBodyLimit))
+	resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), resp.Body), Closer: resp.Body}
+
+	if err != nil {
+		return false
+	}
+
+	body := strings.ToLower(string(head))
+
+	return slices.ContainsFunc(quotaMarkers, func(marker string) bool {
+		return strings.Contains(body, marker)
+	})
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// rewind returns a copy of req with a fresh body, so that it can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return next, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be replayed")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	next.Body = body
+
+	return next, nil
+}
+
+// backoff returns the delay before the next attempt: the server's Retry-After
+// when present, otherwise an exponentially growing delay with jitter.
+func (t *Transport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return delay
+		}
+	}
+
+	delay := t.opts.baseDelay << attempt
+	if delay <= 0 || delay > t.opts.maxDelay {
+		delay = t.opts.maxDelay
+	}
+
+	// Equal jitter: half of the delay is fixed, the other half random.
+	half := delay >> 1
+
+	return half + rand.N(half+1)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
+
+// fitsDeadline reports whether waiting delay still leaves time before the
+// context deadline.
+func fitsDeadline(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+
+	return !ok || time.Until(deadline) > delay
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
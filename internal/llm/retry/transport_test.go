@@ -0,0 +1,210 @@
+package retry_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/metalagman/aida/internal/llm/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T, maxRetries int) *http.Client {
+	t.Helper()
+
+	transport, err := retry.NewTransport(
+		retry.WithMaxRetries(maxRetries),
+		retry.WithBaseDelay(time.Millisecond),
+		retry.WithMaxDelay(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	return &http.Client{Transport: transport}
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxRetries   int
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{
+			name:         "recovers after server errors",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			maxRetries:   2,
+			wantStatus:   http.StatusOK,
+			wantAttempts: 3,
+		},
+		{
+			name:         "recovers after rate limit",
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			maxRetries:   2,
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+		},
+		{
+			name:         "gives up after max retries",
+			statuses:     []int{http.StatusInternalServerError},
+			maxRetries:   2,
+			wantStatus:   http.StatusInternalServerError,
+			wantAttempts: 3,
+		},
+		{
+			name:         "does not retry client errors",
+			statuses:     []int{http.StatusBadRequest, http.StatusOK},
+			maxRetries:   2,
+			wantStatus:   http.StatusBadRequest,
+			wantAttempts: 1,
+		},
+		{
+			name:         "disabled",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			maxRetries:   0,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, `{"prompt":"ls"}`, string(body))
+
+				n := int(attempts.Add(1))
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer server.Close()
+
+			resp, err := newClient(t, tt.maxRetries).Post(server.URL, "application/json", strings.NewReader(`{"prompt":"ls"}`))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestTransportQuotaExhausted(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		body         string
+		wantAttempts int32
+	}{
+		{
+			name:         "openai quota",
+			body:         `{"error":{"code":"insufficient_quota","message":"You exceeded your current quota"}}`,
+			wantAttempts: 1,
+		},
+		{
+			name:         "anthropic credits",
+			body:         `{"error":{"type":"invalid_request_error","message":"Your credit balance is too low"}}`,
+			wantAttempts: 1,
+		},
+		{
+			name:         "should not retry",
+			header:       "false",
+			body:         `{"error":{"message":"slow down"}}`,
+			wantAttempts: 1,
+		},
+		{
+			name:         "rate limit",
+			body:         `{"error":{"code":"rate_limit_exceeded","message":"Rate limit reached"}}`,
+			wantAttempts: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				attempts.Add(1)
+
+				if tt.header != "" {
+					w.Header().Set("X-Should-Retry", tt.header)
+				}
+
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			resp, err := newClient(t, 2).Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+			assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+			assert.Equal(t, tt.wantAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestTransportRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	start := time.Now()
+
+	resp, err := newClient(t, 1).Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestTransportStopsAtDeadline(t *testing.T) {
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	resp, err := newClient(t, 3).Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(1), attempts.Load())
+}
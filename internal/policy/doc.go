@@ -0,0 +1,2 @@
+// Package policy checks generated commands against user and project allow/deny rules.
+package policy
@@ -0,0 +1,335 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/risk"
+	"github.com/metalagman/aida/internal/shell"
+)
+
+type Action int
+
+const (
+	ActionAllow Action = iota
+	ActionConfirm
+	ActionDeny
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionAllow:
+		return "allow"
+	case ActionConfirm:
+		return "confirm"
+	case ActionDeny:
+		return "deny"
+	default:
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+}
+
+// Decision is the outcome of checking a command against a policy.
+type Decision struct {
+	Action Action
+	Reason string
+}
+
+// Policy is a compiled set of policy layers. A nil *Policy allows everything.
+type Policy struct {
+	// dir is the working directory relative forbidden paths are resolved against.
+	dir    string
+	layers []layer
+}
+
+type layer struct {
+	allowPrograms   []string
+	denyPrograms    []string
+	confirmPrograms []string
+	denyPatterns    []*regexp.Regexp
+	confirmPatterns []*regexp.Regexp
+	forbiddenPaths  []string
+}
+
+// New compiles the given policy layers. A command must pass every layer.
+func New(layers ...config.PolicyConfig) (*Policy, error) {
+	p := &Policy{}
+	p.dir, _ = os.Getwd()
+
+	for _, cfg := range layers {
+		if cfg.Empty() {
+			continue
+		}
+
+		denyPatterns, err := compilePatterns(cfg.DenyPatterns)
+		if err != nil {
+			return nil, err
+		}
+
+		confirmPatterns, err := compilePatterns(cfg.ConfirmPatterns)
+		if err != nil {
+			return nil, err
+		}
+
+		p.layers = append(p.layers, layer{
+			allowPrograms:   cfg.AllowPrograms,
+			denyPrograms:    cfg.DenyPrograms,
+			confirmPrograms: cfg.ConfirmPrograms,
+			denyPatterns:    denyPatterns,
+			confirmPatterns: confirmPatterns,
+			forbiddenPaths:  expandHome(cfg.ForbiddenPaths),
+		})
+	}
+
+	return p, nil
+}
+
+// Check returns the strictest decision of all layers for the command.
+func (p *Policy) Check(command string) Decision {
+	if p == nil || len(p.layers) == 0 {
+		return Decision{Action: ActionAllow}
+	}
+
+	script, err := shell.Parse(command)
+	if err != nil {
+		return Decision{Action: ActionDeny, Reason: "command could not be parsed"}
+	}
+
+	result := Decision{Action: ActionAllow}
+
+	for _, l := range p.layers {
+		if decision := l.check(command, script, p.dir); decision.Action > result.Action {
+			result = decision
+		}
+	}
+
+	return result
+}
+
+func (l layer) check(command string, script *shell.Script, dir string) Decision {
+	for _, re := range l.denyPatterns {
+		if re.MatchString(command) {
+			return deny("command matches denied pattern %q", re.String())
+		}
+	}
+
+	for _, call := range script.Calls {
+		if call.Dynamic() && (len(l.allowPrograms) > 0 || len(l.denyPrograms) > 0) {
+			return deny("program name %q is only known at runtime", call.Program)
+		}
+
+		if call.Opaque && (len(l.allowPrograms) > 0 || len(l.denyPrograms) > 0) {
+			return deny("%s runs a script that cannot be analyzed", call.Program)
+		}
+
+		if pattern, ok := matchProgram(l.denyPrograms, call.Program); ok {
+			return deny("program %q is denied by rule %q", call.Program, pattern)
+		}
+
+		if len(l.allowPrograms) > 0 {
+			if _, ok := matchProgram(l.allowPrograms, call.Program); !ok {
+				return deny("program %q is not in the allowed programs", call.Program)
+			}
+		}
+	}
+
+	if target, pattern, ok := l.forbiddenTarget(script, dir); ok {
+		return deny("%s is under forbidden path %q", target, pattern)
+	}
+
+	for _, re := range l.confirmPatterns {
+		if re.MatchString(command) {
+			return Decision{Action: ActionConfirm, Reason: fmt.Sprintf("command matches pattern %q", re.String())}
+		}
+	}
+
+	for _, call := range script.Calls {
+		if pattern, ok := matchProgram(l.confirmPrograms, call.Program); ok {
+			return Decision{Action: ActionConfirm, Reason: fmt.Sprintf("program %q matches rule %q", call.Program, pattern)}
+		}
+	}
+
+	return Decision{Action: ActionAllow}
+}
+
+// forbiddenTarget finds a written redirect target, or an argument of a
+// program that modifies files, under one of the forbidden paths. Relative
+// targets are resolved against dir; a cd earlier in the command is not tracked.
+func (l layer) forbiddenTarget(script *shell.Script, dir string) (string, string, bool) {
+	if len(l.forbiddenPaths) == 0 {
+		return "", "", false
+	}
+
+	var targets []string
+
+	for _, redirect := range script.Redirects {
+		if redirect.Writes() {
+			targets = append(targets, redirect.Target)
+		}
+	}
+
+	for _, call := range script.Calls {
+		if risk.ClassifyCall(call).Level > risk.LevelLow {
+			targets = append(targets, modifiedPaths(call)...)
+		}
+	}
+
+	for _, target := range targets {
+		resolved := resolvePath(target, dir)
+
+		for _, pattern := range l.forbiddenPaths {
+			if underPath(resolved, pattern) {
+				return target, pattern, true
+			}
+		}
+	}
+
+	return "", "", false
+}
+
+// modifiedPaths returns the arguments a program may modify. For copy-like
+// programs only the destination counts, so reading from a forbidden path is allowed.
+func modifiedPaths(call shell.Call) []string {
+	var paths []string
+
+	for i := 0; i < len(call.Args); i++ {
+		arg := call.Args[i]
+
+		if copyPrograms[call.Program] && (arg == "-t" || arg == "--target-directory") && i+1 < len(call.Args) {
+			return []string{call.Args[i+1]}
+		}
+
+		if _, value, ok := strings.Cut(arg, "="); ok {
+			arg = value
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			paths = append(paths, arg)
+		}
+	}
+
+	if copyPrograms[call.Program] && len(paths) > 0 {
+		return paths[len(paths)-1:]
+	}
+
+	return paths
+}
+
+var copyPrograms = map[string]bool{"cp": true, "install": true, "ln": true, "rsync": true, "scp": true}
+
+func resolvePath(target, dir string) string {
+	target = expandHomePath(target)
+	if !path.IsAbs(target) && dir != "" {
+		target = path.Join(dir, target)
+	}
+
+	return path.Clean(target)
+}
+
+func deny(format string, args ...any) Decision {
+	return Decision{Action: ActionDeny, Reason: fmt.Sprintf(format, args...)}
+}
+
+func matchProgram(patterns []string, program string) (string, bool) {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, program); ok {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// underPath reports whether an absolute target equals or lives below a path matching the glob.
+func underPath(target, pattern string) bool {
+	if !path.IsAbs(target) {
+		return false
+	}
+
+	for candidate := target; ; candidate = path.Dir(candidate) {
+		if ok, _ := path.Match(path.Clean(pattern), candidate); ok {
+			return true
+		}
+
+		if candidate == "/" {
+			return false
+		}
+	}
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		expr, isRegexp := strings.CutPrefix(pattern, "re:")
+		if !isRegexp {
+			expr = globToRegexp(pattern)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy pattern %q: %w", pattern, err)
+		}
+
+		compiled = append(compiled, re)
+	}
+
+	return compiled, nil
+}
+
+// globToRegexp converts a command glob, where "*" matches any text including
+// slashes and spaces, into an anchored regular expression.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+
+	sb.WriteString("^")
+
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	sb.WriteString("$")
+
+	return sb.String()
+}
+
+func expandHome(paths []string) []string {
+	expanded := make([]string, 0, len(paths))
+
+	for _, p := range paths {
+		expanded = append(expanded, expandHomePath(p))
+	}
+
+	return expanded
+}
+
+// expandHomePath replaces a leading "~" or "$HOME" with the user's home directory.
+func expandHomePath(p string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return p
+	}
+
+	for _, prefix := range []string{"~", "$HOME", "${HOME}"} {
+		if p == prefix {
+			return home
+		}
+
+		if rest, ok := strings.CutPrefix(p, prefix+"/"); ok {
+			return home + "/" + rest
+		}
+	}
+
+	return p
+}
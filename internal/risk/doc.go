@@ -0,0 +1,2 @@
+// Package risk statically classifies shell commands by the damage they can do.
+package risk
@@ -0,0 +1,101 @@
+package risk
+
+func set(values ...string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+
+	return m
+}
+
+var (
+	privilegePrograms = set("sudo", "doas", "su", "pkexec", "runuser")
+	accountPrograms   = set(
+		"useradd", "userdel", "usermod", "groupadd", "groupdel", "groupmod",
+		"passwd", "chpasswd", "visudo", "adduser", "deluser",
+	)
+	diskPrograms = set(
+		"mke2fs", "mkswap", "wipefs", "fdisk", "sfdisk", "cfdisk", "gdisk", "sgdisk",
+		"parted", "blkdiscard", "cryptsetup", "diskutil", "newfs",
+	)
+	powerPrograms  = set("shutdown", "reboot", "halt", "poweroff", "init", "telinit")
+	systemPrograms = set(
+		"kill", "killall", "pkill", "systemctl", "service", "launchctl",
+		"mount", "umount", "swapon", "swapoff", "iptables", "ip6tables", "nft", "ufw",
+		"sysctl", "modprobe", "rmmod", "insmod", "crontab",
+	)
+	deletePrograms = set("rm", "rmdir", "unlink", "shred", "srm")
+	writePrograms  = set(
+		"cp", "mv", "tee", "touch", "mkdir", "ln", "chmod", "chown", "chgrp", "install",
+		"truncate", "patch", "tar", "unzip", "zip", "gzip", "gunzip", "bzip2", "bunzip2",
+		"xz", "unxz", "zstd", "split", "mktemp", "mkfifo", "setfacl", "chattr",
+	)
+	networkPrograms = set(
+		"curl", "wget", "ssh", "scp", "sftp", "ftp", "telnet", "nc", "ncat", "netcat", "socat",
+		"ping", "ping6", "traceroute", "tracepath", "mtr", "dig", "nslookup", "host", "whois",
+		"http", "https", "aria2c", "nmap",
+	)
+	readOnlyPrograms = set(
+		"ls", "cat", "head", "tail", "less", "more", "grep", "egrep", "fgrep", "rg", "ag", "fd",
+		"locate", "wc", "sort", "uniq", "cut", "tr", "awk", "gawk", "mawk", "jq", "yq", "column",
+		"diff", "cmp", "comm", "file", "stat", "du", "df", "free", "ps", "top", "htop", "pgrep",
+		"uptime", "whoami", "id", "groups", "hostname", "uname", "date", "cal", "pwd", "echo",
+		"printf", "which", "whereis", "type", "printenv", "realpath", "readlink", "basename",
+		"dirname", "tree", "lsof", "ss", "netstat", "lsblk", "blkid", "true", "false", "test",
+		"[", "sleep", "seq", "xxd", "hexdump", "od", "md5sum", "sha1sum", "sha256sum",
+		"sha512sum", "shasum", "base64", "tac", "rev", "nl", "fold", "fmt", "paste", "join",
+		"expand", "unexpand", "man", "history", "cd", "getent", "nproc", "lscpu", "vm_stat",
+		"sw_vers", "tput", "clear", "dmesg", "journalctl", "last", "w", "who", "zcat", "zgrep",
+		"strings", "nm", "ldd", "otool", "cksum", "sum",
+	)
+	wrapperPrograms = set(
+		"env", "nice", "ionice", "nohup", "time", "timeout", "exec", "command", "builtin",
+		"xargs", "watch", "stdbuf", "chroot", "eval",
+	)
+)
+
+// packageManagers maps package managers to the subcommands that change installed packages.
+var packageManagers = map[string][]string{
+	"apt":      {"install", "remove", "purge", "upgrade", "dist-upgrade", "full-upgrade", "autoremove", "reinstall"},
+	"apt-get":  {"install", "remove", "purge", "upgrade", "dist-upgrade", "autoremove", "reinstall"},
+	"aptitude": {"install", "remove", "purge", "upgrade", "full-upgrade", "safe-upgrade", "reinstall"},
+	"dnf":      {"install", "remove", "erase", "upgrade", "update", "reinstall", "autoremove", "downgrade"},
+	"yum":      {"install", "remove", "erase", "upgrade", "update", "reinstall", "autoremove", "downgrade"},
+	"zypper":   {"install", "in", "remove", "rm", "update", "up", "dist-upgrade", "dup", "patch"},
+	"apk":      {"add", "del", "upgrade", "fix"},
+	"brew":     {"install", "uninstall", "remove", "rm", "reinstall", "upgrade", "tap", "untap"},
+	"port":     {"install", "uninstall", "upgrade", "activate", "deactivate"},
+	"snap":     {"install", "remove", "refresh", "revert"},
+	"flatpak":  {"install", "uninstall", "update"},
+	"pip":      {"install", "uninstall", "download"},
+	"pip3":     {"install", "uninstall", "download"},
+	"pipx":     {"install", "uninstall", "upgrade", "reinstall", "inject"},
+	"npm":      {"install", "i", "add", "uninstall", "remove", "rm", "update", "ci"},
+	"pnpm":     {"install", "i", "add", "remove", "rm", "update", "up"},
+	"yarn":     {"install", "add", "remove", "upgrade"},
+	"gem":      {"install", "uninstall", "update"},
+	"cargo":    {"install", "uninstall"},
+	"go":       {"install", "get"},
+}
+
+// packageManagerFlags maps package managers driven by options rather than
+// subcommands to the options that change installed packages.
+var packageManagerFlags = map[string][]string{
+	"pacman":  {"-S", "-R", "-U"},
+	"dpkg":    {"-i", "-r", "-P", "--install", "--remove", "--purge"},
+	"rpm":     {"-i", "-U", "-F", "-e", "--install", "--upgrade", "--freshen", "--erase"},
+	"nix-env": {"-i", "-e", "-u", "--install", "--uninstall", "--upgrade"},
+}
+
+var systemDirs = []string{
+	"/etc", "/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/boot", "/var", "/sys",
+	"/proc", "/dev", "/opt", "/root", "/srv", "/System", "/Library", "/Applications", "/private",
+}
+
+var devicePrefixes = []string{
+	"/dev/sd", "/dev/hd", "/dev/vd", "/dev/xvd", "/dev/nvme", "/dev/mmcblk", "/dev/disk", "/dev/rdisk",
+	"/dev/mapper/", "/dev/md", "/dev/loop",
+}
+
+var harmlessRedirectTargets = set("/dev/null", "/dev/stdout", "/dev/stderr", "/dev/tty", "-")
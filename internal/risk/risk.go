@@ -0,0 +1,459 @@
+package risk
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/metalagman/aida/internal/shell"
+)
+
+type Level int
+
+const (
+	LevelLow Level = iota
+	LevelMedium
+	LevelHigh
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelLow:
+		return "low"
+	case LevelMedium:
+		return "medium"
+	case LevelHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+}
+
+type Category string
+
+const (
+	CategoryReadOnly        Category = "read-only"
+	CategoryWritesFiles     Category = "writes files"
+	CategoryDeletes         Category = "deletes"
+	CategoryPrivilege       Category = "privilege escalation"
+	CategoryNetwork         Category = "network"
+	CategoryPackageInstall  Category = "package install"
+	CategoryDestructiveDisk Category = "destructive disk"
+	CategorySystem          Category = "system state"
+	CategoryUnknown         Category = "unknown"
+)
+
+// Assessment is the result of classifying a command.
+type Assessment struct {
+	Level      Level
+	Categories []Category
+	Reasons    []string
+}
+
+// Has reports whether the assessment includes the category.
+func (a Assessment) Has(category Category) bool {
+	return slices.Contains(a.Categories, category)
+}
+
+// Classify parses the command and classifies everything it runs.
+// Commands that cannot be parsed are treated as high risk.
+func Classify(command string) Assessment {
+	script, err := shell.Parse(command)
+	if err != nil {
+		return Assessment{
+			Level:      LevelHigh,
+			Categories: []Category{CategoryUnknown},
+			Reasons:    []string{"command could not be parsed"},
+		}
+	}
+
+	return ClassifyScript(script)
+}
+
+// ClassifyScript classifies an already parsed script.
+func ClassifyScript(script *shell.Script) Assessment {
+	var a Assessment
+
+	for _, call := range script.Calls {
+		classifyCall(&a, call)
+	}
+
+	for _, redirect := range script.Redirects {
+		classifyRedirect(&a, redirect)
+	}
+
+	if pipesDownloadIntoShell(script) {
+		a.add(CategoryNetwork, LevelHigh, "pipes downloaded content into a shell")
+	}
+
+	if len(a.Categories) == 0 {
+		a.add(CategoryReadOnly, LevelLow, "")
+	}
+
+	if len(a.Categories) > 1 {
+		a.Categories = slices.DeleteFunc(a.Categories, func(c Category) bool {
+			return c == CategoryReadOnly
+		})
+	}
+
+	return a
+}
+
+// ClassifyCall classifies a single program invocation, ignoring redirections.
+func ClassifyCall(call shell.Call) Assessment {
+	var a Assessment
+
+	classifyCall(&a, call)
+
+	return a
+}
+
+func (a *Assessment) add(category Category, level Level, reason string) {
+	if !slices.Contains(a.Categories, category) {
+		a.Categories = append(a.Categories, category)
+	}
+
+	if level > a.Level {
+		a.Level = level
+	}
+
+	if reason != "" && !slices.Contains(a.Reasons, reason) {
+		a.Reasons = append(a.Reasons, reason)
+	}
+}
+
+//nolint:cyclop,funlen
+func classifyCall(a *Assessment, call shell.Call) {
+	program := call.Program
+
+	switch {
+	case call.Opaque:
+		a.add(CategoryUnknown, LevelHigh, program+" runs a script that cannot be analyzed")
+	case privilegePrograms[program]:
+		a.add(CategoryPrivilege, LevelHigh, program+" runs commands with elevated privileges")
+	case accountPrograms[program]:
+		a.add(CategoryPrivilege, LevelHigh, program+" modifies user accounts")
+	case diskPrograms[program] || strings.HasPrefix(program, "mkfs"):
+		a.add(CategoryDestructiveDisk, LevelHigh, program+" modifies disks or partitions")
+	case program == "dd":
+		classifyDD(a, call)
+	case powerPrograms[program]:
+		a.add(CategorySystem, LevelHigh, program+" changes the system power state")
+	case systemPrograms[program]:
+		a.add(CategorySystem, LevelMedium, program+" changes running services or processes")
+	case deletePrograms[program]:
+		classifyDelete(a, call)
+	case program == "chmod" && setsSpecialBits(call.Args):
+		a.add(CategoryPrivilege, LevelHigh, "chmod sets the setuid or setgid bit")
+		classifyWrite(a, call)
+	case program == "git":
+		classifyGit(a, call)
+	case program == "find":
+		if slices.Contains(call.Args, "-delete") {
+			classifyDelete(a, call)
+		} else {
+			a.add(CategoryReadOnly, LevelLow, "")
+		}
+	case program == "sed" || program == "perl":
+		if hasInPlaceFlag(call.Args) {
+			classifyWrite(a, call)
+		} else {
+			a.add(CategoryReadOnly, LevelLow, "")
+		}
+	case writePrograms[program]:
+		classifyWrite(a, call)
+	case networkPrograms[program]:
+		a.add(CategoryNetwork, LevelMedium, program+" accesses the network")
+	case program == "rsync":
+		classifyWrite(a, call)
+
+		if slices.ContainsFunc(call.Args, isRemoteSpec) {
+			a.add(CategoryNetwork, LevelMedium, "rsync transfers files over the network")
+		}
+	case isPackageInstall(call):
+		a.add(CategoryPackageInstall, LevelMedium, program+" installs or removes packages")
+	case readOnlyPrograms[program] || wrapperPrograms[program]:
+		a.add(CategoryReadOnly, LevelLow, "")
+	case shell.IsShell(program):
+		if !slices.ContainsFunc(call.Args, isShellScriptFlag) {
+			a.add(CategoryUnknown, LevelMedium, program+" runs a script")
+		}
+	default:
+		a.add(CategoryUnknown, LevelMedium, "unrecognized program "+program)
+	}
+}
+
+func classifyDelete(a *Assessment, call shell.Call) {
+	targets := pathArgs(call.Args)
+
+	for _, target := range targets {
+		if isSystemPath(target) {
+			a.add(CategoryDeletes, LevelHigh, fmt.Sprintf("%s deletes %s", call.Program, target))
+
+			return
+		}
+	}
+
+	if call.Program == "rm" && hasRecursiveFlag(call.Args) {
+		a.add(CategoryDeletes, LevelHigh, "rm deletes recursively")
+
+		return
+	}
+
+	if call.Program == "shred" {
+		a.add(CategoryDeletes, LevelHigh, "shred irreversibly destroys file contents")
+
+		return
+	}
+
+	a.add(CategoryDeletes, LevelMedium, call.Program+" deletes files")
+}
+
+func classifyWrite(a *Assessment, call shell.Call) {
+	for _, target := range pathArgs(call.Args) {
+		if isSystemPath(target) {
+			a.add(CategoryWritesFiles, LevelHigh, fmt.Sprintf("%s modifies %s", call.Program, target))
+
+			return
+		}
+	}
+
+	a.add(CategoryWritesFiles, LevelMedium, call.Program+" writes files")
+}
+
+func classifyDD(a *Assessment, call shell.Call) {
+	for _, arg := range call.Args {
+		target, ok := strings.CutPrefix(arg, "of=")
+		if !ok {
+			continue
+		}
+
+		if isDevicePath(target) {
+			a.add(CategoryDestructiveDisk, LevelHigh, "dd writes directly to "+target)
+
+			return
+		}
+
+		classifyWrite(a, shell.Call{Program: "dd", Args: []string{target}})
+
+		return
+	}
+
+	a.add(CategoryReadOnly, LevelLow, "")
+}
+
+func classifyGit(a *Assessment, call shell.Call) {
+	subcommand, args := gitSubcommand(call.Args)
+
+	switch subcommand {
+	case "clone", "pull":
+		a.add(CategoryNetwork, LevelMedium, "git "+subcommand+" accesses the network")
+		a.add(CategoryWritesFiles, LevelMedium, "git "+subcommand+" writes files")
+	case "fetch", "push", "ls-remote":
+		level := LevelMedium
+		if subcommand == "push" && (slices.Contains(args, "--force") || slices.Contains(args, "-f")) {
+			level = LevelHigh
+		}
+
+		a.add(CategoryNetwork, level, "git "+subcommand+" accesses the network")
+	case "clean":
+		a.add(CategoryDeletes, LevelHigh, "git clean deletes untracked files")
+	case "reset":
+		if slices.Contains(args, "--hard") {
+			a.add(CategoryDeletes, LevelHigh, "git reset --hard discards uncommitted changes")
+		} else {
+			a.add(CategoryWritesFiles, LevelMedium, "git reset modifies the repository")
+		}
+	case "", "status", "log", "diff", "show", "blame", "grep", "ls-files", "rev-parse", "describe", "shortlog", "reflog":
+		a.add(CategoryReadOnly, LevelLow, "")
+	default:
+		a.add(CategoryWritesFiles, LevelMedium, "git "+subcommand+" modifies the repository")
+	}
+}
+
+func classifyRedirect(a *Assessment, redirect shell.Redirect) {
+	if !redirect.Writes() {
+		return
+	}
+
+	target := redirect.Target
+
+	switch {
+	case harmlessRedirectTargets[target]:
+	case isDevicePath(target):
+		a.add(CategoryDestructiveDisk, LevelHigh, "writes directly to "+target)
+	case isSystemPath(target):
+		a.add(CategoryWritesFiles, LevelHigh, "writes to "+target)
+	default:
+		a.add(CategoryWritesFiles, LevelMedium, "writes to "+target)
+	}
+}
+
+// pipesDownloadIntoShell detects "curl ... | sh" style scripts.
+func pipesDownloadIntoShell(script *shell.Script) bool {
+	var downloads, shellFromStdin bool
+
+	for _, call := range script.Calls {
+		if call.Program == "curl" || call.Program == "wget" {
+			downloads = true
+		}
+
+		if shell.IsShell(call.Program) && len(pathArgs(call.Args)) == 0 &&
+			!slices.ContainsFunc(call.Args, isShellScriptFlag) {
+			shellFromStdin = true
+		}
+	}
+
+	return downloads && shellFromStdin
+}
+
+func isPackageInstall(call shell.Call) bool {
+	if flags, ok := packageManagerFlags[call.Program]; ok {
+		return slices.ContainsFunc(call.Args, func(arg string) bool {
+			return matchesPackageFlag(call.Program, arg, flags)
+		})
+	}
+
+	subcommands, ok := packageManagers[call.Program]
+	if !ok {
+		return false
+	}
+
+	for _, arg := range call.Args {
+		if !strings.HasPrefix(arg, "-") {
+			return slices.Contains(subcommands, arg)
+		}
+	}
+
+	return false
+}
+
+func matchesPackageFlag(program, arg string, flags []string) bool {
+	// pacman -Ss, -Si and -Sg only query the sync database.
+	if program == "pacman" && len(arg) > 2 && strings.HasPrefix(arg, "-S") && strings.ContainsAny(arg[2:], "sig") {
+		return false
+	}
+
+	for _, flag := range flags {
+		if arg == flag || (!strings.HasPrefix(flag, "--") && strings.HasPrefix(arg, flag) && !strings.HasPrefix(arg, "--")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func gitSubcommand(args []string) (string, []string) {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-C" || arg == "-c" || arg == "--git-dir" || arg == "--work-tree":
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			return arg, args[i+1:]
+		}
+	}
+
+	return "", nil
+}
+
+// pathArgs returns the non-option arguments of a call.
+func pathArgs(args []string) []string {
+	var paths []string
+
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		paths = append(paths, arg)
+	}
+
+	return paths
+}
+
+func hasRecursiveFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--recursive" {
+			return true
+		}
+
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.ContainsAny(arg, "rR") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasInPlaceFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--in-place" || strings.HasPrefix(arg, "--in-place=") {
+			return true
+		}
+
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "i") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setsSpecialBits(args []string) bool {
+	for _, arg := range pathArgs(args) {
+		if strings.Contains(arg, "+s") {
+			return true
+		}
+
+		if len(arg) == 4 && strings.Trim(arg, "01234567") == "" && strings.ContainsAny(arg[:1], "2467") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isShellScriptFlag(arg string) bool {
+	return strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "c")
+}
+
+func isRemoteSpec(arg string) bool {
+	host, _, ok := strings.Cut(arg, ":")
+
+	return ok && host != "" && !strings.HasPrefix(arg, "/") && !strings.HasPrefix(arg, ".")
+}
+
+func isDevicePath(target string) bool {
+	for _, prefix := range devicePrefixes {
+		if strings.HasPrefix(target, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSystemPath reports whether a path is the filesystem root, the home
+// directory itself, or lives under a system directory.
+func isSystemPath(target string) bool {
+	switch strings.TrimSuffix(target, "/*") {
+	case "", "/", "~", "$HOME", "${HOME}":
+		return true
+	}
+
+	if !strings.HasPrefix(target, "/") {
+		return false
+	}
+
+	cleaned := path.Clean(target)
+
+	for _, dir := range systemDirs {
+		if cleaned == dir || strings.HasPrefix(cleaned, dir+"/") {
+			return true
+		}
+	}
+
+	return false
+}
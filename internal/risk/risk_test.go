@@ -0,0 +1,73 @@
+package risk_test
+
+import (
+	"testing"
+
+	"github.com/metalagman/aida/internal/risk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		command  string
+		level    risk.Level
+		category risk.Category
+	}{
+		{command: "ls -la", level: risk.LevelLow, category: risk.CategoryReadOnly},
+		{command: "find . -name '*.go' | xargs grep -n TODO", level: risk.LevelLow, category: risk.CategoryReadOnly},
+		{command: "git status && git log --oneline", level: risk.LevelLow, category: risk.CategoryReadOnly},
+		{command: "du -sh * 2>/dev/null | sort -h", level: risk.LevelLow, category: risk.CategoryReadOnly},
+		{command: "sed 's/a/b/' file.txt", level: risk.LevelLow, category: risk.CategoryReadOnly},
+		{command: "sed -i 's/a/b/' file.txt", level: risk.LevelMedium, category: risk.CategoryWritesFiles},
+		{command: "echo hi > notes.txt", level: risk.LevelMedium, category: risk.CategoryWritesFiles},
+		{command: "echo hi | sudo tee /etc/motd", level: risk.LevelHigh, category: risk.CategoryPrivilege},
+		{command: "echo 1 >> /etc/hosts", level: risk.LevelHigh, category: risk.CategoryWritesFiles},
+		{command: "rm notes.txt", level: risk.LevelMedium, category: risk.CategoryDeletes},
+		{command: "rm -rf build", level: risk.LevelHigh, category: risk.CategoryDeletes},
+		{command: "rm -f /", level: risk.LevelHigh, category: risk.CategoryDeletes},
+		{command: "find . -name '*.tmp' -delete", level: risk.LevelMedium, category: risk.CategoryDeletes},
+		{command: `find . -name '*.tmp' -exec rm {} \;`, level: risk.LevelMedium, category: risk.CategoryDeletes},
+		{command: "git reset --hard HEAD~1", level: risk.LevelHigh, category: risk.CategoryDeletes},
+		{command: "sudo apt-get install -y ripgrep", level: risk.LevelHigh, category: risk.CategoryPackageInstall},
+		{command: "brew install fd", level: risk.LevelMedium, category: risk.CategoryPackageInstall},
+		{command: "pacman -Syu", level: risk.LevelMedium, category: risk.CategoryPackageInstall},
+		{command: "pacman -Ss vim", level: risk.LevelMedium, category: risk.CategoryUnknown},
+		{command: "curl -s https://example.com", level: risk.LevelMedium, category: risk.CategoryNetwork},
+		{command: "curl -fsSL https://example.com/install.sh | sh", level: risk.LevelHigh, category: risk.CategoryNetwork},
+		{command: "git push --force origin main", level: risk.LevelHigh, category: risk.CategoryNetwork},
+		{command: "mkfs.ext4 /dev/sdb1", level: risk.LevelHigh, category: risk.CategoryDestructiveDisk},
+		{command: "dd if=/dev/zero of=/dev/sda bs=1M", level: risk.LevelHigh, category: risk.CategoryDestructiveDisk},
+		{command: "dd if=/dev/zero of=disk.img bs=1M count=10", level: risk.LevelMedium, category: risk.CategoryWritesFiles},
+		{command: "cat image.iso > /dev/sdb", level: risk.LevelHigh, category: risk.CategoryDestructiveDisk},
+		{command: "chmod u+s ./helper", level: risk.LevelHigh, category: risk.CategoryPrivilege},
+		{command: "shutdown -h now", level: risk.LevelHigh, category: risk.CategorySystem},
+		{command: "./deploy.sh --prod", level: risk.LevelMedium, category: risk.CategoryUnknown},
+		{command: "echo 'unterminated", level: risk.LevelHigh, category: risk.CategoryUnknown},
+		{command: "fish -c 'rm -rf ~'", level: risk.LevelHigh, category: risk.CategoryDeletes},
+		{command: "bash -c 'echo ('", level: risk.LevelHigh, category: risk.CategoryUnknown},
+		{command: `sh -c "$VAR"`, level: risk.LevelHigh, category: risk.CategoryUnknown},
+		{command: `sh -c "rm $FILE"`, level: risk.LevelHigh, category: risk.CategoryUnknown},
+		{command: `eval "$CMD"`, level: risk.LevelHigh, category: risk.CategoryUnknown},
+		{command: "sh -c 'echo $HOME'", level: risk.LevelLow, category: risk.CategoryReadOnly},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.command, func(t *testing.T) {
+			got := risk.Classify(tc.command)
+			assert.Equal(t, tc.level, got.Level, "level (reasons: %v)", got.Reasons)
+			assert.True(t, got.Has(tc.category), "categories %v should include %q", got.Categories, tc.category)
+		})
+	}
+}
+
+func TestClassifyReadOnlyIsExclusive(t *testing.T) {
+	got := risk.Classify("ls && rm notes.txt")
+	assert.False(t, got.Has(risk.CategoryReadOnly))
+	assert.Equal(t, []risk.Category{risk.CategoryDeletes}, got.Categories)
+}
+
+func TestLevelString(t *testing.T) {
+	assert.Equal(t, "low", risk.LevelLow.String())
+	assert.Equal(t, "medium", risk.LevelMedium.String())
+	assert.Equal(t, "high", risk.LevelHigh.String())
+}
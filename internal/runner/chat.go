@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/metalagman/aida/internal/llm/provider"
+)
+
+// maxChatTurns bounds how many earlier turns are sent to the model as context.
+const maxChatTurns = 20
+
+// Chat runs an interactive session. Every line read from Stdin is a prompt
+// that goes through the configured mode like a single Run, and earlier
+// prompts, commands and output tails are sent along as context so that
+// follow-ups can refine previous commands. It returns nil when the input ends
+// or the user types "exit".
+func (r Runner) Chat(ctx context.Context, generator CommandGenerator) error {
+	if r.answers == nil && r.Stdin != nil {
+		r.answers = bufio.NewReader(r.Stdin)
+	}
+
+	_, _ = fmt.Fprintln(r.Stdout, `Describe what you want to do, or type "exit" to quit.`)
+
+	var history []provider.Turn
+
+	for {
+		_, _ = fmt.Fprint(r.Stdout, "aida> ")
+
+		prompt, err := r.readInput(ctx)
+		if errors.Is(err, io.EOF) {
+			_, _ = fmt.Fprintln(r.Stdout)
+
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch prompt {
+		case "":
+			continue
+		case "exit", "quit":
+			return nil
+		}
+
+		turn, err := r.runRequest(ctx, provider.Request{
+			Prompt:       prompt,
+			History:      history,
+			Environment:  r.environment(ctx),
+			Instructions: r.Instructions,
+			Candidates:   r.Candidates,
+		}, generator)
+
+		if turn.Command != "" {
+			history = append(history, turn)
+			if len(history) > maxChatTurns {
+				history = history[len(history)-maxChatTurns:]
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ErrCancelled
+		}
+
+		if err != nil && !errors.Is(err, ErrCancelled) {
+			_, _ = fmt.Fprintf(r.stderr(), "Error: %v\n", err)
+		}
+	}
+}
+
+func (r Runner) stderr() io.Writer {
+	if r.Stderr != nil {
+		return r.Stderr
+	}
+
+	return r.Stdout
+}
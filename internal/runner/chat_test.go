@@ -0,0 +1,99 @@
+package runner_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type outputExecutor struct {
+	output   string
+	commands []string
+}
+
+func (e *outputExecutor) Execute(_ context.Context, command string, stdout, _ io.Writer, _ io.Reader) error {
+	e.commands = append(e.commands, command)
+	_, _ = io.WriteString(stdout, e.output)
+
+	return nil
+}
+
+func TestRunnerChat(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &outputExecutor{output: "./app.log\n"}
+	gen := &sequenceProvider{commands: []string{"find . -name '*.log'", "find . -name '*.log' -size +10M"}}
+	r := runner.Runner{
+		Mode:     runner.ModeYOLO,
+		Stdout:   &stdout,
+		Stdin:    strings.NewReader("find log files\n\nnow only the ones bigger than 10MB\nexit\nignored\n"),
+		Executor: exec,
+	}
+
+	err := r.Chat(context.Background(), gen)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"find . -name '*.log'", "find . -name '*.log' -size +10M"}, exec.commands)
+
+	require.Len(t, gen.requests, 2)
+	assert.Empty(t, gen.requests[0].History)
+	assert.Equal(t, []provider.Turn{{
+		Prompt:  "find log files",
+		Command: "find . -name '*.log'",
+		Ran:     true,
+		Output:  "./app.log\n",
+	}}, gen.requests[1].History)
+	assert.Equal(t, 4, strings.Count(stdout.String(), "aida> "))
+}
+
+func TestRunnerChatDescribesWorkspaceForEveryPrompt(t *testing.T) {
+	calls := 0
+
+	gen := &sequenceProvider{commands: []string{"git add -A", "git commit -m wip"}}
+	r := runner.Runner{
+		Mode:        runner.ModeYOLO,
+		Stdout:      io.Discard,
+		Stdin:       strings.NewReader("stage everything\ncommit it\n"),
+		Executor:    &outputExecutor{},
+		Environment: []string{"Distro: Debian 12"},
+		Workspace: func(context.Context) []string {
+			calls++
+
+			return []string{fmt.Sprintf("Git work tree: call %d", calls)}
+		},
+	}
+
+	require.NoError(t, r.Chat(context.Background(), gen))
+	require.Len(t, gen.requests, 2)
+	assert.Equal(t, []string{"Distro: Debian 12", "Git work tree: call 1"}, gen.requests[0].Environment)
+	assert.Equal(t, []string{"Distro: Debian 12", "Git work tree: call 2"}, gen.requests[1].Environment)
+}
+
+func TestRunnerChatKeepsGoingAfterCancel(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &outputExecutor{}
+	gen := &sequenceProvider{commands: []string{"rm -rf build", "ls"}}
+	r := runner.Runner{
+		Mode:     runner.ModeConfirm,
+		Stdout:   &stdout,
+		Stdin:    strings.NewReader("clean up\nn\nlist files\ny\n"),
+		Executor: exec,
+	}
+
+	err := r.Chat(context.Background(), gen)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ls"}, exec.commands)
+
+	require.Len(t, gen.requests, 2)
+	require.Len(t, gen.requests[1].History, 1)
+	assert.False(t, gen.requests[1].History[0].Ran)
+	assert.Equal(t, "rm -rf build", gen.requests[1].History[0].Command)
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ExternalEditor edits commands in an external program such as $VISUAL or $EDITOR.
+type ExternalEditor struct {
+	// Program is the editor command line; it may include arguments, e.g. "code --wait".
+	Program string
+	Stdin   io.Reader
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+// EditorFromEnv returns the editor configured in $VISUAL or $EDITOR, or an empty string.
+func EditorFromEnv() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func (e ExternalEditor) Edit(ctx context.Context, command string) (string, error) {
+	file, err := os.CreateTemp("", "aida-*.sh")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+
+	path := file.Name()
+	defer os.Remove(path)
+
+	if _, err := file.WriteString(command + "\n"); err != nil {
+		_ = file.Close()
+
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := e.EditFile(ctx, path); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read temp file: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// EditFile opens the file at path in the editor and waits for it to exit.
+func (e ExternalEditor) EditFile(ctx context.Context, path string) error {
+	// Run through the shell so that editor settings with arguments work.
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", e.Program+` "$1"`, "aida-editor", path)
+	cmd.Stdin = e.Stdin
+	cmd.Stdout = e.Stdout
+	cmd.Stderr = e.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor %q: %w", e.Program, err)
+	}
+
+	return nil
+}
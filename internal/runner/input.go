@@ -0,0 +1,126 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// inputExcerptSize bounds how much piped input is sent to the model.
+	inputExcerptSize = 8 << 10
+	// inputReadLimit bounds how much piped input is read, so that a producer
+	// that never stops, such as tail -f, does not keep aida waiting.
+	inputReadLimit = 16 << 20
+	// inputChunkSize is the size of a single read from the input.
+	inputChunkSize = 32 << 10
+)
+
+// inputCutNote ends an excerpt of input that was still open when reading stopped.
+const inputCutNote = "[stopped reading before the end of the input]"
+
+// ReadInput reads r and returns an excerpt small enough to send to the model
+// as context. Longer input keeps its last lines, where logs usually report
+// what went wrong, behind a note on how much was left out. Reading stops at
+// the end of r, after inputReadLimit bytes or when ctx is done; an excerpt of
+// input that had not ended says so.
+func ReadInput(ctx context.Context, r io.Reader) (string, error) {
+	tail := newTailBuffer(inputExcerptSize)
+
+	size, complete, err := copyInput(ctx, tail, r, inputReadLimit)
+	if err != nil {
+		return "", fmt.Errorf("read input: %w", err)
+	}
+
+	excerpt := tail.String()
+	if strings.TrimSpace(excerpt) == "" {
+		return "", nil
+	}
+
+	if omitted := size - int64(len(excerpt)); omitted > 0 {
+		// Start at a line boundary rather than in the middle of a line or rune.
+		if idx := strings.IndexByte(excerpt, '\n'); idx >= 0 && idx < len(excerpt)-1 {
+			omitted += int64(idx + 1)
+			excerpt = excerpt[idx+1:]
+		}
+
+		excerpt = fmt.Sprintf("[%d earlier bytes omitted]\n%s", omitted, excerpt)
+	}
+
+	if !complete {
+		// Drop the line that was still being written.
+		if idx := strings.LastIndexByte(excerpt, '\n'); idx > 0 {
+			excerpt = excerpt[:idx]
+		}
+	}
+
+	excerpt = strings.TrimRight(strings.ToValidUTF8(excerpt, ""), "\n")
+	if !complete {
+		excerpt += "\n" + inputCutNote
+	}
+
+	return excerpt, nil
+}
+
+// copyInput copies r to w until r ends, limit bytes were copied or ctx is
+// done, and reports whether r ended. A blocked Read cannot be interrupted, so
+// reads happen in a goroutine that is abandoned when copying stops early.
+func copyInput(ctx context.Context, w io.Writer, r io.Reader, limit int64) (int64, bool, error) {
+	chunks := make(chan []byte)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+
+	defer close(done)
+
+	go func() {
+		buf := make([]byte, inputChunkSize)
+
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				select {
+				case chunks <- bytes.Clone(buf[:n]):
+				case <-done:
+					return
+				}
+			}
+
+			if err != nil {
+				readErr <- err
+
+				return
+			}
+		}
+	}()
+
+	var size int64
+
+	for {
+		select {
+		case <-ctx.Done():
+			return size, false, nil
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return size, true, nil
+			}
+
+			return size, false, err
+		case chunk := <-chunks:
+			chunk = chunk[:min(int64(len(chunk)), limit-size)]
+
+			n, err := w.Write(chunk)
+			size += int64(n)
+
+			if err != nil {
+				return size, false, err
+			}
+
+			if size >= limit {
+				return size, false, nil
+			}
+		}
+	}
+}
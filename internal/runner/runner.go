@@ -7,13 +7,29 @@ import (
 	"fmt"
 	"io"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/policy"
+	"github.com/metalagman/aida/internal/risk"
+)
+
+var (
+	ErrCancelled    = errors.New("command canceled")
+	ErrPolicyDenied = errors.New("command refused by policy")
 )
 
-var ErrCancelled = errors.New("command canceled")
+const (
+	// stderrTailSize bounds how much of a failed command's stderr is sent back to the model.
+	stderrTailSize = 4 << 10
+	// outputTailSize bounds how much of a command's output is kept as session context.
+	outputTailSize = 2 << 10
+)
 
 type CommandGenerator interface {
-	GenerateCommand(ctx context.Context, prompt string) (string, error)
+	GenerateCommand(ctx context.Context, req provider.Request) (provider.Result, error)
 }
 
 type Executor interface {
@@ -54,93 +70,510 @@ type Runner struct {
 	Stderr   io.Writer
 	Stdin    io.Reader
 	Executor Executor
+	// Policy is checked before every command runs. A nil policy allows everything.
+	Policy *policy.Policy
+	// MaxFixAttempts is how many corrected commands may be requested after a
+	// generated command exits with a non-zero status. Zero disables fixing.
+	MaxFixAttempts int
+	// Editor edits a command when the user answers "e" at the confirmation
+	// prompt. When nil, the replacement is read inline from Stdin.
+	Editor CommandEditor
+	// Candidates is how many alternative commands to ask for. In confirm mode
+	// the user picks one from a menu; other modes use the first.
+	Candidates int
+	// Live renders a spinner on Stdout until the first token arrives and then
+	// the command as it streams in. It is meant for terminals and applies only
+	// when a single command is requested.
+	Live bool
+	// Environment lists facts about the local machine sent with every request.
+	Environment []string
+	// Workspace, when set, describes the working tree, such as the state of
+	// its git repository. It is called for every prompt, so follow-ups see the
+	// changes made by earlier commands, and added to Environment.
+	Workspace func(ctx context.Context) []string
+	// Instructions are project-specific guidance sent with every request.
+	Instructions string
+	// Input is data piped to aida. Run sends it to the model as context for
+	// the prompt.
+	Input string
+	// OnUsage, when set, is called with the usage of every generated answer,
+	// including fix requests and follow-up prompts.
+	OnUsage func(provider.Usage)
+	// OnRecord, when set, is called for every generated command once it has
+	// run, been refused or canceled.
+	OnRecord func(Record)
+
+	answers *bufio.Reader
+}
+
+// Record describes a generated command and what became of it.
+type Record struct {
+	Prompt   string
+	Command  string
+	Provider string
+	Model    string
+	Mode     RunMode
+	// Confirmed reports whether the user approved the command at the prompt.
+	Confirmed bool
+	Ran       bool
+	ExitCode  int
+}
+
+// CommandEditor lets the user change a command before it runs.
+type CommandEditor interface {
+	Edit(ctx context.Context, command string) (string, error)
+}
+
+// exitCoder is implemented by errors carrying a process exit code, such as *exec.ExitError.
+type exitCoder interface {
+	error
+	ExitCode() int
+}
+
+func (r Runner) Run(ctx context.Context, prompt string, generator CommandGenerator) error {
+	if r.answers == nil && r.Stdin != nil {
+		r.answers = bufio.NewReader(r.Stdin)
+	}
+
+	_, err := r.runRequest(ctx, provider.Request{
+		Prompt:       prompt,
+		Input:        r.Input,
+		Environment:  r.environment(ctx),
+		Instructions: r.Instructions,
+		Candidates:   r.Candidates,
+	}, generator)
+
+	return err
+}
+
+// environment returns the facts about the machine and the working tree sent
+// with a prompt.
+func (r Runner) environment(ctx context.Context) []string {
+	if r.Workspace == nil {
+		return r.Environment
+	}
+
+	return append(slices.Clip(r.Environment), r.Workspace(ctx)...)
 }
 
-func (r Runner) Run(ctx context.Context, prompt string, provider CommandGenerator) error {
-	command, err := provider.GenerateCommand(ctx, prompt)
+// runRequest generates a command for req and runs it, asking for fixes when
+// enabled. The returned turn describes the last command and its outcome.
+func (r Runner) runRequest(ctx context.Context, req provider.Request, generator CommandGenerator) (provider.Turn, error) {
+	turn := provider.Turn{Prompt: req.Prompt}
+
+	command, usage, err := r.generate(ctx, generator, req)
 	if err != nil {
-		return fmt.Errorf("generate command: %w", err)
+		return turn, err
+	}
+
+	// lastFailure keeps the previous exit error so that declining a fix still
+	// reports the original failure.
+	var lastFailure, runErr error
+
+	for {
+		stderrTail := newTailBuffer(stderrTailSize)
+		output := newTailBuffer(outputTailSize)
+
+		var outcome execution
+
+		outcome, runErr = r.execute(ctx, command, stderrTail, output)
+		command = outcome.command
+		turn.Command = command
+		turn.Ran = outcome.ran
+		turn.Output = output.String()
+		turn.ExitCode = exitCode(runErr)
+
+		r.record(Record{
+			Prompt:    req.Prompt,
+			Command:   command,
+			Provider:  usage.Provider,
+			Model:     usage.Model,
+			Mode:      r.Mode,
+			Confirmed: outcome.confirmed,
+			Ran:       outcome.ran,
+			ExitCode:  turn.ExitCode,
+		})
+
+		if errors.Is(runErr, ErrCancelled) && lastFailure != nil {
+			return turn, lastFailure
+		}
+
+		var exitErr exitCoder
+		if !errors.As(runErr, &exitErr) || !r.canFix(ctx, len(req.Failures)) {
+			return turn, runErr
+		}
+
+		lastFailure = runErr
+		req.Failures = append(req.Failures, provider.Failure{
+			Command:  command,
+			ExitCode: exitErr.ExitCode(),
+			Stderr:   stderrTail.String(),
+		})
+
+		if r.Mode != ModeQuiet {
+			_, _ = fmt.Fprintf(r.Stdout, "Command exited with status %d, asking for a fix (%d/%d)...\n",
+				exitErr.ExitCode(), len(req.Failures), r.MaxFixAttempts)
+		}
+
+		command, usage, err = r.generate(ctx, generator, req)
+		if err != nil {
+			if errors.Is(err, ErrCancelled) {
+				return turn, runErr
+			}
+
+			return turn, err
+		}
+	}
+}
+
+// exitCode returns the exit status carried by err: 0 for nil and -1 when err
+// does not come from the process exiting.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
 	}
 
-	command = strings.TrimSpace(command)
-	if command == "" {
-		return errors.New("empty command generated")
+	var exitErr exitCoder
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
 	}
 
-	if command == "UNABLE_TO_RUN_LOCAL" {
+	return -1
+}
+
+func (r Runner) record(rec Record) {
+	if r.OnRecord != nil {
+		r.OnRecord(rec)
+	}
+}
+
+func (r Runner) canFix(ctx context.Context, attempts int) bool {
+	return r.Mode != ModeDryRun && attempts < r.MaxFixAttempts && ctx.Err() == nil
+}
+
+func (r Runner) generate(
+	ctx context.Context,
+	generator CommandGenerator,
+	req provider.Request,
+) (string, provider.Usage, error) {
+	var view *liveView
+	if r.Live && req.Candidates <= 1 {
+		view = startLiveView(r.Stdout)
+		req.OnPartial = view.update
+	}
+
+	generated, err := generator.GenerateCommand(ctx, req)
+
+	if view != nil {
+		view.stop()
+	}
+
+	if err != nil {
+		return "", provider.Usage{}, fmt.Errorf("generate command: %w", err)
+	}
+
+	if r.OnUsage != nil {
+		r.OnUsage(generated.Usage)
+	}
+
+	if generated.Usage.Cached && (r.Mode == ModeConfirm || r.Mode == ModeYOLO) {
+		_, _ = fmt.Fprintln(r.Stdout, "Using a cached answer.")
+	}
+
+	candidates := make([]provider.Candidate, 0, len(generated.Candidates))
+	unable := false
+
+	for _, candidate := range generated.Candidates {
+		candidate.Command = strings.TrimSpace(candidate.Command)
+
+		switch candidate.Command {
+		case "":
+		case "UNABLE_TO_RUN_LOCAL":
+			unable = true
+		default:
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if len(candidates) == 0 {
+		if !unable {
+			return "", generated.Usage, errors.New("empty command generated")
+		}
+
 		if r.Mode != ModeQuiet {
 			_, _ = fmt.Fprintln(r.Stdout, "Unable to process the request locally with shell scripting tools.")
 		}
 
-		return ErrCancelled
+		return "", generated.Usage, ErrCancelled
 	}
 
-	switch r.Mode {
-	case ModeDryRun:
-		_, _ = fmt.Fprintln(r.Stdout, command)
+	if len(candidates) == 1 || r.Mode != ModeConfirm {
+		return candidates[0].Command, generated.Usage, nil
+	}
 
-		return nil
-	case ModeQuiet:
-		return r.Executor.Execute(ctx, command, io.Discard, io.Discard, r.Stdin)
-	case ModeYOLO:
-		return r.runWithConfirmation(ctx, command, false)
-	default:
-		return r.runWithConfirmation(ctx, command, true)
+	command, err := r.choose(ctx, candidates)
+
+	return command, generated.Usage, err
+}
+
+// choose shows a numbered menu of candidates and returns the one the user picked.
+func (r Runner) choose(ctx context.Context, candidates []provider.Candidate) (string, error) {
+	_, _ = fmt.Fprintln(r.Stdout, "Candidates:")
+
+	for i, candidate := range candidates {
+		level := risk.Classify(candidate.Command).Level
+
+		_, _ = fmt.Fprintf(r.Stdout, "  %d) %s`%s`%s [%s%s risk%s]\n",
+			i+1, colorCyan, candidate.Command, colorReset, riskColor(level), level, colorReset)
+
+		if candidate.Description != "" {
+			_, _ = fmt.Fprintf(r.Stdout, "     %s\n", candidate.Description)
+		}
 	}
+
+	_, _ = fmt.Fprintf(r.Stdout, "Choose a command [1-%d]: ", len(candidates))
+
+	line, err := r.readLine(ctx)
+	if err != nil {
+		return "", fmt.Errorf("read choice: %w", err)
+	}
+
+	choice, err := strconv.Atoi(line)
+	if err != nil || choice < 1 || choice > len(candidates) {
+		_, _ = fmt.Fprintln(r.Stdout, "Canceled.")
+
+		return "", ErrCancelled
+	}
+
+	return candidates[choice-1].Command, nil
 }
 
+// execution is the outcome of execute.
+type execution struct {
+	// command is the command that was actually run, which differs from the
+	// generated one when the user edited it.
+	command   string
+	ran       bool
+	confirmed bool
+}
+
+// execute checks the command against the policy and runs it according to the
+// mode, copying its stderr into stderrTail and all of its output into output.
+func (r Runner) execute(ctx context.Context, command string, stderrTail, output io.Writer) (execution, error) {
+	edited := false
+
+	for {
+		decision := r.Policy.Check(command)
+		if decision.Action == policy.ActionDeny {
+			return execution{command: command}, fmt.Errorf("%w: %s", ErrPolicyDenied, decision.Reason)
+		}
+
+		if r.Mode == ModeDryRun {
+			_, _ = fmt.Fprintln(r.Stdout, command)
+
+			return execution{command: command}, nil
+		}
+
+		assessment := risk.Classify(command)
+		needsConfirm := edited || !r.skipsConfirmation()
+
+		switch {
+		case decision.Action == policy.ActionConfirm:
+			_, _ = fmt.Fprintf(r.Stdout, "Policy requires confirmation: %s\n", decision.Reason)
+
+			needsConfirm = true
+		case assessment.Level >= risk.LevelHigh && !needsConfirm:
+			_, _ = fmt.Fprintln(r.Stdout, "High-risk command, confirmation required.")
+
+			needsConfirm = true
+		}
+
+		if needsConfirm {
+			answer, err := r.confirm(ctx, command, assessment)
+			if err != nil {
+				return execution{command: command}, err
+			}
+
+			if answer == answerEdit {
+				if command, err = r.edit(ctx, command); err != nil {
+					return execution{command: command}, err
+				}
+
+				edited = true
+
+				continue
+			}
+		}
+
+		err := r.run(ctx, command, stderrTail, output, needsConfirm)
+
+		return execution{command: command, ran: true, confirmed: needsConfirm}, err
+	}
+}
+
+// skipsConfirmation reports whether the mode runs commands without asking.
+// High-risk commands and policy confirmations are still asked for.
+func (r Runner) skipsConfirmation() bool {
+	return r.Mode == ModeYOLO || r.Mode == ModeQuiet
+}
+
+func (r Runner) run(ctx context.Context, command string, stderrTail, output io.Writer, confirmed bool) error {
+	if r.Mode == ModeQuiet {
+		return r.Executor.Execute(ctx, command, output, io.MultiWriter(stderrTail, output), r.Stdin)
+	}
+
+	if !confirmed {
+		_, _ = fmt.Fprintf(r.Stdout, "Running: %s`%s`%s\n", colorCyan, command, colorReset)
+	}
+
+	stderr := io.MultiWriter(stderrTail, output)
+	if r.Stderr != nil {
+		stderr = io.MultiWriter(r.Stderr, stderrTail, output)
+	}
+
+	return r.Executor.Execute(ctx, command, io.MultiWriter(r.Stdout, output), stderr, r.Stdin)
+}
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+type answer int
+
 const (
-	colorReset = "\033[0m"
-	colorCyan  = "\033[36m"
+	answerYes answer = iota
+	answerEdit
 )
 
-func (r Runner) runWithConfirmation(ctx context.Context, command string, forceConfirm bool) error {
-	if forceConfirm {
-		if err := r.confirm(ctx, command); err != nil {
-			return err
+func (r Runner) confirm(ctx context.Context, command string, assessment risk.Assessment) (answer, error) {
+	levelColor := riskColor(assessment.Level)
+
+	if len(assessment.Reasons) > 0 {
+		_, _ = fmt.Fprintf(r.Stdout, "%sRisk: %s%s (%s)\n",
+			levelColor, assessment.Level, colorReset, strings.Join(assessment.Reasons, "; "))
+	}
+
+	_, _ = fmt.Fprintf(r.Stdout, "I would run %s`%s`%s [%s%s risk%s], confirm? [y/N/e] ",
+		colorCyan, command, colorReset, levelColor, assessment.Level, colorReset)
+
+	line, err := r.readLine(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("read confirmation: %w", err)
+	}
+
+	switch strings.ToLower(line) {
+	case "y", "yes":
+		return answerYes, nil
+	case "e", "edit":
+		return answerEdit, nil
+	default:
+		_, _ = fmt.Fprintln(r.Stdout, "Canceled.")
+
+		return 0, ErrCancelled
+	}
+}
+
+// edit lets the user change the command, using the configured editor or an
+// inline prompt when there is none. An empty result cancels the run.
+func (r Runner) edit(ctx context.Context, command string) (string, error) {
+	var (
+		edited string
+		err    error
+	)
+
+	if r.Editor != nil {
+		edited, err = r.Editor.Edit(ctx, command)
+		if err != nil {
+			return command, fmt.Errorf("edit command: %w", err)
 		}
 	} else {
-		_, _ = fmt.Fprintf(r.Stdout, "Running: %s`%s`%s\n", colorCyan, command, colorReset)
+		_, _ = fmt.Fprintf(r.Stdout, "Edit command (empty keeps it unchanged):\n> ")
+
+		edited, err = r.readLine(ctx)
+		if err != nil {
+			return command, fmt.Errorf("read edited command: %w", err)
+		}
+
+		if edited == "" {
+			edited = command
+		}
 	}
 
-	return r.Executor.Execute(ctx, command, r.Stdout, r.Stderr, r.Stdin)
+	edited = strings.TrimSpace(edited)
+	if edited == "" {
+		_, _ = fmt.Fprintln(r.Stdout, "Canceled.")
+
+		return command, ErrCancelled
+	}
+
+	return edited, nil
 }
 
-func (r Runner) confirm(ctx context.Context, command string) error {
-	_, _ = fmt.Fprintf(r.Stdout, "I would run %s`%s`%s, confirm? [y/N] ", colorCyan, command, colorReset)
+// readLine reads one trimmed line of user input. Canceling the context
+// returns ErrCancelled; EOF returns whatever was read so far.
+func (r Runner) readLine(ctx context.Context) (string, error) {
+	line, err := r.readInput(ctx)
+	if errors.Is(err, io.EOF) {
+		return line, nil
+	}
+
+	return line, err
+}
 
+// readInput is like readLine but returns io.EOF when the input ends before
+// anything was read.
+func (r Runner) readInput(ctx context.Context) (string, error) {
 	type readResult struct {
-		answer string
-		err    error
+		line string
+		err  error
 	}
 
 	done := make(chan readResult, 1)
 
 	go func() {
-		reader := bufio.NewReader(r.Stdin)
+		line, err := r.input().ReadString('\n')
 
-		answer, err := reader.ReadString('\n')
-
-		done <- readResult{answer, err}
+		done <- readResult{line, err}
 	}()
 
 	select {
 	case <-ctx.Done():
 		_, _ = fmt.Fprintln(r.Stdout)
 
-		return ErrCancelled
+		return "", ErrCancelled
 	case res := <-done:
-		if res.err != nil && !errors.Is(res.err, io.EOF) {
-			return fmt.Errorf("read confirmation: %w", res.err)
+		if errors.Is(res.err, io.EOF) && res.line == "" {
+			return "", io.EOF
 		}
 
-		answer := strings.TrimSpace(strings.ToLower(res.answer))
-		if answer == "y" || answer == "yes" {
-			return nil
+		if res.err != nil && !errors.Is(res.err, io.EOF) {
+			return "", res.err
 		}
 
-		_, _ = fmt.Fprintln(r.Stdout, "Canceled.")
+		return strings.TrimSpace(res.line), nil
+	}
+}
 
-		return ErrCancelled
+// input returns the buffered reader for user answers, shared across prompts
+// within a single Run so that buffered input is not lost between them.
+func (r Runner) input() *bufio.Reader {
+	if r.answers != nil {
+		return r.answers
+	}
+
+	return bufio.NewReader(r.Stdin)
+}
+
+func riskColor(level risk.Level) string {
+	switch level {
+	case risk.LevelHigh:
+		return colorRed
+	case risk.LevelMedium:
+		return colorYellow
+	default:
+		return colorCyan
 	}
 }
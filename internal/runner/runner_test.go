@@ -9,22 +9,52 @@ import (
 	"testing"
 	"time"
 
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/policy"
 	"github.com/metalagman/aida/internal/runner"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 type fakeProvider struct {
-	command string
-	err     error
+	command    string
+	candidates []provider.Candidate
+	usage      provider.Usage
+	err        error
 }
 
-func (p fakeProvider) GenerateCommand(ctx context.Context, _ string) (string, error) {
+func (p fakeProvider) GenerateCommand(ctx context.Context, _ provider.Request) (provider.Result, error) {
 	if p.err != nil {
-		return "", p.err
+		return provider.Result{}, p.err
+	}
+
+	if p.candidates != nil {
+		return provider.Result{Candidates: p.candidates}, nil
 	}
 
-	return p.command, nil
+	return provider.Result{Candidates: []provider.Candidate{{Command: p.command}}, Usage: p.usage}, nil
+}
+
+// streamingProvider streams its command through OnPartial before returning it.
+type streamingProvider struct {
+	partials []string
+	requests []provider.Request
+}
+
+func (p *streamingProvider) GenerateCommand(_ context.Context, req provider.Request) (provider.Result, error) {
+	p.requests = append(p.requests, req)
+
+	var command string
+
+	for _, partial := range p.partials {
+		command += partial
+		if req.OnPartial != nil {
+			req.OnPartial(command)
+		}
+	}
+
+	return provider.Result{Candidates: []provider.Candidate{{Command: command}}}, nil
 }
 
 type fakeExecutor struct {
@@ -131,6 +161,61 @@ func TestRunnerYOLO(t *testing.T) {
 	assert.Contains(t, stdout.String(), "Running: \x1b[36m`ls`\x1b[0m")
 }
 
+func TestRunnerLiveStreamsCommand(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &fakeExecutor{}
+	generator := &streamingProvider{partials: []string{"```sh\n", "ls ", "-la\n```"}}
+	r := runner.Runner{
+		Mode:     runner.ModeYOLO,
+		Stdout:   &stdout,
+		Stdin:    strings.NewReader(""),
+		Executor: exec,
+		Live:     true,
+	}
+
+	err := r.Run(context.Background(), "list files", generator)
+	require.NoError(t, err)
+	require.Len(t, generator.requests, 1)
+	assert.NotNil(t, generator.requests[0].OnPartial)
+	assert.Contains(t, stdout.String(), "\x1b[36mls -la\x1b[0m")
+	assert.Contains(t, stdout.String(), "\r\x1b[KRunning:")
+}
+
+func TestRunnerLiveSkippedForCandidates(t *testing.T) {
+	generator := &streamingProvider{partials: []string{"ls"}}
+	r := runner.Runner{
+		Mode:       runner.ModeYOLO,
+		Stdout:     io.Discard,
+		Stdin:      strings.NewReader(""),
+		Executor:   &fakeExecutor{},
+		Candidates: 2,
+		Live:       true,
+	}
+
+	err := r.Run(context.Background(), "list files", generator)
+	require.NoError(t, err)
+	require.Len(t, generator.requests, 1)
+	assert.Nil(t, generator.requests[0].OnPartial)
+}
+
+func TestRunnerCachedAnswerIsConfirmed(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &fakeExecutor{}
+	r := runner.Runner{
+		Mode:     runner.ModeConfirm,
+		Stdout:   &stdout,
+		Stdin:    strings.NewReader("n\n"),
+		Executor: exec,
+	}
+
+	err := r.Run(context.Background(), "list files", fakeProvider{command: "ls", usage: provider.Usage{Cached: true}})
+	require.ErrorIs(t, err, runner.ErrCancelled)
+	assert.False(t, exec.called)
+	assert.Contains(t, stdout.String(), "Using a cached answer.")
+}
+
 func TestRunnerEmptyCommand(t *testing.T) {
 	r := runner.Runner{Mode: runner.ModeConfirm}
 	err := r.Run(context.Background(), "noop", fakeProvider{command: " "})
@@ -187,3 +272,542 @@ func TestRunnerDryRunOutputsOnlyCommand(t *testing.T) {
 	assert.Equal(t, "ls -la\n", stdout.String())
 	assert.False(t, exec.called)
 }
+
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func (e exitError) ExitCode() int {
+	return e.code
+}
+
+// sequenceProvider returns commands in order and records the requests it received.
+type sequenceProvider struct {
+	commands []string
+	requests []provider.Request
+}
+
+func (p *sequenceProvider) GenerateCommand(_ context.Context, req provider.Request) (provider.Result, error) {
+	p.requests = append(p.requests, req)
+	command := p.commands[0]
+	p.commands = p.commands[1:]
+
+	return provider.Result{
+		Candidates: []provider.Candidate{{Command: command}},
+		Usage:      provider.Usage{Provider: "fake", Model: "fake-model", PromptTokens: 10, CompletionTokens: 2},
+	}, nil
+}
+
+// failingExecutor fails every command listed in failures with exit status 1.
+type failingExecutor struct {
+	failures map[string]string
+	commands []string
+}
+
+func (e *failingExecutor) Execute(_ context.Context, command string, _, stderr io.Writer, _ io.Reader) error {
+	e.commands = append(e.commands, command)
+
+	if message, ok := e.failures[command]; ok {
+		_, _ = fmt.Fprint(stderr, message)
+
+		return exitError{code: 1}
+	}
+
+	return nil
+}
+
+func TestRunnerFixRetriesFailedCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exec := &failingExecutor{failures: map[string]string{"ls --color": "ls: unrecognized option"}}
+	gen := &sequenceProvider{commands: []string{"ls --color", "ls -G"}}
+	r := runner.Runner{
+		Mode:           runner.ModeYOLO,
+		Stdout:         &stdout,
+		Stderr:         &stderr,
+		Executor:       exec,
+		MaxFixAttempts: 2,
+	}
+
+	err := r.Run(context.Background(), "list files", gen)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ls --color", "ls -G"}, exec.commands)
+	assert.Contains(t, stdout.String(), "Command exited with status 1, asking for a fix (1/2)")
+	assert.Contains(t, stderr.String(), "ls: unrecognized option")
+
+	require.Len(t, gen.requests, 2)
+	assert.Empty(t, gen.requests[0].Failures)
+	assert.Equal(t, []provider.Failure{
+		{Command: "ls --color", ExitCode: 1, Stderr: "ls: unrecognized option"},
+	}, gen.requests[1].Failures)
+}
+
+func TestRunnerReportsUsage(t *testing.T) {
+	var reported []provider.Usage
+
+	exec := &failingExecutor{failures: map[string]string{"ls --color": "ls: unrecognized option"}}
+	gen := &sequenceProvider{commands: []string{"ls --color", "ls -G"}}
+	r := runner.Runner{
+		Mode:           runner.ModeYOLO,
+		Stdout:         io.Discard,
+		Stdin:          strings.NewReader(""),
+		Executor:       exec,
+		MaxFixAttempts: 1,
+		OnUsage:        func(u provider.Usage) { reported = append(reported, u) },
+	}
+
+	require.NoError(t, r.Run(context.Background(), "list files", gen))
+
+	usage := provider.Usage{Provider: "fake", Model: "fake-model", PromptTokens: 10, CompletionTokens: 2}
+	assert.Equal(t, []provider.Usage{usage, usage}, reported)
+}
+
+func TestRunnerSendsInput(t *testing.T) {
+	exec := &failingExecutor{failures: map[string]string{"grep -c ERROR app.log": "no such file"}}
+	gen := &sequenceProvider{commands: []string{"grep -c ERROR app.log", "grep -c ERROR logs/app.log"}}
+	r := runner.Runner{
+		Mode:           runner.ModeYOLO,
+		Stdout:         io.Discard,
+		Stdin:          strings.NewReader(""),
+		Executor:       exec,
+		MaxFixAttempts: 1,
+		Input:          "ERROR db timeout",
+	}
+
+	require.NoError(t, r.Run(context.Background(), "count the errors", gen))
+	require.Len(t, gen.requests, 2)
+	assert.Equal(t, "ERROR db timeout", gen.requests[0].Input)
+	assert.Equal(t, "ERROR db timeout", gen.requests[1].Input)
+}
+
+func TestReadInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "empty", input: " \n\n", want: ""},
+		{name: "short", input: "error: disk full\n", want: "error: disk full"},
+		{
+			name:  "truncated at line boundary",
+			input: strings.Repeat("x", 10<<10) + "\nlast line\n",
+			want:  fmt.Sprintf("[%d earlier bytes omitted]\n", 10<<10+1) + "last line",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runner.ReadInput(context.Background(), strings.NewReader(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadInputKeepsTail(t *testing.T) {
+	var input strings.Builder
+	for i := range 2000 {
+		fmt.Fprintf(&input, "line %d\n", i)
+	}
+
+	got, err := runner.ReadInput(context.Background(), strings.NewReader(input.String()))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(got), 8<<10+64)
+	assert.Regexp(t, `^\[\d+ earlier bytes omitted\]\nline \d+\n`, got)
+	assert.True(t, strings.HasSuffix(got, "\nline 1999"))
+}
+
+// endlessReader returns line until it is closed and then blocks, like a pipe
+// whose writer never exits.
+type endlessReader struct {
+	line   string
+	blocks chan struct{}
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	if r.line == "" {
+		<-r.blocks
+
+		return 0, io.EOF
+	}
+
+	return copy(p, r.line), nil
+}
+
+func TestReadInputStopsOnOpenInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		timeout   time.Duration
+		wantEmpty bool
+	}{
+		{name: "silent until the deadline", timeout: 50 * time.Millisecond, wantEmpty: true},
+		{name: "endless output", line: strings.Repeat("tick\n", 1000), timeout: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &endlessReader{line: tt.line, blocks: make(chan struct{})}
+			t.Cleanup(func() { close(r.blocks) })
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			got, err := runner.ReadInput(ctx, r)
+			require.NoError(t, err)
+
+			if tt.wantEmpty {
+				assert.Empty(t, got)
+
+				return
+			}
+
+			assert.Regexp(t, `^\[\d+ earlier bytes omitted\]\ntick\n`, got)
+			assert.True(t, strings.HasSuffix(got, "tick\n[stopped reading before the end of the input]"), got)
+		})
+	}
+}
+
+func TestRunnerRecordsCommands(t *testing.T) {
+	var records []runner.Record
+
+	exec := &failingExecutor{failures: map[string]string{"ls --color": "ls: unrecognized option"}}
+	gen := &sequenceProvider{commands: []string{"ls --color", "ls -G"}}
+	r := runner.Runner{
+		Mode:           runner.ModeConfirm,
+		Stdout:         io.Discard,
+		Stdin:          strings.NewReader("y\nn\n"),
+		Executor:       exec,
+		MaxFixAttempts: 1,
+		OnRecord:       func(rec runner.Record) { records = append(records, rec) },
+	}
+
+	err := r.Run(context.Background(), "list files", gen)
+
+	var exitErr exitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, []runner.Record{
+		{
+			Prompt: "list files", Command: "ls --color", Provider: "fake", Model: "fake-model",
+			Mode: runner.ModeConfirm, Confirmed: true, Ran: true, ExitCode: 1,
+		},
+		{
+			Prompt: "list files", Command: "ls -G", Provider: "fake", Model: "fake-model",
+			Mode: runner.ModeConfirm, ExitCode: -1,
+		},
+	}, records)
+}
+
+func TestRunnerFixStopsAfterMaxAttempts(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &failingExecutor{failures: map[string]string{"a": "", "b": ""}}
+	gen := &sequenceProvider{commands: []string{"a", "b"}}
+	r := runner.Runner{
+		Mode:           runner.ModeYOLO,
+		Stdout:         &stdout,
+		Executor:       exec,
+		MaxFixAttempts: 1,
+	}
+
+	err := r.Run(context.Background(), "do it", gen)
+
+	var exitErr exitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, []string{"a", "b"}, exec.commands)
+	assert.Len(t, gen.requests, 2)
+}
+
+func TestRunnerFixDisabledByDefault(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &failingExecutor{failures: map[string]string{"a": ""}}
+	gen := &sequenceProvider{commands: []string{"a"}}
+	r := runner.Runner{
+		Mode:     runner.ModeYOLO,
+		Stdout:   &stdout,
+		Executor: exec,
+	}
+
+	err := r.Run(context.Background(), "do it", gen)
+
+	var exitErr exitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Len(t, gen.requests, 1)
+}
+
+func TestRunnerFixDeclinedKeepsOriginalFailure(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &failingExecutor{failures: map[string]string{"a": ""}}
+	gen := &sequenceProvider{commands: []string{"a", "b"}}
+	r := runner.Runner{
+		Mode:           runner.ModeConfirm,
+		Stdout:         &stdout,
+		Stdin:          strings.NewReader("y\nn\n"),
+		Executor:       exec,
+		MaxFixAttempts: 1,
+	}
+
+	err := r.Run(context.Background(), "do it", gen)
+
+	var exitErr exitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, []string{"a"}, exec.commands)
+}
+
+func TestRunnerConfirmShowsRisk(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &fakeExecutor{}
+	r := runner.Runner{
+		Mode:     runner.ModeConfirm,
+		Stdout:   &stdout,
+		Stdin:    strings.NewReader("n\n"),
+		Executor: exec,
+	}
+
+	err := r.Run(context.Background(), "clean up", fakeProvider{command: "rm -rf build"})
+	assert.ErrorIs(t, err, runner.ErrCancelled)
+	assert.Contains(t, stdout.String(), "Risk: high\x1b[0m (rm deletes recursively)")
+	assert.Contains(t, stdout.String(), "[\x1b[31mhigh risk\x1b[0m]")
+}
+
+func TestRunnerConfirmsHighRisk(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    runner.RunMode
+		command string
+		answer  string
+		wantRun bool
+		wantErr error
+	}{
+		{name: "yolo declined", mode: runner.ModeYOLO, command: "sudo rm -rf /", answer: "n\n", wantErr: runner.ErrCancelled},
+		{name: "yolo accepted", mode: runner.ModeYOLO, command: "sudo rm -rf /", answer: "y\n", wantRun: true},
+		{name: "quiet declined", mode: runner.ModeQuiet, command: "dd if=/dev/zero of=/dev/sda", answer: "n\n",
+			wantErr: runner.ErrCancelled},
+		{name: "quiet accepted", mode: runner.ModeQuiet, command: "rm -rf build", answer: "y\n", wantRun: true},
+		{name: "quiet low risk", mode: runner.ModeQuiet, command: "ls -la", wantRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+
+			exec := &fakeExecutor{}
+			r := runner.Runner{
+				Mode:     tt.mode,
+				Stdout:   &stdout,
+				Stdin:    strings.NewReader(tt.answer),
+				Executor: exec,
+			}
+
+			err := r.Run(context.Background(), "do it", fakeProvider{command: tt.command})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantRun, exec.called)
+			assert.NotContains(t, stdout.String(), "Running:")
+
+			if tt.answer == "" {
+				assert.NotContains(t, stdout.String(), "High-risk command")
+			} else {
+				assert.Contains(t, stdout.String(), "High-risk command, confirmation required.")
+			}
+		})
+	}
+}
+
+func TestRunnerPolicyDenies(t *testing.T) {
+	var stdout bytes.Buffer
+
+	p, err := policy.New(config.PolicyConfig{DenyPrograms: []string{"shutdown"}})
+	require.NoError(t, err)
+
+	exec := &fakeExecutor{}
+	r := runner.Runner{
+		Mode:     runner.ModeYOLO,
+		Stdout:   &stdout,
+		Executor: exec,
+		Policy:   p,
+	}
+
+	err = r.Run(context.Background(), "turn it off", fakeProvider{command: "sudo shutdown now"})
+	require.ErrorIs(t, err, runner.ErrPolicyDenied)
+	assert.Contains(t, err.Error(), `program "shutdown" is denied`)
+	assert.False(t, exec.called)
+}
+
+func TestRunnerPolicyRequiresConfirmation(t *testing.T) {
+	var stdout bytes.Buffer
+
+	p, err := policy.New(config.PolicyConfig{ConfirmPrograms: []string{"git"}})
+	require.NoError(t, err)
+
+	exec := &fakeExecutor{}
+	r := runner.Runner{
+		Mode:     runner.ModeQuiet,
+		Stdout:   &stdout,
+		Stdin:    strings.NewReader("y\n"),
+		Executor: exec,
+		Policy:   p,
+	}
+
+	err = r.Run(context.Background(), "status", fakeProvider{command: "git status"})
+	require.NoError(t, err)
+	assert.True(t, exec.called)
+	assert.Contains(t, stdout.String(), `Policy requires confirmation: program "git" matches rule "git"`)
+}
+
+type fakeEditor struct {
+	result string
+	got    string
+}
+
+func (e *fakeEditor) Edit(_ context.Context, command string) (string, error) {
+	e.got = command
+
+	return e.result, nil
+}
+
+func TestRunnerConfirmEditInline(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &fakeExecutor{}
+	r := runner.Runner{
+		Mode:     runner.ModeConfirm,
+		Stdout:   &stdout,
+		Stdin:    strings.NewReader("e\nls -la /tmp\ny\n"),
+		Executor: exec,
+	}
+
+	err := r.Run(context.Background(), "list files", fakeProvider{command: "ls -la"})
+	require.NoError(t, err)
+	assert.Equal(t, "ls -la /tmp", exec.command)
+	assert.Contains(t, stdout.String(), "confirm? [y/N/e]")
+	assert.Contains(t, stdout.String(), "I would run \x1b[36m`ls -la /tmp`\x1b[0m")
+}
+
+func TestRunnerConfirmEditWithEditor(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &fakeExecutor{}
+	editor := &fakeEditor{result: "ls -lah"}
+	r := runner.Runner{
+		Mode:     runner.ModeConfirm,
+		Stdout:   &stdout,
+		Stdin:    strings.NewReader("e\nn\n"),
+		Executor: exec,
+		Editor:   editor,
+	}
+
+	err := r.Run(context.Background(), "list files", fakeProvider{command: "ls -la"})
+	assert.ErrorIs(t, err, runner.ErrCancelled)
+	assert.Equal(t, "ls -la", editor.got)
+	assert.False(t, exec.called)
+	assert.Contains(t, stdout.String(), "I would run \x1b[36m`ls -lah`\x1b[0m")
+}
+
+func TestRunnerEditedCommandIsCheckedByPolicy(t *testing.T) {
+	var stdout bytes.Buffer
+
+	p, err := policy.New(config.PolicyConfig{DenyPrograms: []string{"reboot"}})
+	require.NoError(t, err)
+
+	exec := &fakeExecutor{}
+	r := runner.Runner{
+		Mode:     runner.ModeConfirm,
+		Stdout:   &stdout,
+		Stdin:    strings.NewReader("e\n"),
+		Executor: exec,
+		Editor:   &fakeEditor{result: "reboot"},
+		Policy:   p,
+	}
+
+	err = r.Run(context.Background(), "uptime", fakeProvider{command: "uptime"})
+	require.ErrorIs(t, err, runner.ErrPolicyDenied)
+	assert.False(t, exec.called)
+}
+
+func TestExternalEditor(t *testing.T) {
+	// The editor receives the temp file path as its last argument.
+	editor := runner.ExternalEditor{Program: `rewrite() { printf 'ls -lah\n' > "$1"; }; rewrite`}
+
+	got, err := editor.Edit(context.Background(), "ls -la")
+	require.NoError(t, err)
+	assert.Equal(t, "ls -lah", got)
+}
+
+func TestRunnerChoosesCandidate(t *testing.T) {
+	var stdout bytes.Buffer
+
+	exec := &fakeExecutor{}
+	r := runner.Runner{
+		Mode:     runner.ModeConfirm,
+		Stdout:   &stdout,
+		Stdin:    strings.NewReader("2\ny\n"),
+		Executor: exec,
+	}
+
+	gen := fakeProvider{candidates: []provider.Candidate{
+		{Command: "find . -name '*.log' -exec rm {} +", Description: "delete with find -exec"},
+		{Command: "find . -name '*.log' -print0 | xargs -0 rm", Description: "pipe to xargs"},
+	}}
+
+	err := r.Run(context.Background(), "delete logs", gen)
+	require.NoError(t, err)
+	assert.Equal(t, "find . -name '*.log' -print0 | xargs -0 rm", exec.command)
+	assert.Contains(t, stdout.String(), "1) \x1b[36m`find . -name '*.log' -exec rm {} +`\x1b[0m")
+	assert.Contains(t, stdout.String(), "     pipe to xargs\n")
+	assert.Contains(t, stdout.String(), "Choose a command [1-2]: ")
+}
+
+func TestRunnerCandidates(t *testing.T) {
+	candidates := []provider.Candidate{
+		{Command: "UNABLE_TO_RUN_LOCAL"},
+		{Command: "ls -la"},
+		{Command: "ls -lah"},
+	}
+
+	tests := []struct {
+		name    string
+		mode    runner.RunMode
+		stdin   string
+		wantErr error
+		want    string
+	}{
+		{name: "invalid choice cancels", mode: runner.ModeConfirm, stdin: "3\n", wantErr: runner.ErrCancelled},
+		{name: "empty choice cancels", mode: runner.ModeConfirm, stdin: "\n", wantErr: runner.ErrCancelled},
+		{name: "yolo runs the first", mode: runner.ModeYOLO, want: "ls -la"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &fakeExecutor{}
+			r := runner.Runner{
+				Mode:     tt.mode,
+				Stdout:   io.Discard,
+				Stdin:    strings.NewReader(tt.stdin),
+				Executor: exec,
+			}
+
+			err := r.Run(context.Background(), "list files", fakeProvider{candidates: candidates})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.False(t, exec.called)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, exec.command)
+		})
+	}
+}
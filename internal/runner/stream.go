@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	spinnerInterval = 100 * time.Millisecond
+	// livePreviewSize bounds how many trailing characters of the partial
+	// command are shown, keeping the preview on a single terminal line.
+	livePreviewSize = 100
+	clearLine       = "\r\033[K"
+)
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// liveView renders a spinner on a terminal line until the first token of a
+// streamed command arrives, then the partial command as it grows.
+type liveView struct {
+	out  io.Writer
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	mu      sync.Mutex
+	partial string
+	stopped bool
+}
+
+func startLiveView(out io.Writer) *liveView {
+	v := &liveView{
+		out:  out,
+		done: make(chan struct{}),
+	}
+
+	v.wg.Add(1)
+
+	go v.spin()
+
+	return v
+}
+
+func (v *liveView) spin() {
+	defer v.wg.Done()
+
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		v.mu.Lock()
+		if v.partial == "" {
+			_, _ = fmt.Fprintf(v.out, "%s%s Generating command...", clearLine, spinnerFrames[frame%len(spinnerFrames)])
+		}
+		v.mu.Unlock()
+
+		select {
+		case <-v.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update shows the command generated so far.
+func (v *liveView) update(partial string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.stopped {
+		return
+	}
+
+	v.partial = preview(partial)
+	if v.partial != "" {
+		_, _ = fmt.Fprintf(v.out, "%s%s%s%s", clearLine, colorCyan, v.partial, colorReset)
+	}
+}
+
+// stop ends the spinner and clears the line so the final command can be shown.
+func (v *liveView) stop() {
+	v.mu.Lock()
+	v.stopped = true
+	v.mu.Unlock()
+
+	close(v.done)
+	v.wg.Wait()
+
+	_, _ = fmt.Fprint(v.out, clearLine)
+}
+
+// preview turns a partial answer into a single line: markdown fences are
+// dropped, whitespace is collapsed and only the tail is kept.
+func preview(partial string) string {
+	lines := strings.Split(partial, "\n")
+	kept := lines[:0]
+
+	for _, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "```") {
+			kept = append(kept, line)
+		}
+	}
+
+	text := []rune(strings.Join(strings.Fields(strings.Join(kept, " ")), " "))
+	if len(text) > livePreviewSize {
+		text = append([]rune("..."), text[len(text)-livePreviewSize:]...)
+	}
+
+	return string(text)
+}
@@ -0,0 +1,4 @@
+// Package secrets keeps API keys out of the config file: in the OS keyring,
+// in a passphrase-encrypted file, or behind an environment variable or a
+// command such as "pass show openai".
+package secrets
@@ -0,0 +1,247 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	fileVersion = 1
+	// kdfIterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256.
+	kdfIterations = 600_000
+	keySize       = 32
+	saltSize      = 16
+	dirPerm       = 0o700
+	filePerm      = 0o600
+)
+
+// ErrWrongPassphrase is returned when the secrets file cannot be decrypted.
+var ErrWrongPassphrase = errors.New("wrong passphrase for the secrets file")
+
+// FileStore keeps secrets in a file, each encrypted with AES-256-GCM under a
+// key derived from a passphrase. It works where no OS keyring is available.
+type FileStore struct {
+	path string
+	// passphrase is asked for once, when a secret is first read or written.
+	passphrase func() (string, error)
+	key        []byte
+}
+
+// secretsFile is the JSON layout of the file.
+type secretsFile struct {
+	Version int               `json:"version"`
+	Salt    []byte            `json:"salt"`
+	Secrets map[string][]byte `json:"secrets"`
+}
+
+// NewFileStore returns a store kept in path, unlocked with the passphrase
+// returned by passphrase.
+func NewFileStore(path string, passphrase func() (string, error)) *FileStore {
+	return &FileStore{path: path, passphrase: passphrase}
+}
+
+func (s *FileStore) Name() string {
+	return "encrypted file " + s.path
+}
+
+func (s *FileStore) Get(account string) (string, error) {
+	file, err := s.read()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, ok := file.Secrets[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	aead, err := s.cipher(file)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", fmt.Errorf("secret %q is corrupted", account)
+	}
+
+	plain, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(account))
+	if err != nil {
+		s.key = nil
+
+		return "", ErrWrongPassphrase
+	}
+
+	return string(plain), nil
+}
+
+func (s *FileStore) Set(account, secret string) error {
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	aead, err := s.cipher(file)
+	if err != nil {
+		return err
+	}
+
+	// Check the passphrase against an existing secret, so a typo does not
+	// leave secrets encrypted under different keys.
+	if err := s.verify(file, aead); err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("generate nonce: %w", err)
+	}
+
+	file.Secrets[account] = aead.Seal(nonce, nonce, []byte(secret), []byte(account))
+
+	return s.write(file)
+}
+
+func (s *FileStore) Delete(account string) error {
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := file.Secrets[account]; !ok {
+		return ErrNotFound
+	}
+
+	delete(file.Secrets, account)
+
+	return s.write(file)
+}
+
+func (s *FileStore) read() (secretsFile, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return secretsFile{}, fmt.Errorf("generate salt: %w", err)
+		}
+
+		return secretsFile{Version: fileVersion, Salt: salt, Secrets: map[string][]byte{}}, nil
+	}
+
+	if err != nil {
+		return secretsFile{}, fmt.Errorf("read secrets file: %w", err)
+	}
+
+	var file secretsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return secretsFile{}, fmt.Errorf("parse secrets file %s: %w", s.path, err)
+	}
+
+	if file.Version != fileVersion {
+		return secretsFile{}, fmt.Errorf("unsupported secrets file version %d", file.Version)
+	}
+
+	if file.Secrets == nil {
+		file.Secrets = map[string][]byte{}
+	}
+
+	return file, nil
+}
+
+func (s *FileStore) write(file secretsFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal secrets file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), dirPerm); err != nil {
+		return fmt.Errorf("create secrets dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".secrets-*")
+	if err != nil {
+		return fmt.Errorf("create secrets file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), filePerm); err != nil {
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileStore) cipher(file secretsFile) (cipher.AEAD, error) {
+	if s.key == nil {
+		if s.passphrase == nil {
+			return nil, errors.New("no passphrase for the secrets file")
+		}
+
+		passphrase, err := s.passphrase()
+		if err != nil {
+			return nil, err
+		}
+
+		if passphrase == "" {
+			return nil, errors.New("passphrase for the secrets file is empty")
+		}
+
+		s.key, err = pbkdf2.Key(sha256.New, passphrase, file.Salt, kdfIterations, keySize)
+		if err != nil {
+			return nil, fmt.Errorf("derive key: %w", err)
+		}
+	}
+
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+
+	return aead, nil
+}
+
+func (s *FileStore) verify(file secretsFile, aead cipher.AEAD) error {
+	for account, sealed := range file.Secrets {
+		nonceSize := aead.NonceSize()
+		if len(sealed) < nonceSize {
+			continue
+		}
+
+		if _, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(account)); err != nil {
+			s.key = nil
+
+			return ErrWrongPassphrase
+		}
+
+		return nil
+	}
+
+	return nil
+}
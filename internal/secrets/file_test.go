@@ -0,0 +1,71 @@
+package secrets_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func passphrase(value string) func() (string, error) {
+	return func() (string, error) { return value, nil }
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aida", "secrets.json")
+	store := secrets.NewFileStore(path, passphrase("correct horse"))
+
+	_, err := store.Get("openai")
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+
+	require.NoError(t, store.Set("openai", "sk-test-123"))
+	require.NoError(t, store.Set("groq", "gsk-test-456"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-test-123")
+
+	reopened := secrets.NewFileStore(path, passphrase("correct horse"))
+
+	key, err := reopened.Get("openai")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-test-123", key)
+
+	require.NoError(t, reopened.Delete("openai"))
+	_, err = reopened.Get("openai")
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+
+	key, err = reopened.Get("groq")
+	require.NoError(t, err)
+	assert.Equal(t, "gsk-test-456", key)
+}
+
+func TestFileStoreWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	require.NoError(t, secrets.NewFileStore(path, passphrase("right")).Set("openai", "sk-test-123"))
+
+	wrong := secrets.NewFileStore(path, passphrase("wrong"))
+
+	_, err := wrong.Get("openai")
+	require.ErrorIs(t, err, secrets.ErrWrongPassphrase)
+	require.ErrorIs(t, wrong.Set("groq", "gsk-test-456"), secrets.ErrWrongPassphrase)
+}
+
+func TestOpenFileBackend(t *testing.T) {
+	file := secrets.NewFileStore(filepath.Join(t.TempDir(), "secrets.json"), passphrase("pw"))
+
+	store, err := secrets.Open(config.SecretsBackendFile, file)
+	require.NoError(t, err)
+	assert.Same(t, file, store)
+
+	_, err = secrets.Open("vault", file)
+	require.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// macOSNotFound is the exit status of "security" when no item matches.
+const macOSNotFound = 44
+
+// osKeyring returns the keyring of the OS when its command line tool is
+// available: "security" on macOS, "secret-tool" for the Secret Service on
+// Linux, which needs a D-Bus session.
+func osKeyring() (Store, bool) {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return macOSKeychain{}, true
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if _, err := exec.LookPath("secret-tool"); err == nil && os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+			return secretService{}, true
+		}
+	}
+
+	return nil, false
+}
+
+// macOSKeychain keeps secrets as generic passwords in the login keychain.
+type macOSKeychain struct{}
+
+func (macOSKeychain) Name() string {
+	return "macOS keychain"
+}
+
+func (macOSKeychain) Get(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if exitCode(err) == macOSNotFound {
+		return "", ErrNotFound
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("read keychain: %w", err)
+	}
+
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+func (macOSKeychain) Set(account, secret string) error {
+	// Commands are read from stdin so the secret does not show up in the
+	// process list.
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		strconv.Quote(service), strconv.Quote(account), strconv.Quote(secret)))
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("write keychain: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func (macOSKeychain) Delete(account string) error {
+	err := exec.Command("security", "delete-generic-password", "-s", service, "-a", account).Run()
+	if exitCode(err) == macOSNotFound {
+		return ErrNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("delete from keychain: %w", err)
+	}
+
+	return nil
+}
+
+// secretService keeps secrets in the freedesktop Secret Service, such as
+// GNOME Keyring or KWallet.
+type secretService struct{}
+
+func (secretService) Name() string {
+	return "Secret Service keyring"
+}
+
+func (secretService) Get(account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", service, "account", account).Output()
+	if err != nil || len(out) == 0 {
+		// secret-tool exits with status 1 and no output when nothing matches.
+		if err == nil || exitCode(err) == 1 {
+			return "", ErrNotFound
+		}
+
+		return "", fmt.Errorf("read keyring: %w", err)
+	}
+
+	return string(out), nil
+}
+
+func (secretService) Set(account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", service+" "+account, "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("write keyring: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func (secretService) Delete(account string) error {
+	if err := exec.Command("secret-tool", "clear", "service", service, "account", account).Run(); err != nil {
+		return fmt.Errorf("delete from keyring: %w", err)
+	}
+
+	return nil
+}
+
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return 0
+}
@@ -0,0 +1,83 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/metalagman/aida/internal/config"
+)
+
+// commandTimeout bounds an api_key_cmd, leaving time to unlock a password
+// manager.
+const commandTimeout = time.Minute
+
+// ResolveAPIKey returns the API key of a provider entry, taken in order from
+// api_key, the variable named by api_key_env, the first line printed by api_key_cmd, or
+// the secret store when api_key_keyring is set. The store is opened only
+// when needed. An entry without any of them has no key.
+func ResolveAPIKey(
+	ctx context.Context,
+	name string,
+	provider config.ProviderConfig,
+	store func() (Store, error),
+) (string, error) {
+	switch {
+	case provider.APIKey != "":
+		return provider.APIKey, nil
+	case provider.APIKeyEnv != "":
+		key := strings.TrimSpace(os.Getenv(provider.APIKeyEnv))
+		if key == "" {
+			return "", fmt.Errorf("environment variable %s holding the API key is not set", provider.APIKeyEnv)
+		}
+
+		return key, nil
+	case provider.APIKeyCmd != "":
+		return commandOutput(ctx, provider.APIKeyCmd)
+	case provider.APIKeyKeyring:
+		s, err := store()
+		if err != nil {
+			return "", err
+		}
+
+		key, err := s.Get(name)
+		if errors.Is(err, ErrNotFound) {
+			return "", fmt.Errorf("no API key for %s in the %s: run aida providers configure %s", name, s.Name(), name)
+		}
+
+		return key, err
+	default:
+		return "", nil
+	}
+}
+
+// commandOutput runs command in the shell and returns the first line of its
+// output, which is where password managers such as pass print the password.
+func commandOutput(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("api_key_cmd %q failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	key, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", fmt.Errorf("api_key_cmd %q printed no API key", command)
+	}
+
+	return key, nil
+}
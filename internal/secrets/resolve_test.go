@@ -0,0 +1,75 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveAPIKey(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "sk-from-env")
+
+	file := secrets.NewFileStore(filepath.Join(t.TempDir(), "secrets.json"), passphrase("pw"))
+	require.NoError(t, file.Set("openai", "sk-from-store"))
+
+	store := func() (secrets.Store, error) { return file, nil }
+
+	tests := []struct {
+		name     string
+		provider config.ProviderConfig
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "plain key wins",
+			provider: config.ProviderConfig{APIKey: "sk-plain", APIKeyEnv: "TEST_OPENAI_KEY"},
+			want:     "sk-plain",
+		},
+		{name: "environment", provider: config.ProviderConfig{APIKeyEnv: "TEST_OPENAI_KEY"}, want: "sk-from-env"},
+		{name: "unset environment", provider: config.ProviderConfig{APIKeyEnv: "TEST_MISSING_KEY"}, wantErr: "not set"},
+		{
+			name:     "command",
+			provider: config.ProviderConfig{APIKeyCmd: "printf 'sk-from-cmd\\nlogin: me\\n'"},
+			want:     "sk-from-cmd",
+		},
+		{name: "failing command", provider: config.ProviderConfig{APIKeyCmd: "echo locked >&2; exit 1"}, wantErr: "locked"},
+		{name: "keyring", provider: config.ProviderConfig{APIKeyKeyring: true}, want: "sk-from-store"},
+		{name: "none", provider: config.ProviderConfig{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := secrets.ResolveAPIKey(context.Background(), "openai", tt.provider, store)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveAPIKeyMissingFromStore(t *testing.T) {
+	file := secrets.NewFileStore(filepath.Join(t.TempDir(), "secrets.json"), passphrase("pw"))
+
+	_, err := secrets.ResolveAPIKey(context.Background(), "groq", config.ProviderConfig{APIKeyKeyring: true},
+		func() (secrets.Store, error) { return file, nil })
+	require.ErrorContains(t, err, "run aida providers configure groq")
+}
+
+func TestResolveAPIKeyOpensStoreOnlyWhenNeeded(t *testing.T) {
+	store := func() (secrets.Store, error) { return nil, errors.New("store opened") }
+
+	got, err := secrets.ResolveAPIKey(context.Background(), "openai", config.ProviderConfig{APIKey: "sk-plain"}, store)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-plain", got)
+}
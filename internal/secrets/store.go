@@ -0,0 +1,41 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/metalagman/aida/internal/config"
+)
+
+// service names aida's entries in the OS keyring.
+const service = "aida"
+
+// ErrNotFound is returned when a store holds no secret for an account.
+var ErrNotFound = errors.New("secret not found")
+
+// Store keeps one secret per account, such as a provider entry name.
+type Store interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	Delete(account string) error
+	// Name describes where the secrets are kept.
+	Name() string
+}
+
+// Open returns the store for a config.SecretsConfig backend. The keyring
+// backend falls back to file when the OS keyring is not available, as on
+// headless Linux.
+func Open(backend string, file *FileStore) (Store, error) {
+	switch backend {
+	case config.SecretsBackendKeyring:
+		if keyring, ok := osKeyring(); ok {
+			return keyring, nil
+		}
+
+		return file, nil
+	case config.SecretsBackendFile:
+		return file, nil
+	default:
+		return nil, fmt.Errorf("unsupported secrets backend %q", backend)
+	}
+}
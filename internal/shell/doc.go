@@ -0,0 +1,2 @@
+// Package shell parses shell commands into a simplified form for static analysis.
+package shell
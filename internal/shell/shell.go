@@ -0,0 +1,327 @@
+package shell
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Script is the simplified form of a parsed shell command.
+type Script struct {
+	// Calls lists every program invocation, including ones nested in pipelines,
+	// command substitutions, subshells and wrappers such as sudo or xargs.
+	Calls []Call
+	// Redirects lists every file redirection in the script.
+	Redirects []Redirect
+}
+
+// Call is a single program invocation.
+type Call struct {
+	// Program is the base name of the executable, e.g. "rm" for "/bin/rm".
+	Program string
+	Args    []string
+	// Wrappers lists the programs this call was launched through, outermost
+	// first, e.g. ["sudo", "xargs"] for "sudo xargs rm".
+	Wrappers []string
+	// Opaque is set for eval and shell -c calls whose script cannot be parsed
+	// or is only known at runtime, e.g. sh -c "$SCRIPT".
+	Opaque bool
+}
+
+// Redirect is a file redirection such as "> out.txt".
+type Redirect struct {
+	Op     string
+	Target string
+}
+
+// Writes reports whether the redirection writes to its target.
+func (r Redirect) Writes() bool {
+	switch r.Op {
+	case ">", ">>", ">|", "&>", "&>>", "<>":
+		return true
+	default:
+		return false
+	}
+}
+
+// Dynamic reports whether the program name is only known at runtime, e.g. "$CMD".
+func (c Call) Dynamic() bool {
+	return strings.ContainsAny(c.Program, "$`")
+}
+
+// HasWrapper reports whether the call was launched through the named program.
+func (c Call) HasWrapper(name string) bool {
+	return slices.Contains(c.Wrappers, name)
+}
+
+// Parse parses a shell command written in bash syntax.
+func Parse(command string) (*Script, error) {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil, fmt.Errorf("parse shell command: %w", err)
+	}
+
+	script := &Script{}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Stmt:
+			for _, redirect := range n.Redirs {
+				if redirect.Word == nil || redirect.Hdoc != nil {
+					continue
+				}
+
+				script.Redirects = append(script.Redirects, Redirect{
+					Op:     redirect.Op.String(),
+					Target: wordString(redirect.Word),
+				})
+			}
+		case *syntax.CallExpr:
+			args := make([]string, 0, len(n.Args))
+			literal := make([]bool, 0, len(n.Args))
+
+			for _, word := range n.Args {
+				args = append(args, wordString(word))
+				literal = append(literal, wordLiteral(word))
+			}
+
+			script.addCall(args, literal, nil)
+		}
+
+		return true
+	})
+
+	return script, nil
+}
+
+// addCall records a call and unwraps wrapper programs into the calls they launch.
+// literal reports for each argument whether it has no expansions.
+func (s *Script) addCall(args []string, literal []bool, wrappers []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	call := Call{
+		Program:  path.Base(args[0]),
+		Args:     args[1:],
+		Wrappers: wrappers,
+	}
+
+	nested := append(append([]string(nil), wrappers...), call.Program)
+
+	var inner *Script
+
+	switch {
+	case call.Program == "eval":
+		inner, call.Opaque = parseNested(strings.Join(call.Args, " "), !slices.Contains(literal[1:], false))
+	case IsShell(call.Program):
+		if i := shellScriptArg(call.Args); i >= 0 {
+			inner, call.Opaque = parseNested(call.Args[i], literal[i+1])
+		}
+	}
+
+	s.Calls = append(s.Calls, call)
+
+	if inner != nil {
+		s.addNested(inner, nested)
+	}
+
+	switch {
+	case call.Program == "find":
+		for _, r := range findExecArgs(call.Args) {
+			s.addCall(call.Args[r[0]:r[1]], literal[r[0]+1:r[1]+1], nested)
+		}
+	default:
+		if spec, ok := wrapperSpecs[call.Program]; ok {
+			rest := spec.unwrap(call.Args)
+			s.addCall(rest, literal[len(literal)-len(rest):], nested)
+		}
+	}
+}
+
+// parseNested parses a script run by eval or a shell. It reports the script as
+// opaque when it is built from expansions or fails to parse.
+func parseNested(script string, literal bool) (*Script, bool) {
+	if !literal {
+		return nil, true
+	}
+
+	inner, err := Parse(script)
+	if err != nil {
+		return nil, true
+	}
+
+	return inner, false
+}
+
+func (s *Script) addNested(inner *Script, wrappers []string) {
+	for _, call := range inner.Calls {
+		call.Wrappers = append(append([]string(nil), wrappers...), call.Wrappers...)
+		s.Calls = append(s.Calls, call)
+	}
+
+	s.Redirects = append(s.Redirects, inner.Redirects...)
+}
+
+// wrapperSpec describes how to find the wrapped command in a wrapper's arguments.
+type wrapperSpec struct {
+	// valueFlags are options that consume the following argument.
+	valueFlags []string
+	// positionals is the number of non-option arguments before the command.
+	positionals int
+	// assignments skips leading NAME=value arguments.
+	assignments bool
+}
+
+var wrapperSpecs = map[string]wrapperSpec{
+	"sudo":    {valueFlags: []string{"-u", "-g", "-C", "-D", "-h", "-p", "-r", "-t", "-U"}},
+	"doas":    {valueFlags: []string{"-u", "-C"}},
+	"pkexec":  {valueFlags: []string{"--user"}},
+	"env":     {valueFlags: []string{"-u", "-C", "-S", "--unset", "--chdir"}, assignments: true},
+	"nice":    {valueFlags: []string{"-n", "--adjustment"}},
+	"ionice":  {valueFlags: []string{"-c", "-n", "-p"}},
+	"nohup":   {},
+	"time":    {valueFlags: []string{"-f", "-o"}},
+	"timeout": {valueFlags: []string{"-s", "-k", "--signal", "--kill-after"}, positionals: 1},
+	"exec":    {valueFlags: []string{"-a"}},
+	"command": {},
+	"builtin": {},
+	"xargs": {valueFlags: []string{
+		"-I", "-i", "-n", "-P", "-d", "-L", "-l", "-s", "-E", "-e", "-a",
+		"--max-args", "--max-procs", "--delimiter", "--arg-file", "--replace",
+	}},
+	"watch":  {valueFlags: []string{"-n", "-d", "--interval"}},
+	"stdbuf": {valueFlags: []string{"-i", "-o", "-e"}},
+	"chroot": {positionals: 1},
+}
+
+func (w wrapperSpec) unwrap(args []string) []string {
+	positionals := w.positionals
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--":
+			return args[i+1:]
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			if slices.Contains(w.valueFlags, arg) {
+				i++
+			}
+		case w.assignments && strings.Contains(arg, "="):
+			continue
+		case positionals > 0:
+			positionals--
+		default:
+			return args[i:]
+		}
+	}
+
+	return nil
+}
+
+// IsShell reports whether the program is a shell that can run a script via -c.
+func IsShell(program string) bool {
+	switch program {
+	case "sh", "bash", "zsh", "dash", "ksh", "fish":
+		return true
+	default:
+		return false
+	}
+}
+
+// shellScriptArg returns the index of the script passed to a shell via -c,
+// or -1 if there is none.
+func shellScriptArg(args []string) int {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "c") {
+			if i+1 < len(args) {
+				return i + 1
+			}
+		}
+	}
+
+	return -1
+}
+
+// findExecArgs returns the start and end indexes of the commands run by
+// find's -exec family of actions.
+func findExecArgs(args []string) [][2]int {
+	var commands [][2]int
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-exec", "-execdir", "-ok", "-okdir":
+		default:
+			continue
+		}
+
+		end := i + 1
+		for end < len(args) && !isFindExecTerminator(args[end]) {
+			end++
+		}
+
+		commands = append(commands, [2]int{i + 1, end})
+		i = end
+	}
+
+	return commands
+}
+
+func isFindExecTerminator(arg string) bool {
+	return arg == ";" || arg == `\;` || arg == "+"
+}
+
+// wordString returns the literal value of a word, falling back to its source
+// text when it contains expansions.
+func wordString(word *syntax.Word) string {
+	var sb strings.Builder
+
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				if lit, ok := inner.(*syntax.Lit); ok {
+					sb.WriteString(lit.Value)
+				} else {
+					printNode(&sb, inner)
+				}
+			}
+		default:
+			printNode(&sb, part)
+		}
+	}
+
+	return sb.String()
+}
+
+// wordLiteral reports whether a word has no expansions, so its value is known
+// before the command runs.
+func wordLiteral(word *syntax.Word) bool {
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit, *syntax.SglQuoted:
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				if _, ok := inner.(*syntax.Lit); !ok {
+					return false
+				}
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func printNode(sb *strings.Builder, node syntax.Node) {
+	_ = syntax.NewPrinter().Print(sb, node)
+}
@@ -0,0 +1,110 @@
+package shell_test
+
+import (
+	"testing"
+
+	"github.com/metalagman/aida/internal/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		calls     []shell.Call
+		redirects []shell.Redirect
+	}{
+		{
+			name:    "pipeline",
+			command: "ls -la | grep foo",
+			calls: []shell.Call{
+				{Program: "ls", Args: []string{"-la"}},
+				{Program: "grep", Args: []string{"foo"}},
+			},
+		},
+		{
+			name:    "absolute program path and quotes",
+			command: `/bin/echo "hello world" 'x'`,
+			calls: []shell.Call{
+				{Program: "echo", Args: []string{"hello world", "x"}},
+			},
+		},
+		{
+			name:    "sudo unwraps with option values",
+			command: "sudo -u root rm -rf /tmp/x",
+			calls: []shell.Call{
+				{Program: "sudo", Args: []string{"-u", "root", "rm", "-rf", "/tmp/x"}},
+				{Program: "rm", Args: []string{"-rf", "/tmp/x"}, Wrappers: []string{"sudo"}},
+			},
+		},
+		{
+			name:    "find exec and xargs",
+			command: `find . -name '*.tmp' -exec rm {} \; ; ls | xargs -n 1 du`,
+			calls: []shell.Call{
+				{Program: "find", Args: []string{".", "-name", "*.tmp", "-exec", "rm", "{}", `\;`}},
+				{Program: "rm", Args: []string{"{}"}, Wrappers: []string{"find"}},
+				{Program: "ls", Args: []string{}},
+				{Program: "xargs", Args: []string{"-n", "1", "du"}},
+				{Program: "du", Args: []string{}, Wrappers: []string{"xargs"}},
+			},
+		},
+		{
+			name:    "shell -c is parsed",
+			command: `bash -c "mkdir out && touch out/file"`,
+			calls: []shell.Call{
+				{Program: "bash", Args: []string{"-c", "mkdir out && touch out/file"}},
+				{Program: "mkdir", Args: []string{"out"}, Wrappers: []string{"bash"}},
+				{Program: "touch", Args: []string{"out/file"}, Wrappers: []string{"bash"}},
+			},
+		},
+		{
+			name:    "eval is parsed",
+			command: `eval "shutdown now"`,
+			calls: []shell.Call{
+				{Program: "eval", Args: []string{"shutdown now"}},
+				{Program: "shutdown", Args: []string{"now"}, Wrappers: []string{"eval"}},
+			},
+		},
+		{
+			name:    "shell script from an expansion is opaque",
+			command: `sh -c "$SCRIPT"`,
+			calls: []shell.Call{
+				{Program: "sh", Args: []string{"-c", "$SCRIPT"}, Opaque: true},
+			},
+		},
+		{
+			name:    "unparseable eval is opaque",
+			command: `eval 'echo ('`,
+			calls: []shell.Call{
+				{Program: "eval", Args: []string{"echo ("}, Opaque: true},
+			},
+		},
+		{
+			name:    "redirects and command substitution",
+			command: "echo $(date) > out.log 2>/dev/null",
+			calls: []shell.Call{
+				{Program: "echo", Args: []string{"$(date)"}},
+				{Program: "date", Args: []string{}},
+			},
+			redirects: []shell.Redirect{
+				{Op: ">", Target: "out.log"},
+				{Op: ">", Target: "/dev/null"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			script, err := shell.Parse(tc.command)
+			require.NoError(t, err)
+			assert.Equal(t, tc.calls, script.Calls)
+			assert.Equal(t, tc.redirects, script.Redirects)
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := shell.Parse("echo 'unterminated")
+	require.Error(t, err)
+}
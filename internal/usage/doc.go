@@ -0,0 +1,3 @@
+// Package usage keeps a local ledger of the tokens spent on providers and
+// summarizes it with estimated costs.
+package usage
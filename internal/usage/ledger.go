@@ -0,0 +1,127 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/llm/provider"
+)
+
+// ledgerFile is the ledger's file name inside config.DataDir.
+const ledgerFile = "usage.jsonl"
+
+// Entry is the usage of one provider call.
+type Entry struct {
+	Time             time.Time `json:"time"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+}
+
+// NewEntry records u as used at t.
+func NewEntry(u provider.Usage, t time.Time) Entry {
+	return Entry{
+		Time:             t.UTC(),
+		Provider:         u.Provider,
+		Model:            u.Model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+	}
+}
+
+// Ledger appends entries to a JSON lines file.
+type Ledger struct {
+	path string
+}
+
+// NewLedger returns a ledger stored at path.
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path}
+}
+
+// DefaultLedger returns the ledger in config.DataDir.
+func DefaultLedger() (*Ledger, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLedger(filepath.Join(dir, ledgerFile)), nil
+}
+
+// Path returns the file the ledger is stored in.
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Record appends entry to the ledger.
+func (l *Ledger) Record(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal usage: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), config.DirPerm); err != nil {
+		return fmt.Errorf("create usage dir: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, config.FilePerm)
+	if err != nil {
+		return fmt.Errorf("open usage ledger: %w", err)
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+
+		return fmt.Errorf("write usage ledger: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close usage ledger: %w", err)
+	}
+
+	return nil
+}
+
+// Entries returns the entries recorded at or after since, oldest first. A
+// missing ledger has no entries; lines that cannot be parsed, such as one cut
+// short by an interrupted write, are skipped.
+func (l *Ledger) Entries(since time.Time) ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("open usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read usage ledger: %w", err)
+	}
+
+	return entries, nil
+}
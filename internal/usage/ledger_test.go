@@ -0,0 +1,46 @@
+package usage_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/metalagman/aida/internal/llm/provider"
+	"github.com/metalagman/aida/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aida", "usage.jsonl")
+	ledger := usage.NewLedger(path)
+
+	entries, err := ledger.Entries(time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	old := usage.NewEntry(provider.Usage{Provider: "openai", Model: "gpt-4o", PromptTokens: 10}, day)
+	recent := usage.NewEntry(provider.Usage{Provider: "ollama", Model: "llama3.2"}, day.Add(time.Hour))
+
+	require.NoError(t, ledger.Record(old))
+
+	// A line cut short by an interrupted write is skipped.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"time":"2026-03-01T12:30:00Z","prov` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.NoError(t, ledger.Record(recent))
+
+	entries, err = ledger.Entries(time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []usage.Entry{old, recent}, entries)
+
+	entries, err = ledger.Entries(day.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []usage.Entry{recent}, entries)
+}
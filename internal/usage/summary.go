@@ -0,0 +1,63 @@
+package usage
+
+import (
+	"sort"
+
+	"github.com/metalagman/aida/internal/config"
+)
+
+// Summary totals the entries of one provider and model.
+type Summary struct {
+	Provider         string
+	Model            string
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	// Cost is the estimated cost in US dollars. It is only meaningful when
+	// Priced is set, i.e. prices list the model.
+	Cost   float64
+	Priced bool
+}
+
+// Summarize groups entries by provider and model, sorted by both, and
+// estimates their cost with prices.
+func Summarize(entries []Entry, prices []config.Price) []Summary {
+	type key struct{ provider, model string }
+
+	totals := map[key]*Summary{}
+
+	for _, entry := range entries {
+		k := key{entry.Provider, entry.Model}
+
+		total, ok := totals[k]
+		if !ok {
+			total = &Summary{Provider: entry.Provider, Model: entry.Model}
+			totals[k] = total
+		}
+
+		total.Requests++
+		total.PromptTokens += entry.PromptTokens
+		total.CompletionTokens += entry.CompletionTokens
+	}
+
+	summaries := make([]Summary, 0, len(totals))
+
+	for _, total := range totals {
+		if price, ok := config.FindPrice(prices, total.Provider, total.Model); ok {
+			total.Cost = price.Cost(total.PromptTokens, total.CompletionTokens)
+			total.Priced = true
+		}
+
+		summaries = append(summaries, *total)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Provider != summaries[j].Provider {
+			return summaries[i].Provider < summaries[j].Provider
+		}
+
+		return summaries[i].Model < summaries[j].Model
+	})
+
+	return summaries
+}
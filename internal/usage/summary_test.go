@@ -0,0 +1,31 @@
+package usage_test
+
+import (
+	"testing"
+
+	"github.com/metalagman/aida/internal/config"
+	"github.com/metalagman/aida/internal/usage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	entries := []usage.Entry{
+		{Provider: "openai", Model: "gpt-4o", PromptTokens: 1_000_000, CompletionTokens: 100_000},
+		{Provider: "ollama", Model: "llama3.2", PromptTokens: 300, CompletionTokens: 30},
+		{Provider: "openai", Model: "gpt-4o", PromptTokens: 1_000_000, CompletionTokens: 100_000},
+		{Provider: "groq", Model: "gpt-4o", PromptTokens: 1_000_000},
+	}
+	prices := []config.Price{
+		{Model: "gpt-4o", Input: 2.5, Output: 10},
+		{Provider: "groq", Model: "GPT-4o", Input: 1},
+	}
+
+	assert.Equal(t, []usage.Summary{
+		{Provider: "groq", Model: "gpt-4o", Requests: 1, PromptTokens: 1_000_000, Cost: 1, Priced: true},
+		{Provider: "ollama", Model: "llama3.2", Requests: 1, PromptTokens: 300, CompletionTokens: 30},
+		{
+			Provider: "openai", Model: "gpt-4o", Requests: 2,
+			PromptTokens: 2_000_000, CompletionTokens: 200_000, Cost: 7, Priced: true,
+		},
+	}, usage.Summarize(entries, prices))
+}